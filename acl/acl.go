@@ -457,6 +457,28 @@ func (a *ACL) matchingSecureVariablesCapabilitySet(ns, path string) (capabilityS
 	return a.findClosestMatchingGlob(a.wildcardSecureVariables, ns+"\x00"+path)
 }
 
+// SecureVariablesPathRule returns the namespace and path spec of the
+// secure_variables path rule that applies to the namespace and path, and
+// whether any rule applies. Globs are resolved as they are for
+// AllowSecureVariableOperation. Management ACLs have no rules.
+func (a *ACL) SecureVariablesPathRule(ns, path string) (string, string, bool) {
+	if a.management {
+		return "", "", false
+	}
+
+	key := ns + "\x00" + path
+	if _, ok := a.secureVariables.Get([]byte(key)); !ok {
+		match, ok := closestMatchingGlob(a.wildcardSecureVariables, key)
+		if !ok {
+			return "", "", false
+		}
+		key = match.name
+	}
+
+	parts := strings.SplitN(key, "\x00", 2)
+	return parts[0], parts[1], true
+}
+
 type matchingGlob struct {
 	name          string
 	difference    int
@@ -464,17 +486,25 @@ type matchingGlob struct {
 }
 
 func (a *ACL) findClosestMatchingGlob(radix *iradix.Tree, ns string) (capabilitySet, bool) {
+	match, ok := closestMatchingGlob(radix, ns)
+	if !ok {
+		return capabilitySet{}, false
+	}
+	return match.capabilitySet, true
+}
+
+func closestMatchingGlob(radix *iradix.Tree, ns string) (matchingGlob, bool) {
 	// First, find all globs that match.
 	matchingGlobs := findAllMatchingWildcards(radix, ns)
 
 	// If none match, let's return.
 	if len(matchingGlobs) == 0 {
-		return capabilitySet{}, false
+		return matchingGlob{}, false
 	}
 
 	// If a single matches, lets be efficient and return early.
 	if len(matchingGlobs) == 1 {
-		return matchingGlobs[0].capabilitySet, true
+		return matchingGlobs[0], true
 	}
 
 	// Stable sort the matched globs, based on the character difference between
@@ -484,7 +514,7 @@ func (a *ACL) findClosestMatchingGlob(radix *iradix.Tree, ns string) (capability
 		return matchingGlobs[i].difference <= matchingGlobs[j].difference
 	})
 
-	return matchingGlobs[0].capabilitySet, true
+	return matchingGlobs[0], true
 }
 
 func findAllMatchingWildcards(radix *iradix.Tree, name string) []matchingGlob {
@@ -602,6 +602,46 @@ func TestSecureVariablesMatching(t *testing.T) {
 	}
 }
 
+func TestSecureVariablesPathRule(t *testing.T) {
+	ci.Parallel(t)
+
+	policy, err := Parse(`
+namespace "ns" {
+  secure_variables {
+    path "foo/bar" { capabilities = ["read"] }
+    path "foo/*" { capabilities = ["list"] }
+  }
+}
+namespace "other-*" {
+  secure_variables {
+    path "*" { capabilities = ["write"] }
+  }
+}`)
+	require.NoError(t, err)
+	acl, err := NewACL(false, []*Policy{policy})
+	require.NoError(t, err)
+
+	tests := []struct {
+		ns, path       string
+		ruleNS, ruleSV string
+		found          bool
+	}{
+		{ns: "ns", path: "foo/bar", ruleNS: "ns", ruleSV: "foo/bar", found: true},
+		{ns: "ns", path: "foo/baz", ruleNS: "ns", ruleSV: "foo/*", found: true},
+		{ns: "other-ns", path: "any/path", ruleNS: "other-*", ruleSV: "*", found: true},
+		{ns: "ns", path: "bar", found: false},
+	}
+	for _, tc := range tests {
+		ruleNS, ruleSV, found := acl.SecureVariablesPathRule(tc.ns, tc.path)
+		require.Equal(t, tc.found, found, tc.path)
+		require.Equal(t, tc.ruleNS, ruleNS, tc.path)
+		require.Equal(t, tc.ruleSV, ruleSV, tc.path)
+	}
+
+	_, _, found := ManagementACL.SecureVariablesPathRule("ns", "foo/bar")
+	require.False(t, found)
+}
+
 func TestACL_matchingCapabilitySet_returnsAllMatches(t *testing.T) {
 	ci.Parallel(t)
 
@@ -11,13 +11,15 @@ import (
 )
 
 const (
-	TopicDeployment Topic = "Deployment"
-	TopicEvaluation Topic = "Evaluation"
-	TopicAllocation Topic = "Allocation"
-	TopicJob        Topic = "Job"
-	TopicNode       Topic = "Node"
-	TopicService    Topic = "Service"
-	TopicAll        Topic = "*"
+	TopicDeployment     Topic = "Deployment"
+	TopicEvaluation     Topic = "Evaluation"
+	TopicAllocation     Topic = "Allocation"
+	TopicJob            Topic = "Job"
+	TopicNode           Topic = "Node"
+	TopicService        Topic = "Service"
+	TopicVariable       Topic = "Variable"
+	TopicVariableAccess Topic = "VariableAccess"
+	TopicAll            Topic = "*"
 )
 
 // Events is a set of events for a corresponding index. Events returned for the
@@ -102,13 +104,50 @@ func (e *Event) Service() (*ServiceRegistration, error) {
 	return out.Service, nil
 }
 
+// SecureVariable returns a SecureVariableMetadata struct from a given event
+// payload. If the Event Topic is Variable this will return a valid
+// SecureVariableMetadata.
+func (e *Event) SecureVariable() (*SecureVariableMetadata, error) {
+	out, err := e.decodePayload()
+	if err != nil {
+		return nil, err
+	}
+	return out.SecureVariable, nil
+}
+
+// SecureVariableAccess returns a SecureVariableAccess struct from a given
+// event payload. If the Event Topic is VariableAccess this will return a valid
+// SecureVariableAccess.
+func (e *Event) SecureVariableAccess() (*SecureVariableAccess, error) {
+	out, err := e.decodePayload()
+	if err != nil {
+		return nil, err
+	}
+	return out.SecureVariableAccess, nil
+}
+
 type eventPayload struct {
-	Allocation *Allocation          `mapstructure:"Allocation"`
-	Deployment *Deployment          `mapstructure:"Deployment"`
-	Evaluation *Evaluation          `mapstructure:"Evaluation"`
-	Job        *Job                 `mapstructure:"Job"`
-	Node       *Node                `mapstructure:"Node"`
-	Service    *ServiceRegistration `mapstructure:"Service"`
+	Allocation           *Allocation             `mapstructure:"Allocation"`
+	Deployment           *Deployment             `mapstructure:"Deployment"`
+	Evaluation           *Evaluation             `mapstructure:"Evaluation"`
+	Job                  *Job                    `mapstructure:"Job"`
+	Node                 *Node                   `mapstructure:"Node"`
+	Service              *ServiceRegistration    `mapstructure:"Service"`
+	SecureVariable       *SecureVariableMetadata `mapstructure:"SecureVariable"`
+	SecureVariableAccess *SecureVariableAccess   `mapstructure:"SecureVariableAccess"`
+}
+
+// SecureVariableAccess records who read, wrote, or deleted a secure variable.
+// It never holds the items of the variable.
+type SecureVariableAccess struct {
+	Namespace    string
+	Path         string
+	AccessorID   string
+	AllocationID string
+	JobID        string
+	TaskName     string
+	ModifyIndex  uint64
+	CheckIndex   *uint64
 }
 
 func (e *Event) decodePayload() (*eventPayload, error) {
@@ -100,3 +100,29 @@ type KeyringRotateOptions struct {
 	Full      bool
 	Algorithm EncryptionAlgorithm
 }
+
+// RotateStatus reports the progress of re-encrypting secure variables after
+// a full rotation.
+func (k *Keyring) RotateStatus(q *QueryOptions) (*KeyringRotateStatus, *QueryMeta, error) {
+	var resp KeyringRotateStatus
+	qm, err := k.client.query("/v1/operator/keyring/rotate/status", &resp, q)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &resp, qm, nil
+}
+
+// KeyringRotateStatus is the progress of full key rotations. Rekeying is
+// empty once every secure variable is encrypted with the active key.
+type KeyringRotateStatus struct {
+	ActiveKeyID string
+	Rekeying    []*KeyringRekeyStatus
+}
+
+// KeyringRekeyStatus is the progress of re-encrypting the secure variables
+// of a root key with the active key.
+type KeyringRekeyStatus struct {
+	KeyID      string
+	CreateTime time.Time
+	Remaining  int
+}
@@ -67,6 +67,38 @@ func (sv *SecureVariables) Read(path string, qo *QueryOptions) (*SecureVariable,
 	return svar, qm, nil
 }
 
+// ReadVersion is used to query a single version of a secure variable by path
+// and modify index. The version can be the current one or any of the
+// previous versions kept by the server. This will error if the version is
+// not found.
+func (sv *SecureVariables) ReadVersion(path string, version uint64, qo *QueryOptions) (*SecureVariable, *QueryMeta, error) {
+
+	path = cleanPathString(path)
+	var svar = new(SecureVariable)
+	qm, err := sv.readInternal("/v1/var/"+path+"?version="+fmt.Sprint(version), &svar, qo)
+	if err != nil {
+		return nil, nil, err
+	}
+	if svar == nil {
+		return nil, qm, errors.New(ErrVariableNotFound)
+	}
+	return svar, qm, nil
+}
+
+// History is used to list the versions of a secure variable, newest first.
+// The current version is followed by the previous versions kept by the
+// server.
+func (sv *SecureVariables) History(path string, qo *QueryOptions) ([]*SecureVariableMetadata, *QueryMeta, error) {
+
+	path = cleanPathString(path)
+	var resp []*SecureVariableMetadata
+	qm, err := sv.client.query("/v1/var/"+path+"?history=true", &resp, qo)
+	if err != nil {
+		return nil, nil, err
+	}
+	return resp, qm, nil
+}
+
 // Peek is used to query a single secure variable by path, but does not error
 // when the variable is not found
 func (sv *SecureVariables) Peek(path string, qo *QueryOptions) (*SecureVariable, *QueryMeta, error) {
@@ -154,6 +186,39 @@ func (sv *SecureVariables) PrefixList(prefix string, qo *QueryOptions) ([]*Secur
 	return sv.List(qo)
 }
 
+// Tree is used to list the secure variables under a prefix as a tree of path
+// segments. The prefix and pagination options are passed via QueryOptions.
+func (sv *SecureVariables) Tree(prefix string, qo *QueryOptions) (*SecureVariableTreeNode, *QueryMeta, error) {
+
+	if qo == nil {
+		qo = &QueryOptions{Prefix: prefix}
+	} else {
+		qo.Prefix = prefix
+	}
+
+	var resp SecureVariableTreeNode
+	qm, err := sv.client.query("/v1/vars/tree", &resp, qo)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &resp, qm, nil
+}
+
+// Copy is used to copy a secure variable, or with Recurse every secure
+// variable under a path, to a new path. With Move the copied variables are
+// deleted. Either all the variables are copied or none are.
+func (sv *SecureVariables) Copy(req *SecureVariablesCopyRequest, qo *WriteOptions) ([]*SecureVariableCopy, *WriteMeta, error) {
+
+	req.Path = cleanPathString(req.Path)
+	req.DestPath = cleanPathString(req.DestPath)
+	var resp []*SecureVariableCopy
+	wm, err := sv.client.write("/v1/vars/copy", req, &resp, qo)
+	if err != nil {
+		return nil, nil, err
+	}
+	return resp, wm, nil
+}
+
 // GetItems returns the inner Items collection from a secure variable at a
 // given path
 func (sv *SecureVariables) GetItems(path string, qo *QueryOptions) (*SecureVariableItems, *QueryMeta, error) {
@@ -314,6 +379,11 @@ type SecureVariable struct {
 	CreateTime int64
 	ModifyTime int64
 
+	// ExpireTime is when the secure variable expires, expressed in
+	// time.UnixNanos. Expired secure variables are treated as missing and
+	// are deleted by the servers. Zero means it never expires.
+	ExpireTime int64
+
 	Items SecureVariableItems
 }
 
@@ -332,10 +402,52 @@ type SecureVariableMetadata struct {
 	// Times provided as a convenience for operators expressed time.UnixNanos
 	CreateTime int64
 	ModifyTime int64
+
+	// ExpireTime is when the secure variable expires, expressed in
+	// time.UnixNanos. Expired secure variables are treated as missing and
+	// are deleted by the servers. Zero means it never expires.
+	ExpireTime int64
 }
 
 type SecureVariableItems map[string]string
 
+// SecureVariableTreeNode is a path segment in a tree listing of secure
+// variables. Variable is set if a secure variable is stored at the path.
+type SecureVariableTreeNode struct {
+	Name     string
+	Path     string
+	Variable *SecureVariableTreeStub
+	Children []*SecureVariableTreeNode
+}
+
+// SecureVariableTreeStub is the metadata of a secure variable in a tree
+// listing, along with the number of items it holds and the size of its
+// encrypted data in bytes.
+type SecureVariableTreeStub struct {
+	SecureVariableMetadata
+	ItemCount int
+	Size      int
+}
+
+// SecureVariablesCopyRequest is used to copy or move secure variables with
+// SecureVariables.Copy. The variables are copied from the namespace of the
+// write options to DestNamespace, which defaults to the same namespace.
+type SecureVariablesCopyRequest struct {
+	Path          string
+	DestNamespace string
+	DestPath      string
+	Recurse       bool
+	Move          bool
+}
+
+// SecureVariableCopy describes a single secure variable that was copied.
+type SecureVariableCopy struct {
+	Namespace     string
+	Path          string
+	DestNamespace string
+	DestPath      string
+}
+
 // NewSecureVariable is a convenience method to more easily create a
 // ready-to-use secure variable
 func NewSecureVariable(path string) *SecureVariable {
@@ -369,6 +481,7 @@ func (sv *SecureVariable) Metadata() *SecureVariableMetadata {
 		ModifyIndex: sv.ModifyIndex,
 		CreateTime:  sv.CreateTime,
 		ModifyTime:  sv.ModifyTime,
+		ExpireTime:  sv.ExpireTime,
 	}
 }
 
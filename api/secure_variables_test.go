@@ -251,6 +251,35 @@ func TestSecureVariables_Read(t *testing.T) {
 	}
 }
 
+func TestSecureVariables_History(t *testing.T) {
+	testutil.Parallel(t)
+	c, s := makeClient(t, nil, nil)
+	defer s.Stop()
+
+	nsv := c.SecureVariables()
+	tID := fmt.Sprint(time.Now().UTC().UnixNano())
+	sv1 := SecureVariable{
+		Namespace: "default",
+		Path:      tID + "/sv1",
+		Items:     map[string]string{"kv1": "val1"},
+	}
+	writeTestVariable(t, c, &sv1)
+	sv2 := sv1.Copy()
+	sv2.Items["kv1"] = "val2"
+	writeTestVariable(t, c, sv2)
+
+	versions, _, err := nsv.History(sv1.Path, nil)
+	require.NoError(t, err)
+	require.Equal(t, []*SecureVariableMetadata{sv2.Metadata(), sv1.Metadata()}, versions)
+
+	get, _, err := nsv.ReadVersion(sv1.Path, sv1.ModifyIndex, nil)
+	require.NoError(t, err)
+	require.Equal(t, &sv1, get)
+
+	_, _, err = nsv.ReadVersion(sv1.Path, sv1.ModifyIndex-1, nil)
+	require.EqualError(t, err, ErrVariableNotFound)
+}
+
 func writeTestVariable(t *testing.T, c *Client, sv *SecureVariable) {
 	_, err := c.write("/v1/var/"+sv.Path, sv, nil, nil)
 	require.NoError(t, err, "Error writing test variable")
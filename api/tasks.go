@@ -677,6 +677,7 @@ type Task struct {
 	Artifacts       []*TaskArtifact        `hcl:"artifact,block"`
 	Vault           *Vault                 `hcl:"vault,block"`
 	Templates       []*Template            `hcl:"template,block"`
+	SecureVariables []*TaskSecureVariable  `mapstructure:"vars" hcl:"vars,block"`
 	DispatchPayload *DispatchPayloadConfig `hcl:"dispatch_payload,block"`
 	VolumeMounts    []*VolumeMount         `hcl:"volume_mount,block"`
 	CSIPluginConfig *TaskCSIPluginConfig   `mapstructure:"csi_plugin" json:",omitempty" hcl:"csi_plugin,block"`
@@ -710,6 +711,9 @@ func (t *Task) Canonicalize(tg *TaskGroup, job *Job) {
 	for _, tmpl := range t.Templates {
 		tmpl.Canonicalize()
 	}
+	for _, sv := range t.SecureVariables {
+		sv.Canonicalize()
+	}
 	for _, s := range t.Services {
 		s.Canonicalize(t, tg, job)
 	}
@@ -874,6 +878,23 @@ func (v *Vault) Canonicalize() {
 	}
 }
 
+// TaskSecureVariable is a secure variable whose items are injected into the
+// task's environment.
+type TaskSecureVariable struct {
+	Path       *string  `hcl:"path,optional"`
+	Items      []string `hcl:"items,optional"`
+	ChangeMode *string  `mapstructure:"change_mode" hcl:"change_mode,optional"`
+}
+
+func (sv *TaskSecureVariable) Canonicalize() {
+	if sv.Path == nil {
+		sv.Path = stringToPtr("")
+	}
+	if sv.ChangeMode == nil {
+		sv.ChangeMode = stringToPtr("restart")
+	}
+}
+
 // NewTask creates and initializes a new Task.
 func NewTask(name, driver string) *Task {
 	return &Task{
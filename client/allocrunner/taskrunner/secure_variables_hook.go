@@ -0,0 +1,263 @@
+package taskrunner
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	log "github.com/hashicorp/go-hclog"
+
+	"github.com/hashicorp/nomad/client/allocrunner/interfaces"
+	ti "github.com/hashicorp/nomad/client/allocrunner/taskrunner/interfaces"
+	"github.com/hashicorp/nomad/client/config"
+	"github.com/hashicorp/nomad/client/taskenv"
+	"github.com/hashicorp/nomad/helper"
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+const (
+	// secureVariablesRetryInterval is the time to wait before watching a
+	// secure variable again after a failed read
+	secureVariablesRetryInterval = 5 * time.Second
+)
+
+// RPCer is the interface needed by hooks to make RPC calls.
+type RPCer interface {
+	RPC(method string, args interface{}, reply interface{}) error
+}
+
+type secureVariablesHookConfig struct {
+	vars         []*structs.TaskSecureVariable
+	rpc          RPCer
+	lifecycle    ti.TaskLifecycle
+	clientConfig *config.Config
+	envBuilder   *taskenv.Builder
+	namespace    string
+	logger       log.Logger
+}
+
+// secureVariablesHook injects the items of the task's secure variables into
+// its environment, and restarts the task when they change if required. The
+// items are set on the task's environment builder rather than returned as
+// the hook's environment, so they're never persisted in the client's state,
+// and they're read again when the task is restored.
+type secureVariablesHook struct {
+	vars         []*structs.TaskSecureVariable
+	rpc          RPCer
+	lifecycle    ti.TaskLifecycle
+	clientConfig *config.Config
+	envBuilder   *taskenv.Builder
+	namespace    string
+	logger       log.Logger
+
+	// ctx and cancel are used to stop the watchers
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	// watchOnce ensures the watchers are started only once
+	watchOnce sync.Once
+
+	// lock guards the fields below
+	lock sync.Mutex
+
+	// token is the workload identity used to read the secure variables
+	token string
+
+	// injected holds, for each path, the items injected into the running
+	// task and the ModifyIndex they were read at
+	injected map[string]*injectedSecureVariable
+}
+
+type injectedSecureVariable struct {
+	index uint64
+	items map[string]string
+}
+
+func newSecureVariablesHook(config *secureVariablesHookConfig) *secureVariablesHook {
+	ctx, cancel := context.WithCancel(context.Background())
+	h := &secureVariablesHook{
+		vars:         config.vars,
+		rpc:          config.rpc,
+		lifecycle:    config.lifecycle,
+		clientConfig: config.clientConfig,
+		envBuilder:   config.envBuilder,
+		namespace:    config.namespace,
+		ctx:          ctx,
+		cancel:       cancel,
+		injected:     make(map[string]*injectedSecureVariable, len(config.vars)),
+	}
+	h.logger = config.logger.Named(h.Name())
+	return h
+}
+
+func (*secureVariablesHook) Name() string {
+	return "secure_variables"
+}
+
+func (h *secureVariablesHook) Prestart(ctx context.Context, req *interfaces.TaskPrestartRequest, resp *interfaces.TaskPrestartResponse) error {
+	h.lock.Lock()
+	h.token = req.NomadToken
+	h.lock.Unlock()
+
+	// Read the secure variables on every start so that a restarted task
+	// receives their current items
+	env := make(map[string]string)
+	for _, sv := range h.vars {
+		index, items, err := h.read(sv, 0)
+		if err != nil {
+			return structs.NewRecoverableError(err, true)
+		}
+		if index == 0 {
+			return fmt.Errorf("secure variable %q not found", sv.Path)
+		}
+
+		selected, err := selectSecureVariableItems(sv, items)
+		if err != nil {
+			return err
+		}
+		for k, v := range selected {
+			env[k] = v
+		}
+
+		h.lock.Lock()
+		h.injected[sv.Path] = &injectedSecureVariable{index: index, items: selected}
+		h.lock.Unlock()
+	}
+	h.envBuilder.SetSecureVariablesEnv(env)
+
+	h.watchOnce.Do(func() {
+		for _, sv := range h.vars {
+			if sv.ChangeMode == structs.SecureVariableChangeModeRestart {
+				go h.watch(sv)
+			}
+		}
+	})
+	return nil
+}
+
+func (h *secureVariablesHook) Update(_ context.Context, req *interfaces.TaskUpdateRequest, _ *interfaces.TaskUpdateResponse) error {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.token = req.NomadToken
+	return nil
+}
+
+func (h *secureVariablesHook) Stop(ctx context.Context, req *interfaces.TaskStopRequest, resp *interfaces.TaskStopResponse) error {
+	h.cancel()
+	return nil
+}
+
+func (h *secureVariablesHook) Shutdown() {
+	h.cancel()
+}
+
+// read returns the ModifyIndex and items of a secure variable, blocking until
+// its index is greater than minIndex if set. The index is zero if the secure
+// variable does not exist.
+func (h *secureVariablesHook) read(sv *structs.TaskSecureVariable, minIndex uint64) (uint64, map[string]string, error) {
+	h.lock.Lock()
+	token := h.token
+	h.lock.Unlock()
+
+	req := &structs.SecureVariablesReadRequest{
+		Path: sv.Path,
+		QueryOptions: structs.QueryOptions{
+			Region:        h.clientConfig.Region,
+			Namespace:     h.namespace,
+			AuthToken:     token,
+			MinQueryIndex: minIndex,
+			AllowStale:    true,
+		},
+	}
+	var resp structs.SecureVariablesReadResponse
+	if err := h.rpc.RPC(structs.SecureVariablesReadRPCMethod, req, &resp); err != nil {
+		return 0, nil, fmt.Errorf("failed to read secure variable %q: %v", sv.Path, err)
+	}
+	if resp.Data == nil {
+		return 0, nil, nil
+	}
+	return resp.Data.ModifyIndex, resp.Data.Items, nil
+}
+
+// watch blocks on changes to a secure variable and restarts the task when
+// the items injected from it are modified.
+func (h *secureVariablesHook) watch(sv *structs.TaskSecureVariable) {
+	for {
+		h.lock.Lock()
+		injected := h.injected[sv.Path]
+		h.lock.Unlock()
+
+		index, items, err := h.read(sv, injected.index)
+		if h.ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			h.logger.Warn("failed to watch secure variable", "path", sv.Path, "error", err)
+			select {
+			case <-h.ctx.Done():
+				return
+			case <-time.After(secureVariablesRetryInterval):
+			}
+			continue
+		}
+
+		// The task keeps the items it was started with if the secure
+		// variable was deleted or no longer holds them
+		selected, err := selectSecureVariableItems(sv, items)
+		if index == 0 || err != nil {
+			h.logger.Warn("secure variable items are no longer available", "path", sv.Path)
+			select {
+			case <-h.ctx.Done():
+				return
+			case <-time.After(secureVariablesRetryInterval):
+			}
+			continue
+		}
+
+		h.lock.Lock()
+		current := h.injected[sv.Path]
+		if current != injected {
+			// The task was started again with newer items
+			h.lock.Unlock()
+			continue
+		}
+		current.index = index
+		changed := !helper.CompareMapStringString(current.items, selected)
+		h.lock.Unlock()
+
+		if !changed {
+			continue
+		}
+
+		h.logger.Debug("secure variable items changed, restarting task", "path", sv.Path)
+		h.lifecycle.Restart(h.ctx,
+			structs.NewTaskEvent(structs.TaskRestartSignal).
+				SetDisplayMessage(fmt.Sprintf("Secure variable %q changed", sv.Path)), false)
+	}
+}
+
+// selectSecureVariableItems returns the items of a secure variable listed by
+// the task, or all of its items if none are listed. The listed items are
+// validated with the job, but when all of them are injected each must have a
+// valid environment variable name.
+func selectSecureVariableItems(sv *structs.TaskSecureVariable, items map[string]string) (map[string]string, error) {
+	if len(sv.Items) == 0 {
+		for key := range items {
+			if !structs.ValidSecureVariableItemName(key) {
+				return nil, fmt.Errorf("secure variable %q item %q is not a valid environment variable name", sv.Path, key)
+			}
+		}
+		return helper.CopyMapStringString(items), nil
+	}
+
+	selected := make(map[string]string, len(sv.Items))
+	for _, key := range sv.Items {
+		v, ok := items[key]
+		if !ok {
+			return nil, fmt.Errorf("secure variable %q has no item %q", sv.Path, key)
+		}
+		selected[key] = v
+	}
+	return selected, nil
+}
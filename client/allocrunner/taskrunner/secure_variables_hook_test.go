@@ -0,0 +1,198 @@
+package taskrunner
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/nomad/ci"
+	"github.com/hashicorp/nomad/client/allocrunner/interfaces"
+	"github.com/hashicorp/nomad/client/config"
+	"github.com/hashicorp/nomad/client/taskenv"
+	"github.com/hashicorp/nomad/helper/testlog"
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/stretchr/testify/require"
+)
+
+// Statically assert the secure variables hook implements the expected interfaces
+var _ interfaces.TaskPrestartHook = (*secureVariablesHook)(nil)
+var _ interfaces.TaskUpdateHook = (*secureVariablesHook)(nil)
+var _ interfaces.TaskStopHook = (*secureVariablesHook)(nil)
+var _ interfaces.ShutdownHook = (*secureVariablesHook)(nil)
+
+// mockSecureVariablesRPCer serves secure variable reads, blocking until the
+// requested index is reached like the servers do.
+type mockSecureVariablesRPCer struct {
+	lock  sync.Mutex
+	vars  map[string]*structs.SecureVariableDecrypted
+	index uint64
+}
+
+func (m *mockSecureVariablesRPCer) put(path string, items structs.SecureVariableItems) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.index++
+	sv := &structs.SecureVariableDecrypted{Items: items}
+	sv.Path = path
+	sv.ModifyIndex = m.index
+	m.vars[path] = sv
+}
+
+func (m *mockSecureVariablesRPCer) RPC(method string, args interface{}, reply interface{}) error {
+	req := args.(*structs.SecureVariablesReadRequest)
+	resp := reply.(*structs.SecureVariablesReadResponse)
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		m.lock.Lock()
+		sv := m.vars[req.Path]
+		if sv != nil && (sv.ModifyIndex > req.MinQueryIndex || time.Now().After(deadline)) {
+			resp.Data = sv
+			resp.Index = m.index
+			m.lock.Unlock()
+			return nil
+		}
+		m.lock.Unlock()
+		if sv == nil {
+			return nil
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+type mockSecureVariablesLifecycle struct {
+	restartCh chan *structs.TaskEvent
+}
+
+func (m *mockSecureVariablesLifecycle) Restart(ctx context.Context, event *structs.TaskEvent, failure bool) error {
+	m.restartCh <- event
+	return nil
+}
+
+func (m *mockSecureVariablesLifecycle) Signal(event *structs.TaskEvent, signal string) error {
+	return nil
+}
+
+func (m *mockSecureVariablesLifecycle) Kill(ctx context.Context, event *structs.TaskEvent) error {
+	return nil
+}
+
+func (m *mockSecureVariablesLifecycle) IsRunning() bool { return true }
+
+// requireSecureVariablesEnv asserts the task environment holds the expected
+// secure variable items and none of the unexpected ones.
+func requireSecureVariablesEnv(t *testing.T, envBuilder *taskenv.Builder, expected map[string]string, unexpected ...string) {
+	t.Helper()
+	env := envBuilder.Build().EnvMap
+	for k, v := range expected {
+		require.Equal(t, v, env[k], "env var %q", k)
+	}
+	for _, k := range unexpected {
+		require.NotContains(t, env, k)
+	}
+}
+
+func TestTaskRunner_SecureVariablesHook_Prestart(t *testing.T) {
+	ci.Parallel(t)
+
+	rpc := &mockSecureVariablesRPCer{vars: map[string]*structs.SecureVariableDecrypted{}}
+	rpc.put("app/db", structs.SecureVariableItems{"user": "admin", "pass": "hunter2", "host": "db"})
+	rpc.put("app/api", structs.SecureVariableItems{"api_key": "abc"})
+
+	envBuilder := taskenv.NewEmptyBuilder()
+	newHook := func(vars ...*structs.TaskSecureVariable) *secureVariablesHook {
+		return newSecureVariablesHook(&secureVariablesHookConfig{
+			vars:         vars,
+			rpc:          rpc,
+			lifecycle:    &mockSecureVariablesLifecycle{},
+			clientConfig: config.DefaultConfig(),
+			envBuilder:   envBuilder,
+			namespace:    structs.DefaultNamespace,
+			logger:       testlog.HCLogger(t),
+		})
+	}
+
+	// Selected items and every item of a secure variable are injected
+	h := newHook(
+		&structs.TaskSecureVariable{Path: "app/db", Items: []string{"user", "pass"}, ChangeMode: structs.SecureVariableChangeModeNoop},
+		&structs.TaskSecureVariable{Path: "app/api", ChangeMode: structs.SecureVariableChangeModeNoop},
+	)
+	defer h.Shutdown()
+	resp := &interfaces.TaskPrestartResponse{}
+	require.NoError(t, h.Prestart(context.Background(), &interfaces.TaskPrestartRequest{}, resp))
+	requireSecureVariablesEnv(t, envBuilder,
+		map[string]string{"user": "admin", "pass": "hunter2", "api_key": "abc"}, "host")
+	require.False(t, resp.Done)
+
+	// The items aren't returned as the hook's env, which is persisted
+	require.Empty(t, resp.Env)
+
+	// Missing items fail the task
+	h = newHook(&structs.TaskSecureVariable{Path: "app/db", Items: []string{"port"}, ChangeMode: structs.SecureVariableChangeModeNoop})
+	defer h.Shutdown()
+	err := h.Prestart(context.Background(), &interfaces.TaskPrestartRequest{}, &interfaces.TaskPrestartResponse{})
+	require.EqualError(t, err, `secure variable "app/db" has no item "port"`)
+
+	// Items that can't be injected as environment variables fail the task
+	rpc.put("app/web", structs.SecureVariableItems{"tls-cert": "abc"})
+	h = newHook(&structs.TaskSecureVariable{Path: "app/web", ChangeMode: structs.SecureVariableChangeModeNoop})
+	defer h.Shutdown()
+	err = h.Prestart(context.Background(), &interfaces.TaskPrestartRequest{}, &interfaces.TaskPrestartResponse{})
+	require.EqualError(t, err, `secure variable "app/web" item "tls-cert" is not a valid environment variable name`)
+
+	// Missing secure variables fail the task
+	h = newHook(&structs.TaskSecureVariable{Path: "app/missing", ChangeMode: structs.SecureVariableChangeModeNoop})
+	defer h.Shutdown()
+	err = h.Prestart(context.Background(), &interfaces.TaskPrestartRequest{}, &interfaces.TaskPrestartResponse{})
+	require.EqualError(t, err, `secure variable "app/missing" not found`)
+}
+
+func TestTaskRunner_SecureVariablesHook_Restart(t *testing.T) {
+	ci.Parallel(t)
+
+	rpc := &mockSecureVariablesRPCer{vars: map[string]*structs.SecureVariableDecrypted{}}
+	rpc.put("app/db", structs.SecureVariableItems{"user": "admin", "pass": "hunter2"})
+
+	lifecycle := &mockSecureVariablesLifecycle{restartCh: make(chan *structs.TaskEvent, 1)}
+	envBuilder := taskenv.NewEmptyBuilder()
+	h := newSecureVariablesHook(&secureVariablesHookConfig{
+		vars: []*structs.TaskSecureVariable{
+			{Path: "app/db", Items: []string{"pass"}, ChangeMode: structs.SecureVariableChangeModeRestart},
+		},
+		rpc:          rpc,
+		lifecycle:    lifecycle,
+		clientConfig: config.DefaultConfig(),
+		envBuilder:   envBuilder,
+		namespace:    structs.DefaultNamespace,
+		logger:       testlog.HCLogger(t),
+	})
+	defer h.Shutdown()
+
+	resp := &interfaces.TaskPrestartResponse{}
+	require.NoError(t, h.Prestart(context.Background(), &interfaces.TaskPrestartRequest{}, resp))
+	requireSecureVariablesEnv(t, envBuilder, map[string]string{"pass": "hunter2"}, "user")
+
+	// Changing an item that is not injected does not restart the task
+	rpc.put("app/db", structs.SecureVariableItems{"user": "root", "pass": "hunter2"})
+	select {
+	case <-lifecycle.restartCh:
+		t.Fatal("unexpected restart")
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	// Changing an injected item restarts the task
+	rpc.put("app/db", structs.SecureVariableItems{"user": "root", "pass": "correct horse"})
+	select {
+	case event := <-lifecycle.restartCh:
+		require.Equal(t, structs.TaskRestartSignal, event.Type)
+		require.Contains(t, event.DisplayMessage, `"app/db"`)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for restart")
+	}
+
+	// The restarted task receives the new item
+	resp = &interfaces.TaskPrestartResponse{}
+	require.NoError(t, h.Prestart(context.Background(), &interfaces.TaskPrestartRequest{}, resp))
+	requireSecureVariablesEnv(t, envBuilder, map[string]string{"pass": "correct horse"}, "user")
+}
@@ -252,6 +252,9 @@ type TaskRunner struct {
 
 	// getter is an interface for retrieving artifacts.
 	getter cinterfaces.ArtifactGetter
+
+	// rpcClient is used by hooks to make RPC calls to the servers.
+	rpcClient RPCer
 }
 
 type Config struct {
@@ -320,6 +323,9 @@ type Config struct {
 
 	// Getter is an interface for retrieving artifacts.
 	Getter cinterfaces.ArtifactGetter
+
+	// RPCClient is used by hooks to make RPC calls to the servers.
+	RPCClient RPCer
 }
 
 func NewTaskRunner(config *Config) (*TaskRunner, error) {
@@ -379,6 +385,7 @@ func NewTaskRunner(config *Config) (*TaskRunner, error) {
 		shutdownDelayCancelFn:  config.ShutdownDelayCancelFn,
 		serviceRegWrapper:      config.ServiceRegWrapper,
 		getter:                 config.Getter,
+		rpcClient:              config.RPCClient,
 	}
 
 	// Create the logger based on the allocation ID
@@ -97,6 +97,19 @@ func (tr *TaskRunner) initHooks() {
 		}))
 	}
 
+	// If there are secure variables to inject, add the hook
+	if len(task.SecureVariables) != 0 {
+		tr.runnerHooks = append(tr.runnerHooks, newSecureVariablesHook(&secureVariablesHookConfig{
+			vars:         task.SecureVariables,
+			rpc:          tr.rpcClient,
+			lifecycle:    tr,
+			clientConfig: tr.clientConfig,
+			envBuilder:   tr.envBuilder,
+			namespace:    tr.alloc.Job.Namespace,
+			logger:       hookLogger,
+		}))
+	}
+
 	// Get the consul namespace for the TG of the allocation.
 	consulNamespace := tr.alloc.ConsulNamespace()
 
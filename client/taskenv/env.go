@@ -375,6 +375,11 @@ type Builder struct {
 	// templateEnv are env vars set from templates
 	templateEnv map[string]string
 
+	// secureVariablesEnv are env vars set from secure variables. They're
+	// kept apart from the hook env vars as those are persisted in the
+	// client's state.
+	secureVariablesEnv map[string]string
+
 	// hostEnv are environment variables filtered from the host
 	hostEnv map[string]string
 
@@ -602,6 +607,11 @@ func (b *Builder) buildEnv(allocDir, localDir, secretsDir string,
 		}
 	}
 
+	// Copy secure variable env vars as they override task env vars
+	for k, v := range b.secureVariablesEnv {
+		envMap[k] = v
+	}
+
 	// Copy template env vars as they override task env vars
 	for k, v := range b.templateEnv {
 		envMap[k] = v
@@ -1009,6 +1019,15 @@ func (b *Builder) SetTemplateEnv(m map[string]string) *Builder {
 	return b
 }
 
+// SetSecureVariablesEnv sets the environment variables injected from the
+// task's secure variables.
+func (b *Builder) SetSecureVariablesEnv(m map[string]string) *Builder {
+	b.mu.Lock()
+	b.secureVariablesEnv = m
+	b.mu.Unlock()
+	return b
+}
+
 func (b *Builder) SetVaultToken(token, namespace string, inject bool) *Builder {
 	b.mu.Lock()
 	b.vaultToken = token
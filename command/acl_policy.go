@@ -31,6 +31,10 @@ Usage: nomad acl policy <subcommand> [options] [args]
 
       $ nomad acl policy info <policy>
 
+  Test an ACL policy against a secure variable path:
+
+      $ nomad acl policy test -path=<path> -op=<operation> <policy-file>
+
   Please see the individual subcommand help for detailed usage information.
 `
 	return strings.TrimSpace(helpText)
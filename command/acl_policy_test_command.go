@@ -0,0 +1,210 @@
+package command
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/hashicorp/nomad/acl"
+	"github.com/hashicorp/nomad/api"
+	"github.com/posener/complete"
+)
+
+type ACLPolicyTestCommand struct {
+	Meta
+}
+
+func (c *ACLPolicyTestCommand) Help() string {
+	helpText := `
+Usage: nomad acl policy test [options] -path=<path> <policy file>
+
+  Test is used to check whether an ACL policy allows secure variable
+  operations on a path, without applying the policy or issuing a request. The
+  policy is sourced from <policy file> or from stdin if it is "-", and is
+  evaluated locally against the namespace given by -namespace, or "default".
+
+  Each requested operation is reported as allowed or denied, along with the
+  secure_variables path rule used to decide it. Rules with globs are matched
+  as they are by the servers, so the closest matching glob is used when more
+  than one matches.
+
+  The command exits with 0 when every requested operation is allowed, 2 when
+  at least one is denied, and 1 on any other error.
+
+General Options:
+
+  ` + generalOptionsUsage(usageOptsDefault) + `
+
+Test Options:
+
+  -path
+    The secure variable path to test. Required.
+
+  -op
+    Comma-separated list of operations to test. Valid operations are "read",
+    "write", "list", and "destroy". Defaults to "read".
+
+  -json
+    Output the results in JSON format.
+
+  -t
+    Format and display the results using a Go template.
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *ACLPolicyTestCommand) AutocompleteFlags() complete.Flags {
+	return mergeAutocompleteFlags(c.Meta.AutocompleteFlags(FlagSetClient),
+		complete.Flags{
+			"-path": complete.PredictAnything,
+			"-op":   complete.PredictSet("read", "write", "list", "destroy"),
+			"-json": complete.PredictNothing,
+			"-t":    complete.PredictAnything,
+		})
+}
+
+func (c *ACLPolicyTestCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictFiles("*")
+}
+
+func (c *ACLPolicyTestCommand) Synopsis() string {
+	return "Test an ACL policy against a secure variable path"
+}
+
+func (c *ACLPolicyTestCommand) Name() string { return "acl policy test" }
+
+func (c *ACLPolicyTestCommand) Run(args []string) int {
+	var json bool
+	var path, ops, tmpl string
+
+	flags := c.Meta.FlagSet(c.Name(), FlagSetClient)
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	flags.StringVar(&path, "path", "", "")
+	flags.StringVar(&ops, "op", acl.SecureVariablesCapabilityRead, "")
+	flags.BoolVar(&json, "json", false, "")
+	flags.StringVar(&tmpl, "t", "", "")
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	// Check that we got exactly one argument
+	args = flags.Args()
+	if l := len(args); l != 1 {
+		c.Ui.Error("This command takes one argument: <policy file>")
+		c.Ui.Error(commandErrorText(c))
+		return 1
+	}
+
+	path = strings.Trim(path, " /")
+	if path == "" {
+		c.Ui.Error("The -path flag is required")
+		c.Ui.Error(commandErrorText(c))
+		return 1
+	}
+
+	opList, err := parseVarAccessOps("op", ops)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		c.Ui.Error(commandErrorText(c))
+		return 1
+	}
+
+	ns := c.Meta.clientConfig().Namespace
+	switch ns {
+	case "":
+		ns = api.DefaultNamespace
+	case api.AllNamespacesNamespace:
+		c.Ui.Error("Policies can not be tested against the wildcard (\"*\") namespace")
+		return 1
+	}
+
+	// Read the file contents
+	file := args[0]
+	var rawPolicy []byte
+	if file == "-" {
+		rawPolicy, err = ioutil.ReadAll(os.Stdin)
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Failed to read stdin: %v", err))
+			return 1
+		}
+	} else {
+		rawPolicy, err = ioutil.ReadFile(file)
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Failed to read file: %v", err))
+			return 1
+		}
+	}
+
+	policy, err := acl.Parse(string(rawPolicy))
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return 1
+	}
+	aclObj, err := acl.NewACL(false, []*acl.Policy{policy})
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error compiling ACL policy: %s", err))
+		return 1
+	}
+
+	rule := ""
+	if ruleNS, rulePath, ok := aclObj.SecureVariablesPathRule(ns, path); ok {
+		rule = fmt.Sprintf("namespace %q path %q", ruleNS, rulePath)
+	}
+
+	results := make([]*aclPolicyTestResult, len(opList))
+	denied := false
+	for i, op := range opList {
+		r := &aclPolicyTestResult{
+			Namespace: ns,
+			Path:      path,
+			Operation: op,
+			Allowed:   aclObj.AllowSecureVariableOperation(ns, path, op),
+			Rule:      rule,
+		}
+		if !r.Allowed {
+			denied = true
+		}
+		results[i] = r
+	}
+
+	if json || len(tmpl) > 0 {
+		out, err := Format(json, tmpl, results)
+		if err != nil {
+			c.Ui.Error(err.Error())
+			return 1
+		}
+		c.Ui.Output(out)
+	} else {
+		rows := make([]string, len(results)+1)
+		rows[0] = "Namespace|Path|Operation|Result|Matching Rule"
+		for i, r := range results {
+			result := "allowed"
+			if !r.Allowed {
+				result = "denied"
+			}
+			rule := r.Rule
+			if rule == "" {
+				rule = "<none>"
+			}
+			rows[i+1] = fmt.Sprintf("%s|%s|%s|%s|%s", r.Namespace, r.Path, r.Operation, result, rule)
+		}
+		c.Ui.Output(formatList(rows))
+	}
+
+	if denied {
+		return varCheckAccessDeniedExitCode
+	}
+	return 0
+}
+
+// aclPolicyTestResult is the outcome of testing a single operation. Rule is
+// empty when no secure_variables path rule applies.
+type aclPolicyTestResult struct {
+	Namespace string
+	Path      string
+	Operation string
+	Allowed   bool
+	Rule      string
+}
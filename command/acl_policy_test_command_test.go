@@ -0,0 +1,93 @@
+package command
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/hashicorp/nomad/ci"
+	"github.com/mitchellh/cli"
+	"github.com/stretchr/testify/require"
+)
+
+func TestACLPolicyTestCommand_Implements(t *testing.T) {
+	ci.Parallel(t)
+	var _ cli.Command = &ACLPolicyTestCommand{}
+}
+
+func TestACLPolicyTestCommand_Run(t *testing.T) {
+	ci.Parallel(t)
+
+	f, err := ioutil.TempFile("", "nomad-test")
+	require.NoError(t, err)
+	defer os.Remove(f.Name())
+
+	rules := `
+namespace "default" {
+  secure_variables {
+    path "nomad/jobs/*" { capabilities = ["read"] }
+    path "nomad/jobs/secret" { capabilities = ["list"] }
+  }
+}`
+	require.NoError(t, ioutil.WriteFile(f.Name(), []byte(rules), 0600))
+
+	ui := cli.NewMockUi()
+	cmd := &ACLPolicyTestCommand{Meta: Meta{Ui: ui}}
+
+	// Missing path
+	code := cmd.Run([]string{f.Name()})
+	require.Equal(t, 1, code)
+	require.Contains(t, ui.ErrorWriter.String(), "The -path flag is required")
+	resetUiWriters(ui)
+
+	// Invalid operation
+	code = cmd.Run([]string{"-path=nomad/jobs/foo", "-op=bogus", f.Name()})
+	require.Equal(t, 1, code)
+	require.Contains(t, ui.ErrorWriter.String(), `Invalid operation "bogus"`)
+	resetUiWriters(ui)
+
+	// Allowed by a glob rule
+	code = cmd.Run([]string{"-path=nomad/jobs/foo", "-op=read,list", f.Name()})
+	require.Equal(t, 0, code, ui.ErrorWriter.String())
+	out := ui.OutputWriter.String()
+	require.Contains(t, out, "read       allowed")
+	require.Contains(t, out, "list       allowed")
+	require.Contains(t, out, `namespace "default" path "nomad/jobs/*"`)
+	resetUiWriters(ui)
+
+	// Denied by a concrete rule
+	code = cmd.Run([]string{"-path=nomad/jobs/secret", f.Name()})
+	require.Equal(t, varCheckAccessDeniedExitCode, code)
+	out = ui.OutputWriter.String()
+	require.Contains(t, out, "denied")
+	require.Contains(t, out, `namespace "default" path "nomad/jobs/secret"`)
+	resetUiWriters(ui)
+
+	// Denied with no matching rule
+	code = cmd.Run([]string{"-namespace=other", "-path=nomad/jobs/foo", "-op=write", f.Name()})
+	require.Equal(t, varCheckAccessDeniedExitCode, code)
+	require.Contains(t, ui.OutputWriter.String(), "<none>")
+}
+
+func TestACLPolicyTestCommand_NamespaceEnv(t *testing.T) {
+	f, err := ioutil.TempFile("", "nomad-test")
+	require.NoError(t, err)
+	defer os.Remove(f.Name())
+
+	rules := `
+namespace "prod" {
+  secure_variables {
+    path "nomad/jobs/*" { capabilities = ["read"] }
+  }
+}`
+	require.NoError(t, ioutil.WriteFile(f.Name(), []byte(rules), 0600))
+
+	// The namespace is taken from the environment
+	t.Setenv("NOMAD_NAMESPACE", "prod")
+
+	ui := cli.NewMockUi()
+	cmd := &ACLPolicyTestCommand{Meta: Meta{Ui: ui}}
+	code := cmd.Run([]string{"-path=nomad/jobs/foo", "-op=read", f.Name()})
+	require.Equal(t, 0, code, ui.ErrorWriter.String())
+	require.Contains(t, ui.OutputWriter.String(), `namespace "prod" path "nomad/jobs/*"`)
+}
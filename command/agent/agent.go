@@ -473,6 +473,13 @@ func convertServerConfig(agentConfig *Config) (*nomad.Config, error) {
 		conf.RaftBoltNoFreelistSync = bolt.NoFreelistSync
 	}
 
+	for _, sink := range agentConfig.Server.SecureVariablesSinks {
+		if err := sink.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid secure_variables_sink %q: %v", sink.Name, err)
+		}
+	}
+	conf.SecureVariablesSinks = config.CopySliceSecureVariablesSink(agentConfig.Server.SecureVariablesSinks)
+
 	return conf, nil
 }
 
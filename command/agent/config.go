@@ -552,6 +552,10 @@ type ServerConfig struct {
 
 	// RaftBoltConfig configures boltdb as used by raft.
 	RaftBoltConfig *RaftBoltConfig `hcl:"raft_boltdb"`
+
+	// SecureVariablesSinks configure external systems that secure variable
+	// change events are forwarded to.
+	SecureVariablesSinks []*config.SecureVariablesSink `hcl:"secure_variables_sink"`
 }
 
 // RaftBoltConfig is used in servers to configure parameters of the boltdb
@@ -1696,6 +1700,12 @@ func (s *ServerConfig) Merge(b *ServerConfig) *ServerConfig {
 		}
 	}
 
+	// Merge the secure variables sinks
+	if len(b.SecureVariablesSinks) != 0 {
+		result.SecureVariablesSinks = config.SecureVariablesSinkSliceMerge(
+			s.SecureVariablesSinks, b.SecureVariablesSinks)
+	}
+
 	// Add the schedulers
 	result.EnabledSchedulers = append(result.EnabledSchedulers, b.EnabledSchedulers...)
 
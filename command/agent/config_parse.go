@@ -133,6 +133,12 @@ func ParseConfigFile(path string) (*Config, error) {
 			fmt.Sprintf("audit.sink.%d", i), &sink.RotateDuration, &sink.RotateDurationHCL, nil})
 	}
 
+	// Add secure variables sinks for time.Duration parsing
+	for i, sink := range c.Server.SecureVariablesSinks {
+		tds = append(tds, durationConversionMap{
+			fmt.Sprintf("server.secure_variables_sink.%d", i), &sink.Timeout, &sink.TimeoutHCL, nil})
+	}
+
 	// convert strings to time.Durations
 	err = convertDurations(tds)
 	if err != nil {
@@ -230,6 +236,12 @@ func extraKeys(c *Config) error {
 		helper.RemoveEqualFold(&c.Audit.ExtraKeysHCL, "sink")
 	}
 
+	// Remove secure variables sink extra keys
+	for _, s := range c.Server.SecureVariablesSinks {
+		helper.RemoveEqualFold(&c.Server.ExtraKeysHCL, s.Name)
+		helper.RemoveEqualFold(&c.Server.ExtraKeysHCL, "secure_variables_sink")
+	}
+
 	for _, k := range []string{"enabled_schedulers", "start_join", "retry_join", "server_join"} {
 		helper.RemoveEqualFold(&c.ExtraKeysHCL, k)
 		helper.RemoveEqualFold(&c.ExtraKeysHCL, "server")
@@ -147,6 +147,18 @@ var basicConfig = &Config{
 			},
 		},
 		LicensePath: "/tmp/nomad.hclic",
+		SecureVariablesSinks: []*config.SecureVariablesSink{
+			{
+				Name:         "sync",
+				Type:         "webhook",
+				Address:      "https://sync.example.com/nomad",
+				PathPrefixes: []string{"app/", "shared/"},
+				HMACKey:      "secret",
+				Headers:      map[string]string{"Authorization": "Bearer token"},
+				Timeout:      5 * time.Second,
+				TimeoutHCL:   "5s",
+			},
+		},
 	},
 	ACL: &ACLConfig{
 		Enabled:          true,
@@ -1227,6 +1227,18 @@ func ApiTaskToStructsTask(job *structs.Job, group *structs.TaskGroup,
 		}
 	}
 
+	if len(apiTask.SecureVariables) > 0 {
+		structsTask.SecureVariables = []*structs.TaskSecureVariable{}
+		for _, sv := range apiTask.SecureVariables {
+			structsTask.SecureVariables = append(structsTask.SecureVariables,
+				&structs.TaskSecureVariable{
+					Path:       *sv.Path,
+					Items:      sv.Items,
+					ChangeMode: *sv.ChangeMode,
+				})
+		}
+	}
+
 	if apiTask.DispatchPayload != nil {
 		structsTask.DispatchPayload = &structs.DispatchPayloadConfig{
 			File: apiTask.DispatchPayload.File,
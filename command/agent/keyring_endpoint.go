@@ -33,6 +33,13 @@ func (s *HTTPServer) KeyringRequest(resp http.ResponseWriter, req *http.Request)
 		default:
 			return nil, CodedError(405, ErrInvalidMethod)
 		}
+	case strings.HasPrefix(path, "rotate/status"):
+		switch req.Method {
+		case http.MethodGet:
+			return s.keyringRotateStatusRequest(resp, req)
+		default:
+			return nil, CodedError(405, ErrInvalidMethod)
+		}
 	case strings.HasPrefix(path, "rotate"):
 		return s.keyringRotateRequest(resp, req)
 	default:
@@ -59,6 +66,25 @@ func (s *HTTPServer) keyringListRequest(resp http.ResponseWriter, req *http.Requ
 	return out.Keys, nil
 }
 
+func (s *HTTPServer) keyringRotateStatusRequest(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+
+	args := structs.KeyringRotateStatusRequest{}
+	if s.parse(resp, req, &args.Region, &args.QueryOptions) {
+		return nil, nil
+	}
+
+	var out structs.KeyringRotateStatusResponse
+	if err := s.agent.RPC("Keyring.RotateStatus", &args, &out); err != nil {
+		return nil, err
+	}
+
+	setMeta(resp, &out.QueryMeta)
+	if out.Rekeying == nil {
+		out.Rekeying = make([]*structs.KeyringRekeyStatus, 0)
+	}
+	return out, nil
+}
+
 func (s *HTTPServer) keyringRotateRequest(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
 
 	args := structs.KeyringRotateRootKeyRequest{}
@@ -34,6 +34,16 @@ func TestHTTP_Keyring_CRUD(t *testing.T) {
 		require.True(t, rotateResp.Key.Active())
 		newID1 := rotateResp.Key.KeyID
 
+		// Rotate status
+
+		req, err = http.NewRequest(http.MethodGet, "/v1/operator/keyring/rotate/status", nil)
+		require.NoError(t, err)
+		obj, err = s.Server.KeyringRequest(respW, req)
+		require.NoError(t, err)
+		statusResp := obj.(structs.KeyringRotateStatusResponse)
+		require.Equal(t, newID1, statusResp.ActiveKeyID)
+		require.Empty(t, statusResp.Rekeying)
+
 		// List
 
 		req, err = http.NewRequest(http.MethodGet, "/v1/operator/keyring/keys", nil)
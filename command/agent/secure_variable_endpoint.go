@@ -32,6 +32,45 @@ func (s *HTTPServer) SecureVariablesListRequest(resp http.ResponseWriter, req *h
 	return out.Data, nil
 }
 
+func (s *HTTPServer) SecureVariablesTreeRequest(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	if req.Method != http.MethodGet {
+		return nil, CodedError(http.StatusMethodNotAllowed, ErrInvalidMethod)
+	}
+
+	args := structs.SecureVariablesTreeRequest{}
+	if s.parse(resp, req, &args.Region, &args.QueryOptions) {
+		return nil, nil
+	}
+
+	var out structs.SecureVariablesTreeResponse
+	if err := s.agent.RPC(structs.SecureVariablesTreeRPCMethod, &args, &out); err != nil {
+		return nil, err
+	}
+
+	setMeta(resp, &out.QueryMeta)
+	return out.Data, nil
+}
+
+func (s *HTTPServer) SecureVariablesCopyRequest(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	if req.Method != http.MethodPut && req.Method != http.MethodPost {
+		return nil, CodedError(http.StatusMethodNotAllowed, ErrInvalidMethod)
+	}
+
+	var args structs.SecureVariablesCopyRequest
+	if err := decodeBody(req, &args); err != nil {
+		return nil, CodedError(http.StatusBadRequest, err.Error())
+	}
+	s.parseWriteRequest(req, &args.WriteRequest)
+
+	var out structs.SecureVariablesCopyResponse
+	if err := s.agent.RPC(structs.SecureVariablesCopyRPCMethod, &args, &out); err != nil {
+		return nil, err
+	}
+
+	setIndex(resp, out.Index)
+	return out.Copies, nil
+}
+
 func (s *HTTPServer) SecureVariableSpecificRequest(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
 	path := strings.TrimPrefix(req.URL.Path, "/v1/var/")
 	if len(path) == 0 {
@@ -39,6 +78,13 @@ func (s *HTTPServer) SecureVariableSpecificRequest(resp http.ResponseWriter, req
 	}
 	switch req.Method {
 	case http.MethodGet:
+		history, err := parseBool(req, "history")
+		if err != nil {
+			return nil, CodedError(http.StatusBadRequest, err.Error())
+		}
+		if history != nil && *history {
+			return s.secureVariableHistory(resp, req, path)
+		}
 		return s.secureVariableQuery(resp, req, path)
 	case http.MethodPut, http.MethodPost:
 		return s.secureVariableUpsert(resp, req, path)
@@ -57,6 +103,13 @@ func (s *HTTPServer) secureVariableQuery(resp http.ResponseWriter, req *http.Req
 	if s.parse(resp, req, &args.Region, &args.QueryOptions) {
 		return nil, nil
 	}
+	if vq := req.URL.Query().Get("version"); vq != "" {
+		version, err := strconv.ParseUint(vq, 10, 64)
+		if err != nil {
+			return nil, CodedError(http.StatusBadRequest, fmt.Sprintf("can not parse version: %v", err))
+		}
+		args.Version = version
+	}
 	var out structs.SecureVariablesReadResponse
 	if err := s.agent.RPC(structs.SecureVariablesReadRPCMethod, &args, &out); err != nil {
 		return nil, err
@@ -70,6 +123,27 @@ func (s *HTTPServer) secureVariableQuery(resp http.ResponseWriter, req *http.Req
 	return out.Data, nil
 }
 
+func (s *HTTPServer) secureVariableHistory(resp http.ResponseWriter, req *http.Request,
+	path string) (interface{}, error) {
+	args := structs.SecureVariablesHistoryRequest{
+		Path: path,
+	}
+	if s.parse(resp, req, &args.Region, &args.QueryOptions) {
+		return nil, nil
+	}
+	var out structs.SecureVariablesHistoryResponse
+	if err := s.agent.RPC(structs.SecureVariablesHistoryRPCMethod, &args, &out); err != nil {
+		return nil, err
+	}
+
+	setMeta(resp, &out.QueryMeta)
+
+	if len(out.Data) == 0 {
+		return nil, CodedError(http.StatusNotFound, "secure variable not found")
+	}
+	return out.Data, nil
+}
+
 func (s *HTTPServer) secureVariableUpsert(resp http.ResponseWriter, req *http.Request,
 	path string) (interface{}, error) {
 	// Parse the SecureVariable
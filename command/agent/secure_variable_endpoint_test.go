@@ -102,6 +102,41 @@ func TestHTTP_SecureVariables(t *testing.T) {
 		})
 		rpcResetSV(s)
 
+		t.Run("error_badverb_tree", func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodPut, "/v1/vars/tree", nil)
+			require.NoError(t, err)
+			respW := httptest.NewRecorder()
+			_, err = s.Server.SecureVariablesTreeRequest(respW, req)
+			require.EqualError(t, err, ErrInvalidMethod)
+		})
+		t.Run("tree", func(t *testing.T) {
+			sv1 := mock.SecureVariable()
+			sv1.Path = "tree/a"
+			sv2 := mock.SecureVariable()
+			sv2.Path = "tree/a/b"
+			for _, sv := range []*structs.SecureVariableDecrypted{sv1, sv2} {
+				require.NoError(t, rpcWriteSV(s, sv))
+			}
+
+			req, err := http.NewRequest("GET", "/v1/vars/tree?prefix=tree", nil)
+			require.NoError(t, err)
+			respW := httptest.NewRecorder()
+			obj, err := s.Server.SecureVariablesTreeRequest(respW, req)
+			require.NoError(t, err)
+			require.NotZero(t, respW.HeaderMap.Get("X-Nomad-Index"))
+
+			root := obj.(*structs.SecureVariableTreeNode)
+			require.Len(t, root.Children, 1)
+			require.Nil(t, root.Children[0].Variable)
+			a := root.Children[0].Children[0]
+			require.Equal(t, sv1.Path, a.Path)
+			require.Equal(t, len(sv1.Items), a.Variable.ItemCount)
+			require.Equal(t, sv1.CreateTime, a.Variable.CreateTime)
+			require.Len(t, a.Children, 1)
+			require.Equal(t, sv2.Path, a.Children[0].Path)
+		})
+		rpcResetSV(s)
+
 		t.Run("error_badverb_query", func(t *testing.T) {
 			req, err := http.NewRequest("LOLWUT", "/v1/var/does/not/exist", nil)
 			require.NoError(t, err)
@@ -164,6 +199,54 @@ func TestHTTP_SecureVariables(t *testing.T) {
 			// Check the output
 			require.Equal(t, sv1.Path, obj.(*structs.SecureVariableDecrypted).Path)
 		})
+		t.Run("query_history", func(t *testing.T) {
+			// Use RPC to make a test variable with a previous version
+			sv1 := mock.SecureVariable()
+			require.NoError(t, rpcWriteSV(s, sv1))
+			prevIndex := sv1.ModifyIndex
+			sv2 := sv1.Copy()
+			sv2.Items["new"] = "new"
+			require.NoError(t, rpcWriteSV(s, &sv2))
+
+			// Query the versions of the variable
+			req, err := http.NewRequest("GET", "/v1/var/"+sv1.Path+"?history=true", nil)
+			require.NoError(t, err)
+			respW := httptest.NewRecorder()
+			obj, err := s.Server.SecureVariableSpecificRequest(respW, req)
+			require.NoError(t, err)
+			require.NotZero(t, respW.HeaderMap.Get("X-Nomad-Index"))
+
+			versions := obj.([]*structs.SecureVariableMetadata)
+			require.Len(t, versions, 2)
+			require.Equal(t, sv2.ModifyIndex, versions[0].ModifyIndex)
+			require.Equal(t, prevIndex, versions[1].ModifyIndex)
+
+			// Query the previous version
+			req, err = http.NewRequest("GET", fmt.Sprintf("/v1/var/%s?version=%d", sv1.Path, prevIndex), nil)
+			require.NoError(t, err)
+			respW = httptest.NewRecorder()
+			obj, err = s.Server.SecureVariableSpecificRequest(respW, req)
+			require.NoError(t, err)
+			out := obj.(*structs.SecureVariableDecrypted)
+			require.Equal(t, prevIndex, out.ModifyIndex)
+			require.NotContains(t, out.Items, "new")
+
+			// Query a version that was never kept
+			req, err = http.NewRequest("GET", "/v1/var/"+sv1.Path+"?version=1", nil)
+			require.NoError(t, err)
+			respW = httptest.NewRecorder()
+			obj, err = s.Server.SecureVariableSpecificRequest(respW, req)
+			require.EqualError(t, err, "secure variable not found")
+			require.Nil(t, obj)
+		})
+		t.Run("query_history_unset_variable", func(t *testing.T) {
+			req, err := http.NewRequest("GET", "/v1/var/not/real?history=true", nil)
+			require.NoError(t, err)
+			respW := httptest.NewRecorder()
+			obj, err := s.Server.SecureVariableSpecificRequest(respW, req)
+			require.EqualError(t, err, "secure variable not found")
+			require.Nil(t, obj)
+		})
 		rpcResetSV(s)
 
 		sv1 := mock.SecureVariable()
@@ -156,6 +156,18 @@ server {
   }
 
   license_path = "/tmp/nomad.hclic"
+
+  secure_variables_sink "sync" {
+    type          = "webhook"
+    address       = "https://sync.example.com/nomad"
+    path_prefixes = ["app/", "shared/"]
+    hmac_key      = "secret"
+    timeout       = "5s"
+
+    headers {
+      Authorization = "Bearer token"
+    }
+  }
 }
 
 acl {
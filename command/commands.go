@@ -107,6 +107,11 @@ func Commands(metaPtr *Meta, agentUi cli.Ui) map[string]cli.CommandFactory {
 				Meta: meta,
 			}, nil
 		},
+		"acl policy test": func() (cli.Command, error) {
+			return &ACLPolicyTestCommand{
+				Meta: meta,
+			}, nil
+		},
 		"acl token": func() (cli.Command, error) {
 			return &ACLTokenCommand{
 				Meta: meta,
@@ -664,6 +669,11 @@ func Commands(metaPtr *Meta, agentUi cli.Ui) map[string]cli.CommandFactory {
 				Meta: meta,
 			}, nil
 		},
+		"operator var-bench": func() (cli.Command, error) {
+			return &OperatorVarBenchCommand{
+				Meta: meta,
+			}, nil
+		},
 
 		"plan": func() (cli.Command, error) {
 			return &JobPlanCommand{
@@ -906,11 +916,76 @@ func Commands(metaPtr *Meta, agentUi cli.Ui) map[string]cli.CommandFactory {
 				Meta: meta,
 			}, nil
 		},
+		"var check-access": func() (cli.Command, error) {
+			return &VarCheckAccessCommand{
+				Meta: meta,
+			}, nil
+		},
+		"var copy": func() (cli.Command, error) {
+			return &VarCopyCommand{
+				Meta: meta,
+			}, nil
+		},
+		"var diff": func() (cli.Command, error) {
+			return &VarDiffCommand{
+				Meta: meta,
+			}, nil
+		},
+		"var edit": func() (cli.Command, error) {
+			return &VarEditCommand{
+				Meta: meta,
+			}, nil
+		},
+		"var exec": func() (cli.Command, error) {
+			return &VarExecCommand{
+				Meta: meta,
+			}, nil
+		},
+		"var export": func() (cli.Command, error) {
+			return &VarExportCommand{
+				Meta: meta,
+			}, nil
+		},
+		"var history": func() (cli.Command, error) {
+			return &VarHistoryCommand{
+				Meta: meta,
+			}, nil
+		},
+		"var import": func() (cli.Command, error) {
+			return &VarImportCommand{
+				Meta: meta,
+			}, nil
+		},
 		"var list": func() (cli.Command, error) {
 			return &VarListCommand{
 				Meta: meta,
 			}, nil
 		},
+		"var lock": func() (cli.Command, error) {
+			return &VarLockCommand{
+				Meta: meta,
+			}, nil
+		},
+		"var migrate-keys": func() (cli.Command, error) {
+			return &VarMigrateKeysCommand{
+				Meta: meta,
+			}, nil
+		},
+		"var move": func() (cli.Command, error) {
+			return &VarMoveCommand{
+				Meta: meta,
+			}, nil
+		},
+		"var replicate": func() (cli.Command, error) {
+			return &VarReplicateCommand{
+				Meta: meta,
+			}, nil
+		},
+		"var rollback": func() (cli.Command, error) {
+			return &VarRollbackCommand{
+				Meta: meta,
+			}, nil
+		},
 		"version": func() (cli.Command, error) {
 			return &VersionCommand{
 				Version: version.GetVersion(),
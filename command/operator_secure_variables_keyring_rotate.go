@@ -31,7 +31,14 @@ Keyring Options:
   -full
     Decrypt all existing variables and re-encrypt with the new key. This command
     will immediately return and the re-encryption process will run
-    asynchronously on the leader.
+    asynchronously on the leader, writing the variables in throttled batches.
+    Use -status to follow its progress.
+
+  -status
+    Show the progress of re-encrypting variables after a full rotation,
+    instead of rotating the key. Each key still being rekeyed is listed with
+    the number of variables and kept previous versions of variables left to
+    re-encrypt.
 
   -verbose
     Show full information.
@@ -48,6 +55,7 @@ func (c *OperatorSecureVariablesKeyringRotateCommand) AutocompleteFlags() comple
 	return mergeAutocompleteFlags(c.Meta.AutocompleteFlags(FlagSetClient),
 		complete.Flags{
 			"-full":    complete.PredictNothing,
+			"-status":  complete.PredictNothing,
 			"-verbose": complete.PredictNothing,
 		})
 }
@@ -61,11 +69,12 @@ func (c *OperatorSecureVariablesKeyringRotateCommand) Name() string {
 }
 
 func (c *OperatorSecureVariablesKeyringRotateCommand) Run(args []string) int {
-	var rotateFull, verbose bool
+	var rotateFull, status, verbose bool
 
 	flags := c.Meta.FlagSet("secure-variables keyring rotate", FlagSetClient)
 	flags.Usage = func() { c.Ui.Output(c.Help()) }
 	flags.BoolVar(&rotateFull, "full", false, "full key rotation")
+	flags.BoolVar(&status, "status", false, "")
 	flags.BoolVar(&verbose, "verbose", false, "")
 
 	if err := flags.Parse(args); err != nil {
@@ -85,6 +94,15 @@ func (c *OperatorSecureVariablesKeyringRotateCommand) Run(args []string) int {
 		return 1
 	}
 
+	if status {
+		if rotateFull {
+			c.Ui.Error("The -status and -full flags can not be used together")
+			c.Ui.Error(commandErrorText(c))
+			return 1
+		}
+		return c.outputStatus(client, verbose)
+	}
+
 	resp, _, err := client.Keyring().Rotate(
 		&api.KeyringRotateOptions{Full: rotateFull}, nil)
 	if err != nil {
@@ -94,3 +112,35 @@ func (c *OperatorSecureVariablesKeyringRotateCommand) Run(args []string) int {
 	c.Ui.Output(renderSecureVariablesKeysResponse([]*api.RootKeyMeta{resp}, verbose))
 	return 0
 }
+
+// outputStatus prints the progress of full key rotations.
+func (c *OperatorSecureVariablesKeyringRotateCommand) outputStatus(client *api.Client, verbose bool) int {
+	status, _, err := client.Keyring().RotateStatus(nil)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("error: %s", err))
+		return 1
+	}
+
+	length := fullId
+	if !verbose {
+		length = 8
+	}
+
+	c.Ui.Output(formatKV([]string{
+		fmt.Sprintf("Active Key ID|%s", limit(status.ActiveKeyID, length)),
+	}))
+	if len(status.Rekeying) == 0 {
+		c.Ui.Output("\nNo keys are being rekeyed")
+		return 0
+	}
+
+	rows := make([]string, len(status.Rekeying)+1)
+	rows[0] = "Key|Create Time|Remaining"
+	for i, key := range status.Rekeying {
+		rows[i+1] = fmt.Sprintf("%s|%s|%d",
+			limit(key.KeyID, length), formatTime(key.CreateTime), key.Remaining)
+	}
+	c.Ui.Output(c.Colorize().Color("\n[bold]Rekeying[reset]"))
+	c.Ui.Output(formatList(rows))
+	return 0
+}
@@ -1,8 +1,11 @@
 package command
 
 import (
+	"io"
 	"strings"
 
+	"github.com/hashicorp/nomad/helper/snapshot"
+	"github.com/hashicorp/nomad/nomad"
 	"github.com/mitchellh/cli"
 )
 
@@ -48,3 +51,12 @@ func (f *OperatorSnapshotCommand) Name() string { return "operator snapshot" }
 func (f *OperatorSnapshotCommand) Run(args []string) int {
 	return cli.RunResultHelp
 }
+
+// rewriteSnapshot copies the snapshot archive from in to out, excluding or
+// moving the secure variables it holds as set by opts.
+func rewriteSnapshot(in io.Reader, out io.Writer, opts *nomad.SnapshotSecureVariablesOptions) error {
+	_, err := snapshot.Rewrite(in, out, func(r io.Reader, w io.Writer) error {
+		return nomad.RewriteSnapshotSecureVariables(r, w, opts)
+	})
+	return err
+}
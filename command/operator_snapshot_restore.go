@@ -2,10 +2,13 @@ package command
 
 import (
 	"fmt"
+	"io/ioutil"
 	"os"
 	"strings"
 
 	"github.com/hashicorp/nomad/api"
+	flaghelper "github.com/hashicorp/nomad/helper/flags"
+	"github.com/hashicorp/nomad/nomad"
 	"github.com/posener/complete"
 )
 
@@ -32,14 +35,36 @@ Usage: nomad operator snapshot restore [options] <file>
 
     $ nomad operator snapshot restore backup.snap
 
+  To restore the snapshot with the secure variables of the "prod" namespace
+  moved to the "staging" namespace:
+
+    $ nomad operator snapshot restore -secure-variables-namespace=prod:staging backup.snap
+
 General Options:
 
-  ` + generalOptionsUsage(usageOptsDefault|usageOptsNoNamespace)
+  ` + generalOptionsUsage(usageOptsDefault|usageOptsNoNamespace) + `
+
+Snapshot Restore Options:
+
+  -exclude-secure-variables
+    Leave the secure variables, their previous versions, and their quotas out
+    of the restored state. The snapshot file itself is not modified.
+
+  -secure-variables-namespace=<from>:<to>
+    Restore the secure variables of the "from" namespace into the "to"
+    namespace, which must exist in the snapshot. May be specified multiple
+    times. The restore fails if this would place more than one secure
+    variable at the same path. The snapshot file itself is not modified.
+`
 	return strings.TrimSpace(helpText)
 }
 
 func (c *OperatorSnapshotRestoreCommand) AutocompleteFlags() complete.Flags {
-	return c.Meta.AutocompleteFlags(FlagSetClient)
+	return mergeAutocompleteFlags(c.Meta.AutocompleteFlags(FlagSetClient),
+		complete.Flags{
+			"-exclude-secure-variables":   complete.PredictNothing,
+			"-secure-variables-namespace": complete.PredictAnything,
+		})
 }
 
 func (c *OperatorSnapshotRestoreCommand) AutocompleteArgs() complete.Predictor {
@@ -53,8 +78,13 @@ func (c *OperatorSnapshotRestoreCommand) Synopsis() string {
 func (c *OperatorSnapshotRestoreCommand) Name() string { return "operator snapshot restore" }
 
 func (c *OperatorSnapshotRestoreCommand) Run(args []string) int {
+	var excludeVars bool
+	var nsMappings flaghelper.StringFlag
+
 	flags := c.Meta.FlagSet(c.Name(), FlagSetClient)
 	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	flags.BoolVar(&excludeVars, "exclude-secure-variables", false, "")
+	flags.Var(&nsMappings, "secure-variables-namespace", "")
 
 	if err := flags.Parse(args); err != nil {
 		c.Ui.Error(fmt.Sprintf("Failed to parse args: %v", err))
@@ -69,6 +99,18 @@ func (c *OperatorSnapshotRestoreCommand) Run(args []string) int {
 		return 1
 	}
 
+	nsMap, err := parseSnapshotNamespaceMappings(nsMappings)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		c.Ui.Error(commandErrorText(c))
+		return 1
+	}
+	if excludeVars && len(nsMap) > 0 {
+		c.Ui.Error("The -exclude-secure-variables flag can not be combined with -secure-variables-namespace")
+		c.Ui.Error(commandErrorText(c))
+		return 1
+	}
+
 	snap, err := os.Open(args[0])
 	if err != nil {
 		c.Ui.Error(fmt.Sprintf("Error opening snapshot file: %q", err))
@@ -76,6 +118,33 @@ func (c *OperatorSnapshotRestoreCommand) Run(args []string) int {
 	}
 	defer snap.Close()
 
+	// Rewrite the snapshot into a scratch file so the original is left as-is
+	if excludeVars || len(nsMap) > 0 {
+		rewritten, err := ioutil.TempFile("", "snapshot")
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Failed to create temp snapshot file: %v", err))
+			return 1
+		}
+		defer func() {
+			rewritten.Close()
+			os.Remove(rewritten.Name())
+		}()
+
+		err = rewriteSnapshot(snap, rewritten, &nomad.SnapshotSecureVariablesOptions{
+			Exclude:      excludeVars,
+			NamespaceMap: nsMap,
+		})
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Failed to rewrite snapshot file: %v", err))
+			return 1
+		}
+		if _, err := rewritten.Seek(0, 0); err != nil {
+			c.Ui.Error(fmt.Sprintf("Failed to rewind snapshot file: %v", err))
+			return 1
+		}
+		snap = rewritten
+	}
+
 	// Set up a client.
 	client, err := c.Meta.Client()
 	if err != nil {
@@ -93,3 +162,20 @@ func (c *OperatorSnapshotRestoreCommand) Run(args []string) int {
 	c.Ui.Output("Snapshot Restored")
 	return 0
 }
+
+// parseSnapshotNamespaceMappings parses the "<from>:<to>" namespace mappings
+// given with -secure-variables-namespace.
+func parseSnapshotNamespaceMappings(in []string) (map[string]string, error) {
+	out := make(map[string]string, len(in))
+	for _, mapping := range in {
+		from, to, ok := strings.Cut(mapping, ":")
+		if !ok || from == "" || to == "" {
+			return nil, fmt.Errorf("Invalid namespace mapping %q; expected <from>:<to>", mapping)
+		}
+		if _, ok := out[from]; ok {
+			return nil, fmt.Errorf("Namespace %q is mapped more than once", from)
+		}
+		out[from] = to
+	}
+	return out, nil
+}
@@ -89,4 +89,57 @@ func TestOperatorSnapshotRestore_Fails(t *testing.T) {
 	code = cmd.Run([]string{"/unicorns/leprechauns"})
 	require.Equal(t, 1, code)
 	require.Contains(t, ui.ErrorWriter.String(), "no such file")
+	ui.ErrorWriter.Reset()
+
+	// Fails on bad namespace mappings
+	code = cmd.Run([]string{"-secure-variables-namespace=prod", "backup.snap"})
+	require.Equal(t, 1, code)
+	require.Contains(t, ui.ErrorWriter.String(), `Invalid namespace mapping "prod"`)
+	ui.ErrorWriter.Reset()
+
+	code = cmd.Run([]string{"-exclude-secure-variables", "-secure-variables-namespace=prod:dev", "backup.snap"})
+	require.Equal(t, 1, code)
+	require.Contains(t, ui.ErrorWriter.String(), "can not be combined")
+}
+
+func TestOperatorSnapshotRestore_SecureVariablesNamespace(t *testing.T) {
+	ci.Parallel(t)
+
+	tmpDir := t.TempDir()
+
+	snapshotPath := generateSnapshotFile(t, func(srv *agent.TestAgent, client *api.Client, url string) {
+		_, err := client.Namespaces().Register(&api.Namespace{Name: "staging"}, nil)
+		require.NoError(t, err)
+		_, err = client.SecureVariables().Create(&api.SecureVariable{
+			Path:  "app/db",
+			Items: map[string]string{"password": "hunter2"},
+		}, nil)
+		require.NoError(t, err)
+	})
+
+	srv, _, url := testServer(t, false, func(c *agent.Config) {
+		c.DevMode = false
+		c.DataDir = filepath.Join(tmpDir, "server1")
+
+		c.AdvertiseAddrs.HTTP = "127.0.0.1"
+		c.AdvertiseAddrs.RPC = "127.0.0.1"
+		c.AdvertiseAddrs.Serf = "127.0.0.1"
+	})
+
+	defer srv.Shutdown()
+
+	ui := cli.NewMockUi()
+	cmd := &OperatorSnapshotRestoreCommand{Meta: Meta{Ui: ui}}
+
+	code := cmd.Run([]string{"--address=" + url, "-secure-variables-namespace=default:staging", snapshotPath})
+	require.Empty(t, ui.ErrorWriter.String())
+	require.Zero(t, code)
+
+	state := srv.Agent.Server().State()
+	sv, err := state.GetSecureVariable(nil, "staging", "app/db")
+	require.NoError(t, err)
+	require.NotNil(t, sv)
+	sv, err = state.GetSecureVariable(nil, structs.DefaultNamespace, "app/db")
+	require.NoError(t, err)
+	require.Nil(t, sv)
 }
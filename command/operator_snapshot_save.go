@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/hashicorp/nomad/api"
+	"github.com/hashicorp/nomad/nomad"
 	"github.com/posener/complete"
 )
 
@@ -49,6 +50,12 @@ Snapshot Save Options:
     The -stale argument defaults to "false" which means the leader provides the
     result. If the cluster is in an outage state without a leader, you may need
     to set -stale to "true" to get the configuration from a non-leader server.
+
+  -exclude-secure-variables
+    Leave the secure variables, their previous versions, and their quotas out
+    of the saved snapshot. This is useful for sharing a snapshot without
+    sharing the encrypted secrets it holds. The secure variables are removed
+    locally once the snapshot has been downloaded.
 `
 	return strings.TrimSpace(helpText)
 }
@@ -56,7 +63,8 @@ Snapshot Save Options:
 func (c *OperatorSnapshotSaveCommand) AutocompleteFlags() complete.Flags {
 	return mergeAutocompleteFlags(c.Meta.AutocompleteFlags(FlagSetClient),
 		complete.Flags{
-			"-stale": complete.PredictAnything,
+			"-stale":                    complete.PredictAnything,
+			"-exclude-secure-variables": complete.PredictNothing,
 		})
 }
 
@@ -71,12 +79,13 @@ func (c *OperatorSnapshotSaveCommand) Synopsis() string {
 func (c *OperatorSnapshotSaveCommand) Name() string { return "operator snapshot save" }
 
 func (c *OperatorSnapshotSaveCommand) Run(args []string) int {
-	var stale bool
+	var stale, excludeVars bool
 
 	flags := c.Meta.FlagSet(c.Name(), FlagSetClient)
 	flags.Usage = func() { c.Ui.Output(c.Help()) }
 
 	flags.BoolVar(&stale, "stale", false, "")
+	flags.BoolVar(&excludeVars, "exclude-secure-variables", false, "")
 	if err := flags.Parse(args); err != nil {
 		c.Ui.Error(fmt.Sprintf("Failed to parse args: %v", err))
 		return 1
@@ -132,7 +141,11 @@ func (c *OperatorSnapshotSaveCommand) Run(args []string) int {
 
 	defer snapIn.Close()
 
-	_, err = io.Copy(tmpFile, snapIn)
+	if excludeVars {
+		err = rewriteSnapshot(snapIn, tmpFile, &nomad.SnapshotSecureVariablesOptions{Exclude: true})
+	} else {
+		_, err = io.Copy(tmpFile, snapIn)
+	}
 	if err != nil {
 		c.Ui.Error(fmt.Sprintf("Filed to download snapshot file: %v", err))
 		return 1
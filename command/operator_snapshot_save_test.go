@@ -5,9 +5,12 @@ import (
 	"path/filepath"
 	"testing"
 
+	"github.com/hashicorp/nomad/api"
 	"github.com/hashicorp/nomad/ci"
 	"github.com/hashicorp/nomad/command/agent"
+	"github.com/hashicorp/nomad/helper/raftutil"
 	"github.com/hashicorp/nomad/helper/snapshot"
+	"github.com/hashicorp/nomad/nomad/structs"
 	"github.com/mitchellh/cli"
 	"github.com/stretchr/testify/require"
 )
@@ -47,6 +50,46 @@ func TestOperatorSnapshotSave_Works(t *testing.T) {
 	require.NotZero(t, meta.Index)
 }
 
+func TestOperatorSnapshotSave_ExcludeSecureVariables(t *testing.T) {
+	ci.Parallel(t)
+
+	snapshotPath := generateSnapshotFile(t, func(srv *agent.TestAgent, client *api.Client, url string) {
+		_, err := client.SecureVariables().Create(&api.SecureVariable{
+			Path:  "app/db",
+			Items: map[string]string{"password": "hunter2"},
+		}, nil)
+		require.NoError(t, err)
+
+		ui := cli.NewMockUi()
+		cmd := &OperatorSnapshotSaveCommand{Meta: Meta{Ui: ui}}
+		dest := filepath.Join(t.TempDir(), "excluded.snap")
+		code := cmd.Run([]string{"--address=" + url, "-exclude-secure-variables", dest})
+		require.Zero(t, code, ui.ErrorWriter.String())
+
+		f, err := os.Open(dest)
+		require.NoError(t, err)
+		defer f.Close()
+
+		state, meta, err := raftutil.RestoreFromArchive(f, nil)
+		require.NoError(t, err)
+		require.NotZero(t, meta.Index)
+		sv, err := state.GetSecureVariable(nil, structs.DefaultNamespace, "app/db")
+		require.NoError(t, err)
+		require.Nil(t, sv)
+	})
+
+	// The secure variable is kept without the flag
+	f, err := os.Open(snapshotPath)
+	require.NoError(t, err)
+	defer f.Close()
+
+	state, _, err := raftutil.RestoreFromArchive(f, nil)
+	require.NoError(t, err)
+	sv, err := state.GetSecureVariable(nil, structs.DefaultNamespace, "app/db")
+	require.NoError(t, err)
+	require.NotNil(t, sv)
+}
+
 func TestOperatorSnapshotSave_Fails(t *testing.T) {
 	ci.Parallel(t)
 
@@ -0,0 +1,463 @@
+package command
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/nomad/api"
+	"github.com/posener/complete"
+)
+
+const (
+	// varBenchOpRead, varBenchOpWrite and varBenchOpList are the operations
+	// a benchmark workload is made of
+	varBenchOpRead  = "read"
+	varBenchOpWrite = "write"
+	varBenchOpList  = "list"
+
+	// varBenchWorkloadMixed runs an even mix of all operations
+	varBenchWorkloadMixed = "mixed"
+)
+
+type OperatorVarBenchCommand struct {
+	Meta
+}
+
+func (c *OperatorVarBenchCommand) Help() string {
+	helpText := `
+Usage: nomad operator var-bench [options]
+
+  Var-bench generates a read, write or list workload against the secure
+  variables API and reports the latency of each operation and the rate at
+  which the cluster applied Raft log entries while it ran. It can be used to
+  size a cluster before storing a large number of secure variables in it.
+
+  Secure variables are written below the path prefix, and the command refuses
+  to run if any secure variables already exist below it. The read, list and
+  mixed workloads first write one secure variable for each path, and all the
+  secure variables written are deleted once the benchmark completes unless
+  -cleanup=false is set.
+
+  NOTE: The benchmark adds load to the cluster and writes to the Raft log.
+  Avoid running it against a production cluster.
+
+  If ACLs are enabled, this command requires a token with the 'read', 'write'
+  and 'list' capabilities for the path prefix.
+
+General Options:
+
+  ` + generalOptionsUsage(usageOptsDefault) + `
+
+Var Bench Options:
+
+  -workload=["read"|"write"|"list"|"mixed"]
+    The operations to run. The mixed workload runs an even mix of reads,
+    writes and lists. Defaults to "write".
+
+  -requests=<count>
+    The total number of requests to make. Defaults to 1000.
+
+  -concurrency=<count>
+    The number of requests to make in parallel. Defaults to 10.
+
+  -payload-size=<bytes>
+    The size of the item stored in each secure variable. Defaults to 128.
+
+  -paths=<count>
+    The number of distinct paths the requests are spread over. Defaults
+    to 100.
+
+  -prefix=<path>
+    The path prefix secure variables are written below. No secure variables
+    may exist below it. Defaults to "nomad-var-bench".
+
+  -cleanup=[true|false]
+    Whether to delete the secure variables written once the benchmark
+    completes. Defaults to true.
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *OperatorVarBenchCommand) AutocompleteFlags() complete.Flags {
+	return mergeAutocompleteFlags(c.Meta.AutocompleteFlags(FlagSetClient),
+		complete.Flags{
+			"-workload": complete.PredictSet(
+				varBenchOpRead, varBenchOpWrite, varBenchOpList, varBenchWorkloadMixed),
+			"-requests":     complete.PredictAnything,
+			"-concurrency":  complete.PredictAnything,
+			"-payload-size": complete.PredictAnything,
+			"-paths":        complete.PredictAnything,
+			"-prefix":       complete.PredictAnything,
+			"-cleanup":      complete.PredictSet("true", "false"),
+		})
+}
+
+func (c *OperatorVarBenchCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *OperatorVarBenchCommand) Synopsis() string {
+	return "Benchmark the secure variables API"
+}
+
+func (c *OperatorVarBenchCommand) Name() string { return "operator var-bench" }
+
+func (c *OperatorVarBenchCommand) Run(args []string) int {
+	var workload, prefix string
+	var requests, concurrency, payloadSize, paths int
+	var cleanup bool
+
+	flags := c.Meta.FlagSet(c.Name(), FlagSetClient)
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	flags.StringVar(&workload, "workload", varBenchOpWrite, "")
+	flags.IntVar(&requests, "requests", 1000, "")
+	flags.IntVar(&concurrency, "concurrency", 10, "")
+	flags.IntVar(&payloadSize, "payload-size", 128, "")
+	flags.IntVar(&paths, "paths", 100, "")
+	flags.StringVar(&prefix, "prefix", "nomad-var-bench", "")
+	flags.BoolVar(&cleanup, "cleanup", true, "")
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	// Check that we got no arguments
+	if len(flags.Args()) != 0 {
+		c.Ui.Error("This command takes no arguments")
+		c.Ui.Error(commandErrorText(c))
+		return 1
+	}
+
+	var ops []string
+	switch workload {
+	case varBenchOpRead, varBenchOpWrite, varBenchOpList:
+		ops = []string{workload}
+	case varBenchWorkloadMixed:
+		ops = []string{varBenchOpWrite, varBenchOpRead, varBenchOpList}
+	default:
+		c.Ui.Error(fmt.Sprintf("Invalid workload %q", workload))
+		c.Ui.Error(commandErrorText(c))
+		return 1
+	}
+
+	for name, v := range map[string]int{
+		"requests":    requests,
+		"concurrency": concurrency,
+		"paths":       paths,
+	} {
+		if v < 1 {
+			c.Ui.Error(fmt.Sprintf("The -%s flag must be greater than zero", name))
+			c.Ui.Error(commandErrorText(c))
+			return 1
+		}
+	}
+	if payloadSize < 0 {
+		c.Ui.Error("The -payload-size flag must not be negative")
+		c.Ui.Error(commandErrorText(c))
+		return 1
+	}
+
+	prefix = strings.Trim(prefix, "/")
+	if prefix == "" {
+		c.Ui.Error("The -prefix flag must not be empty")
+		c.Ui.Error(commandErrorText(c))
+		return 1
+	}
+
+	// Get the HTTP client
+	client, err := c.Meta.Client()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error initializing client: %s", err))
+		return 1
+	}
+
+	if c.Meta.clientConfig().Namespace == api.AllNamespacesNamespace {
+		c.Ui.Error("Secure variables can not be written to the wildcard (\"*\") namespace")
+		return 1
+	}
+
+	bench := &varBench{
+		client:      client.SecureVariables(),
+		ops:         ops,
+		prefix:      prefix,
+		paths:       paths,
+		concurrency: concurrency,
+		payload:     strings.Repeat("x", payloadSize),
+	}
+
+	// Refuse to write to a prefix that is in use, so that the benchmark
+	// can't overwrite or delete secure variables it didn't write
+	existing, _, err := bench.client.PrefixList(prefix+"/", nil)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error listing secure variables: %s", err))
+		return 1
+	}
+	if len(existing) > 0 {
+		c.Ui.Error(fmt.Sprintf("Secure variables already exist below %q, use another prefix", prefix+"/"))
+		return 1
+	}
+
+	// Delete the secure variables written once done, and seed the paths the
+	// read and list operations act upon
+	if cleanup {
+		defer func() {
+			if err := bench.cleanup(); err != nil {
+				c.Ui.Error(fmt.Sprintf("Error deleting benchmark secure variables: %s", err))
+			}
+		}()
+	}
+	if workload != varBenchOpWrite {
+		if err := bench.seed(); err != nil {
+			c.Ui.Error(fmt.Sprintf("Error writing benchmark secure variables: %s", err))
+			return 1
+		}
+	}
+
+	startIndex, raftOK := varBenchRaftAppliedIndex(client)
+	results, elapsed := bench.run(requests)
+	endIndex, endOK := varBenchRaftAppliedIndex(client)
+	raftOK = raftOK && endOK
+
+	total := &varBenchResult{}
+	for _, op := range ops {
+		total.merge(results[op])
+	}
+
+	basic := []string{
+		fmt.Sprintf("Workload|%s", workload),
+		fmt.Sprintf("Requests|%d", total.count()),
+		fmt.Sprintf("Errors|%d", total.errors),
+		fmt.Sprintf("Concurrency|%d", concurrency),
+		fmt.Sprintf("Payload Size|%d", payloadSize),
+		fmt.Sprintf("Paths|%d", paths),
+		fmt.Sprintf("Duration|%s", elapsed.Round(time.Millisecond)),
+		fmt.Sprintf("Throughput|%.2f requests/s", varBenchRate(uint64(total.count()), elapsed)),
+	}
+	if raftOK {
+		applies := endIndex - startIndex
+		basic = append(basic,
+			fmt.Sprintf("Raft Applies|%d", applies),
+			fmt.Sprintf("Raft Apply Throughput|%.2f applies/s", varBenchRate(applies, elapsed)))
+	} else {
+		basic = append(basic, "Raft Apply Throughput|<unavailable>")
+	}
+	c.Ui.Output(formatKV(basic))
+
+	rows := []string{"Operation|Requests|Errors|Mean|P50|P90|P99|Max"}
+	for _, op := range ops {
+		rows = append(rows, results[op].row(op))
+	}
+	if len(ops) > 1 {
+		rows = append(rows, total.row("total"))
+	}
+	c.Ui.Output(c.Colorize().Color("\n[bold]Latency[reset]"))
+	c.Ui.Output(formatList(rows))
+
+	if total.errors > 0 {
+		c.Ui.Error(fmt.Sprintf("\n%d requests failed, the last error was: %s", total.errors, total.lastErr))
+		return 1
+	}
+	return 0
+}
+
+// varBench runs a benchmark workload against the secure variables API.
+type varBench struct {
+	client      *api.SecureVariables
+	ops         []string
+	prefix      string
+	paths       int
+	concurrency int
+	payload     string
+
+	// written holds the paths written, which are deleted by cleanup
+	written     map[string]struct{}
+	writtenLock sync.Mutex
+}
+
+// varBenchResult holds the latencies of the successful requests made for an
+// operation.
+type varBenchResult struct {
+	latencies []time.Duration
+	errors    int
+	lastErr   error
+}
+
+func (r *varBenchResult) count() int {
+	return len(r.latencies) + r.errors
+}
+
+func (r *varBenchResult) merge(o *varBenchResult) {
+	r.latencies = append(r.latencies, o.latencies...)
+	r.errors += o.errors
+	if o.lastErr != nil {
+		r.lastErr = o.lastErr
+	}
+}
+
+// row returns the latency table row of the operation.
+func (r *varBenchResult) row(op string) string {
+	sort.Slice(r.latencies, func(i, j int) bool { return r.latencies[i] < r.latencies[j] })
+
+	var sum time.Duration
+	for _, l := range r.latencies {
+		sum += l
+	}
+	var mean time.Duration
+	if len(r.latencies) > 0 {
+		mean = sum / time.Duration(len(r.latencies))
+	}
+
+	return fmt.Sprintf("%s|%d|%d|%s|%s|%s|%s|%s", op, r.count(), r.errors,
+		varBenchDuration(mean),
+		varBenchDuration(varBenchPercentile(r.latencies, 50)),
+		varBenchDuration(varBenchPercentile(r.latencies, 90)),
+		varBenchDuration(varBenchPercentile(r.latencies, 99)),
+		varBenchDuration(varBenchPercentile(r.latencies, 100)))
+}
+
+func (b *varBench) path(i int) string {
+	return fmt.Sprintf("%s/%d", b.prefix, i%b.paths)
+}
+
+func (b *varBench) write(i int) error {
+	sv := api.NewSecureVariable(b.path(i))
+	sv.Items["payload"] = b.payload
+	if _, err := b.client.Create(sv, nil); err != nil {
+		return err
+	}
+
+	b.writtenLock.Lock()
+	defer b.writtenLock.Unlock()
+	if b.written == nil {
+		b.written = make(map[string]struct{}, b.paths)
+	}
+	b.written[sv.Path] = struct{}{}
+	return nil
+}
+
+// seed writes a secure variable for each path.
+func (b *varBench) seed() error {
+	for i := 0; i < b.paths; i++ {
+		if err := b.write(i); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// cleanup deletes the secure variables written by the benchmark.
+func (b *varBench) cleanup() error {
+	b.writtenLock.Lock()
+	defer b.writtenLock.Unlock()
+	for path := range b.written {
+		if _, err := b.client.Delete(path, nil); err != nil {
+			return err
+		}
+		delete(b.written, path)
+	}
+	return nil
+}
+
+// do makes the i-th request of the benchmark.
+func (b *varBench) do(op string, i int) error {
+	switch op {
+	case varBenchOpRead:
+		_, _, err := b.client.Read(b.path(i), nil)
+		return err
+	case varBenchOpList:
+		_, _, err := b.client.PrefixList(b.prefix+"/", nil)
+		return err
+	default:
+		return b.write(i)
+	}
+}
+
+// run makes the requests and returns the results of each operation and the
+// time taken.
+func (b *varBench) run(requests int) (map[string]*varBenchResult, time.Duration) {
+	results := make(map[string]*varBenchResult, len(b.ops))
+	for _, op := range b.ops {
+		results[op] = &varBenchResult{}
+	}
+
+	var l sync.Mutex
+	var wg sync.WaitGroup
+	reqCh := make(chan int)
+
+	start := time.Now()
+	for w := 0; w < b.concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range reqCh {
+				op := b.ops[i%len(b.ops)]
+				reqStart := time.Now()
+				err := b.do(op, i)
+				latency := time.Since(reqStart)
+
+				l.Lock()
+				if err != nil {
+					results[op].errors++
+					results[op].lastErr = err
+				} else {
+					results[op].latencies = append(results[op].latencies, latency)
+				}
+				l.Unlock()
+			}
+		}()
+	}
+	for i := 0; i < requests; i++ {
+		reqCh <- i
+	}
+	close(reqCh)
+	wg.Wait()
+
+	return results, time.Since(start)
+}
+
+// varBenchRaftAppliedIndex returns the Raft applied index reported by the
+// agent. It is unavailable when the agent is not a server.
+func varBenchRaftAppliedIndex(client *api.Client) (uint64, bool) {
+	self, err := client.Agent().Self()
+	if err != nil {
+		return 0, false
+	}
+	raw, ok := self.Stats["raft"]["applied_index"]
+	if !ok {
+		return 0, false
+	}
+	index, err := strconv.ParseUint(raw, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return index, true
+}
+
+// varBenchPercentile returns the p-th percentile of the sorted latencies
+// using the nearest-rank method.
+func varBenchPercentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+func varBenchRate(n uint64, elapsed time.Duration) float64 {
+	if elapsed <= 0 {
+		return 0
+	}
+	return float64(n) / elapsed.Seconds()
+}
+
+func varBenchDuration(d time.Duration) string {
+	return d.Round(time.Microsecond).String()
+}
@@ -0,0 +1,95 @@
+package command
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hashicorp/nomad/api"
+	"github.com/hashicorp/nomad/ci"
+	"github.com/mitchellh/cli"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOperatorVarBenchCommand_Implements(t *testing.T) {
+	ci.Parallel(t)
+	var _ cli.Command = &OperatorVarBenchCommand{}
+}
+
+func TestOperatorVarBenchCommand_Offline(t *testing.T) {
+	ci.Parallel(t)
+	ui := cli.NewMockUi()
+	cmd := &OperatorVarBenchCommand{Meta: Meta{Ui: ui}}
+
+	code := cmd.Run([]string{"-workload=delete"})
+	require.Equal(t, 1, code)
+	require.Contains(t, ui.ErrorWriter.String(), `Invalid workload "delete"`)
+
+	resetUiWriters(ui)
+	code = cmd.Run([]string{"-concurrency=0"})
+	require.Equal(t, 1, code)
+	require.Contains(t, ui.ErrorWriter.String(), "The -concurrency flag must be greater than zero")
+}
+
+func TestOperatorVarBenchCommand_Run(t *testing.T) {
+	ci.Parallel(t)
+
+	srv, client, url := testServer(t, false, nil)
+	defer srv.Shutdown()
+
+	ui := cli.NewMockUi()
+	cmd := &OperatorVarBenchCommand{Meta: Meta{Ui: ui}}
+
+	code := cmd.Run([]string{"-address=" + url, "-workload=mixed",
+		"-requests=30", "-concurrency=3", "-paths=5", "-prefix=bench"})
+	require.Equal(t, 0, code, "stderr: %s", ui.ErrorWriter.String())
+
+	out := ui.OutputWriter.String()
+	require.Contains(t, out, "Workload")
+	require.Contains(t, out, "mixed")
+	require.Contains(t, out, "Raft Apply Throughput")
+	require.Regexp(t, `write\s+10\s+0`, out)
+	require.Regexp(t, `read\s+10\s+0`, out)
+	require.Regexp(t, `list\s+10\s+0`, out)
+	require.Regexp(t, `total\s+30\s+0`, out)
+
+	// The secure variables written are deleted once done
+	metas, _, err := client.SecureVariables().PrefixList("bench/", nil)
+	require.NoError(t, err)
+	require.Empty(t, metas)
+
+	// Paths that weren't written are skipped when cleaning up
+	resetUiWriters(ui)
+	code = cmd.Run([]string{"-address=" + url, "-requests=2", "-paths=5", "-prefix=bench"})
+	require.Equal(t, 0, code, "stderr: %s", ui.ErrorWriter.String())
+	require.Empty(t, ui.ErrorWriter.String())
+
+	// A prefix holding secure variables is refused, and they are kept
+	_, err = client.SecureVariables().Create(&api.SecureVariable{
+		Path:  "bench/0",
+		Items: map[string]string{"keep": "me"},
+	}, nil)
+	require.NoError(t, err)
+
+	resetUiWriters(ui)
+	code = cmd.Run([]string{"-address=" + url, "-requests=5", "-paths=5", "-prefix=bench"})
+	require.Equal(t, 1, code)
+	require.Contains(t, ui.ErrorWriter.String(), `Secure variables already exist below "bench/"`)
+
+	sv, _, err := client.SecureVariables().Read("bench/0", nil)
+	require.NoError(t, err)
+	require.Equal(t, "me", sv.Items["keep"])
+}
+
+func TestVarBenchPercentile(t *testing.T) {
+	ci.Parallel(t)
+
+	var sorted []time.Duration
+	for i := 1; i <= 10; i++ {
+		sorted = append(sorted, time.Duration(i)*time.Millisecond)
+	}
+	require.Equal(t, 5*time.Millisecond, varBenchPercentile(sorted, 50))
+	require.Equal(t, 9*time.Millisecond, varBenchPercentile(sorted, 90))
+	require.Equal(t, 10*time.Millisecond, varBenchPercentile(sorted, 99))
+	require.Equal(t, 10*time.Millisecond, varBenchPercentile(sorted, 100))
+	require.Equal(t, time.Duration(0), varBenchPercentile(nil, 50))
+}
@@ -1,8 +1,11 @@
 package command
 
 import (
+	"flag"
+	"fmt"
 	"strings"
 
+	"github.com/hashicorp/nomad/api"
 	"github.com/hashicorp/nomad/api/contexts"
 	"github.com/mitchellh/cli"
 	"github.com/posener/complete"
@@ -72,3 +75,100 @@ func SecureVariablePathPredictor(factory ApiClientFactory) complete.Predictor {
 		return resp.Matches[contexts.SecureVariables]
 	})
 }
+
+// varClusterFlags holds the connection settings for an additional cluster
+// used by commands that work with secure variables across clusters. Unlike
+// the general client options, these settings are never read from the
+// environment, so that the local cluster's token isn't sent elsewhere.
+type varClusterFlags struct {
+	address       string
+	region        string
+	token         string
+	caCert        string
+	clientCert    string
+	clientKey     string
+	tlsServerName string
+	insecure      bool
+}
+
+// register adds the cluster flags to the flag set, each named with the given
+// prefix such as "to-".
+func (f *varClusterFlags) register(flags *flag.FlagSet, prefix string) {
+	flags.StringVar(&f.address, prefix+"address", "", "")
+	flags.StringVar(&f.region, prefix+"region", "", "")
+	flags.StringVar(&f.token, prefix+"token", "", "")
+	flags.StringVar(&f.caCert, prefix+"ca-cert", "", "")
+	flags.StringVar(&f.clientCert, prefix+"client-cert", "", "")
+	flags.StringVar(&f.clientKey, prefix+"client-key", "", "")
+	flags.StringVar(&f.tlsServerName, prefix+"tls-server-name", "", "")
+	flags.BoolVar(&f.insecure, prefix+"tls-skip-verify", false, "")
+}
+
+func (f *varClusterFlags) autocompleteFlags(prefix string) complete.Flags {
+	return complete.Flags{
+		"-" + prefix + "address":         complete.PredictAnything,
+		"-" + prefix + "region":          complete.PredictAnything,
+		"-" + prefix + "token":           complete.PredictAnything,
+		"-" + prefix + "ca-cert":         complete.PredictFiles("*"),
+		"-" + prefix + "client-cert":     complete.PredictFiles("*"),
+		"-" + prefix + "client-key":      complete.PredictFiles("*"),
+		"-" + prefix + "tls-server-name": complete.PredictAnything,
+		"-" + prefix + "tls-skip-verify": complete.PredictNothing,
+	}
+}
+
+// client returns an API client for the cluster. The address is required.
+func (f *varClusterFlags) client(prefix string) (*api.Client, error) {
+	if f.address == "" {
+		return nil, fmt.Errorf("The -%saddress flag is required", prefix)
+	}
+	return api.NewClient(&api.Config{
+		Address:  f.address,
+		Region:   f.region,
+		SecretID: f.token,
+		TLSConfig: &api.TLSConfig{
+			CACert:        f.caCert,
+			ClientCert:    f.clientCert,
+			ClientKey:     f.clientKey,
+			TLSServerName: f.tlsServerName,
+			Insecure:      f.insecure,
+		},
+	})
+}
+
+// varClusterFlagsUsage returns the help text for the flags registered by
+// varClusterFlags.register with the given prefix. The name describes the
+// cluster, as in "the destination cluster".
+func varClusterFlagsUsage(prefix, name string) string {
+	helpText := `
+  -{{p}}address=<addr>
+    The address of a Nomad server in {{n}}.
+
+  -{{p}}region=<region>
+    The region of {{n}} to forward requests to.
+
+  -{{p}}token
+    The SecretID of an ACL token to authenticate requests to {{n}}.
+
+  -{{p}}ca-cert=<path>
+    Path to a PEM encoded CA cert file to use to verify the SSL certificate
+    of {{n}}.
+
+  -{{p}}client-cert=<path>
+    Path to a PEM encoded client certificate for TLS authentication to
+    {{n}}. Must also specify -{{p}}client-key.
+
+  -{{p}}client-key=<path>
+    Path to an unencrypted PEM encoded private key matching the client
+    certificate from -{{p}}client-cert.
+
+  -{{p}}tls-server-name=<value>
+    The server name to use as the SNI host when connecting to {{n}}.
+
+  -{{p}}tls-skip-verify
+    Do not verify the TLS certificate of {{n}}. This is highly not
+    recommended.
+`
+	r := strings.NewReplacer("{{p}}", prefix, "{{n}}", name)
+	return strings.TrimSpace(r.Replace(helpText))
+}
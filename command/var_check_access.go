@@ -0,0 +1,261 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/nomad/acl"
+	"github.com/hashicorp/nomad/api"
+	"github.com/posener/complete"
+)
+
+// varCheckAccessDeniedExitCode is returned when at least one of the requested
+// operations is denied, so that scripts can tell a denial apart from an
+// error running the command.
+const varCheckAccessDeniedExitCode = 2
+
+type VarCheckAccessCommand struct {
+	Meta
+}
+
+func (c *VarCheckAccessCommand) Help() string {
+	helpText := `
+Usage: nomad var check-access [options] -path=<path>
+
+  Check-access is used to verify that the current ACL token is allowed to
+  perform a set of secure variable operations on a path, before those
+  operations are attempted. Each requested operation is reported as allowed or
+  denied.
+
+  The token's policies are fetched from the cluster and evaluated locally, so
+  the token must be able to read its own policies. When ACLs are disabled, all
+  operations are reported as allowed.
+
+  The command exits with 0 when every requested operation is allowed, 2 when
+  at least one is denied, and 1 on any other error.
+
+General Options:
+
+  ` + generalOptionsUsage(usageOptsDefault) + `
+
+Check-Access Options:
+
+  -path
+    The secure variable path to check. Required.
+
+  -ops
+    Comma-separated list of operations to check. Valid operations are "read",
+    "write", "list", and "destroy". Defaults to all of them.
+
+  -json
+    Output the results in JSON format.
+
+  -t
+    Format and display the results using a Go template.
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *VarCheckAccessCommand) AutocompleteFlags() complete.Flags {
+	return mergeAutocompleteFlags(c.Meta.AutocompleteFlags(FlagSetClient),
+		complete.Flags{
+			"-path": SecureVariablePathPredictor(c.Meta.Client),
+			"-ops":  complete.PredictAnything,
+			"-json": complete.PredictNothing,
+			"-t":    complete.PredictAnything,
+		},
+	)
+}
+
+func (c *VarCheckAccessCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *VarCheckAccessCommand) Synopsis() string {
+	return "Check the current token's access to a secure variable path"
+}
+
+func (c *VarCheckAccessCommand) Name() string { return "var check-access" }
+
+func (c *VarCheckAccessCommand) Run(args []string) int {
+	var json bool
+	var path, ops, tmpl string
+
+	flags := c.Meta.FlagSet(c.Name(), FlagSetClient)
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	flags.StringVar(&path, "path", "", "")
+	flags.StringVar(&ops, "ops", "read,write,list,destroy", "")
+	flags.BoolVar(&json, "json", false, "")
+	flags.StringVar(&tmpl, "t", "", "")
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	// Check that we got no arguments
+	args = flags.Args()
+	if l := len(args); l != 0 {
+		c.Ui.Error("This command takes no arguments")
+		c.Ui.Error(commandErrorText(c))
+		return 1
+	}
+
+	path = strings.Trim(path, " /")
+	if path == "" {
+		c.Ui.Error("The -path flag is required")
+		c.Ui.Error(commandErrorText(c))
+		return 1
+	}
+
+	opList, err := parseVarAccessOps("ops", ops)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		c.Ui.Error(commandErrorText(c))
+		return 1
+	}
+
+	ns := c.Meta.clientConfig().Namespace
+	switch ns {
+	case "":
+		ns = api.DefaultNamespace
+	case api.AllNamespacesNamespace:
+		c.Ui.Error("Access can not be checked against the wildcard (\"*\") namespace")
+		return 1
+	}
+
+	// Get the HTTP client
+	client, err := c.Meta.Client()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error initializing client: %s", err))
+		return 1
+	}
+
+	aclObj, err := c.resolveSelfACL(client)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return 1
+	}
+
+	results := make([]*varAccessResult, len(opList))
+	denied := false
+	for i, op := range opList {
+		r := &varAccessResult{
+			Namespace: ns,
+			Path:      path,
+			Operation: op,
+			Allowed:   aclObj.AllowSecureVariableOperation(ns, path, op),
+		}
+		if !r.Allowed {
+			denied = true
+		}
+		results[i] = r
+	}
+
+	if json || len(tmpl) > 0 {
+		out, err := Format(json, tmpl, results)
+		if err != nil {
+			c.Ui.Error(err.Error())
+			return 1
+		}
+		c.Ui.Output(out)
+	} else {
+		c.Ui.Output(formatVarAccessResults(results))
+	}
+
+	if denied {
+		return varCheckAccessDeniedExitCode
+	}
+	return 0
+}
+
+// resolveSelfACL builds an ACL object from the policies attached to the
+// token in use. When ACLs are disabled on the cluster, the management ACL is
+// returned since every operation is permitted.
+func (c *VarCheckAccessCommand) resolveSelfACL(client *api.Client) (*acl.ACL, error) {
+	token, _, err := client.ACLTokens().Self(nil)
+	if err != nil {
+		if strings.Contains(err.Error(), "ACL support disabled") {
+			c.Ui.Warn("ACLs are disabled on this cluster; all operations are allowed")
+			return acl.ManagementACL, nil
+		}
+		return nil, fmt.Errorf("Error fetching self token: %s", err)
+	}
+
+	if token.Type == "management" {
+		return acl.ManagementACL, nil
+	}
+
+	policies := make([]*acl.Policy, 0, len(token.Policies))
+	for _, name := range token.Policies {
+		p, _, err := client.ACLPolicies().Info(name, nil)
+		if err != nil {
+			// The server ignores policies that are attached to a token but
+			// no longer exist, so missing ones grant nothing here either.
+			if strings.Contains(err.Error(), "404") {
+				continue
+			}
+			return nil, fmt.Errorf("Error fetching ACL policy %q: %s", name, err)
+		}
+
+		parsed, err := acl.Parse(p.Rules)
+		if err != nil {
+			return nil, fmt.Errorf("Error parsing ACL policy %q: %s", name, err)
+		}
+		policies = append(policies, parsed)
+	}
+
+	aclObj, err := acl.NewACL(false, policies)
+	if err != nil {
+		return nil, fmt.Errorf("Error compiling ACL policies: %s", err)
+	}
+	return aclObj, nil
+}
+
+// varAccessResult is the outcome of checking a single operation.
+type varAccessResult struct {
+	Namespace string
+	Path      string
+	Operation string
+	Allowed   bool
+}
+
+// parseVarAccessOps splits the comma-separated value of the named flag and
+// validates each of the operations it contains.
+func parseVarAccessOps(flag, in string) ([]string, error) {
+	var out []string
+	seen := make(map[string]struct{})
+	for _, op := range strings.Split(in, ",") {
+		op = strings.ToLower(strings.TrimSpace(op))
+		if op == "" {
+			continue
+		}
+		switch op {
+		case acl.SecureVariablesCapabilityRead, acl.SecureVariablesCapabilityWrite,
+			acl.SecureVariablesCapabilityList, acl.SecureVariablesCapabilityDestroy:
+		default:
+			return nil, fmt.Errorf("Invalid operation %q; valid operations are read, write, list, and destroy", op)
+		}
+		if _, ok := seen[op]; ok {
+			continue
+		}
+		seen[op] = struct{}{}
+		out = append(out, op)
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("At least one operation must be provided to -%s", flag)
+	}
+	return out, nil
+}
+
+func formatVarAccessResults(results []*varAccessResult) string {
+	rows := make([]string, len(results)+1)
+	rows[0] = "Namespace|Path|Operation|Result"
+	for i, r := range results {
+		result := "allowed"
+		if !r.Allowed {
+			result = "denied"
+		}
+		rows[i+1] = fmt.Sprintf("%s|%s|%s|%s", r.Namespace, r.Path, r.Operation, result)
+	}
+	return formatList(rows)
+}
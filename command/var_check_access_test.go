@@ -0,0 +1,193 @@
+package command
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/nomad/ci"
+	"github.com/hashicorp/nomad/command/agent"
+	"github.com/hashicorp/nomad/nomad/mock"
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/mitchellh/cli"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVarCheckAccessCommand_Implements(t *testing.T) {
+	ci.Parallel(t)
+	var _ cli.Command = &VarCheckAccessCommand{}
+}
+
+func TestVarCheckAccessCommand_Offline(t *testing.T) {
+	ci.Parallel(t)
+	ui := cli.NewMockUi()
+	cmd := &VarCheckAccessCommand{Meta: Meta{Ui: ui}}
+
+	testCases := []struct {
+		name      string
+		args      []string
+		expectErr string
+	}{
+		{
+			name:      "bad args",
+			args:      []string{"-path=a", "extra"},
+			expectErr: "This command takes no arguments",
+		},
+		{
+			name:      "missing path",
+			args:      []string{},
+			expectErr: "The -path flag is required",
+		},
+		{
+			name:      "invalid op",
+			args:      []string{"-path=a", "-ops=read,execute"},
+			expectErr: `Invalid operation "execute"`,
+		},
+		{
+			name:      "empty ops",
+			args:      []string{"-path=a", "-ops=,"},
+			expectErr: "At least one operation must be provided",
+		},
+		{
+			name:      "wildcard namespace",
+			args:      []string{"-path=a", "-namespace=*"},
+			expectErr: "Access can not be checked against the wildcard",
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			defer resetUiWriters(ui)
+			code := cmd.Run(tC.args)
+			require.Equal(t, 1, code)
+			require.Contains(t, ui.ErrorWriter.String(), tC.expectErr)
+		})
+	}
+}
+
+func TestVarCheckAccessCommand_ACLsDisabled(t *testing.T) {
+	ci.Parallel(t)
+	srv, _, url := testServer(t, false, nil)
+	defer srv.Shutdown()
+
+	ui := cli.NewMockUi()
+	cmd := &VarCheckAccessCommand{Meta: Meta{Ui: ui}}
+
+	code := cmd.Run([]string{"-address=" + url, "-path=a/b", "-ops=read,destroy"})
+	require.Equal(t, 0, code, "stderr: %s", ui.ErrorWriter.String())
+	require.Contains(t, ui.ErrorWriter.String(), "ACLs are disabled")
+
+	out := ui.OutputWriter.String()
+	require.Contains(t, out, "read")
+	require.Contains(t, out, "destroy")
+	require.NotContains(t, out, "denied")
+}
+
+func TestVarCheckAccessCommand_Policies(t *testing.T) {
+	ci.Parallel(t)
+	config := func(c *agent.Config) {
+		c.ACL.Enabled = true
+	}
+	srv, _, url := testServer(t, false, config)
+	defer srv.Shutdown()
+	state := srv.Agent.Server().State()
+
+	policy := mock.ACLPolicy()
+	policy.Rules = `
+namespace "default" {
+  secure_variables {
+    path "app/*" {
+      capabilities = ["read"]
+    }
+  }
+}`
+	policy.SetHash()
+	require.NoError(t, state.UpsertACLPolicies(structs.MsgTypeTestSetup, 1000, []*structs.ACLPolicy{policy}))
+
+	token := mock.ACLToken()
+	token.Policies = []string{policy.Name}
+	token.SetHash()
+	require.NoError(t, state.UpsertACLTokens(structs.MsgTypeTestSetup, 1001, []*structs.ACLToken{token}))
+
+	ui := cli.NewMockUi()
+	cmd := &VarCheckAccessCommand{Meta: Meta{Ui: ui}}
+	baseArgs := []string{"-address=" + url, "-token=" + token.SecretID}
+
+	t.Run("allowed", func(t *testing.T) {
+		defer resetUiWriters(ui)
+		code := cmd.Run(append(baseArgs, "-path=app/db", "-ops=read,list"))
+		require.Equal(t, 0, code, "stderr: %s", ui.ErrorWriter.String())
+		require.NotContains(t, ui.OutputWriter.String(), "denied")
+	})
+
+	t.Run("denied", func(t *testing.T) {
+		defer resetUiWriters(ui)
+		code := cmd.Run(append(baseArgs, "-path=app/db", "-json"))
+		require.Equal(t, varCheckAccessDeniedExitCode, code, "stderr: %s", ui.ErrorWriter.String())
+
+		var results []*varAccessResult
+		require.NoError(t, json.Unmarshal([]byte(ui.OutputWriter.String()), &results))
+		got := make(map[string]bool)
+		for _, r := range results {
+			got[r.Operation] = r.Allowed
+		}
+		require.Equal(t, map[string]bool{
+			"read":    true,
+			"write":   false,
+			"list":    true,
+			"destroy": false,
+		}, got)
+	})
+
+	t.Run("other path", func(t *testing.T) {
+		defer resetUiWriters(ui)
+		code := cmd.Run(append(baseArgs, "-path=other", "-ops=read"))
+		require.Equal(t, varCheckAccessDeniedExitCode, code)
+		require.True(t, strings.Contains(ui.OutputWriter.String(), "denied"))
+	})
+
+	t.Run("management", func(t *testing.T) {
+		defer resetUiWriters(ui)
+		code := cmd.Run([]string{"-address=" + url, "-token=" + srv.RootToken.SecretID, "-path=other"})
+		require.Equal(t, 0, code, "stderr: %s", ui.ErrorWriter.String())
+	})
+}
+
+func TestVarCheckAccessCommand_NamespaceEnv(t *testing.T) {
+	config := func(c *agent.Config) {
+		c.ACL.Enabled = true
+	}
+	srv, _, url := testServer(t, false, config)
+	defer srv.Shutdown()
+	state := srv.Agent.Server().State()
+
+	policy := mock.ACLPolicy()
+	policy.Rules = `
+namespace "prod" {
+  secure_variables {
+    path "app/*" {
+      capabilities = ["read"]
+    }
+  }
+}`
+	policy.SetHash()
+	require.NoError(t, state.UpsertACLPolicies(structs.MsgTypeTestSetup, 1000, []*structs.ACLPolicy{policy}))
+
+	token := mock.ACLToken()
+	token.Policies = []string{policy.Name}
+	token.SetHash()
+	require.NoError(t, state.UpsertACLTokens(structs.MsgTypeTestSetup, 1001, []*structs.ACLToken{token}))
+
+	// The namespace is taken from the environment
+	t.Setenv("NOMAD_NAMESPACE", "prod")
+
+	ui := cli.NewMockUi()
+	cmd := &VarCheckAccessCommand{Meta: Meta{Ui: ui}}
+	code := cmd.Run([]string{"-address=" + url, "-token=" + token.SecretID, "-path=app/db", "-ops=read", "-json"})
+	require.Equal(t, 0, code, "stderr: %s", ui.ErrorWriter.String())
+
+	var results []*varAccessResult
+	require.NoError(t, json.Unmarshal([]byte(ui.OutputWriter.String()), &results))
+	require.Len(t, results, 1)
+	require.Equal(t, "prod", results[0].Namespace)
+	require.True(t, results[0].Allowed)
+}
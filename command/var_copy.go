@@ -0,0 +1,157 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/nomad/api"
+	"github.com/posener/complete"
+)
+
+type VarCopyCommand struct {
+	Meta
+}
+
+func (c *VarCopyCommand) Help() string {
+	helpText := `
+Usage: nomad var copy [options] <source path> <destination path>
+
+  Copy writes the items of the secure variable at the source path to the
+  destination path. With -recurse, every secure variable at or below the
+  source path is copied, keeping its path relative to the source. The
+  variables are copied from the namespace given by -namespace, to the
+  namespace given by -target-namespace.
+
+  All the variables are copied in a single transaction, so either every
+  variable is copied or none are. Nothing is copied if any of the destination
+  paths already holds a secure variable.
+
+  If ACLs are enabled, this command requires a token with the 'read'
+  capability for the source paths and the 'write' capability for the
+  destination paths.
+
+General Options:
+
+  ` + generalOptionsUsage(usageOptsDefault) + `
+
+Copy Options:
+
+  -recurse
+    Copy every secure variable at or below the source path.
+
+  -target-namespace=<namespace>
+    The namespace to copy the secure variables to. Defaults to the namespace
+    they are copied from.
+
+  -json
+    Output the copied secure variables in JSON format.
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *VarCopyCommand) AutocompleteFlags() complete.Flags {
+	return mergeAutocompleteFlags(c.Meta.AutocompleteFlags(FlagSetClient),
+		varCopyAutocompleteFlags(c.Meta))
+}
+
+func varCopyAutocompleteFlags(m Meta) complete.Flags {
+	return complete.Flags{
+		"-recurse":          complete.PredictNothing,
+		"-target-namespace": NamespacePredictor(m.Client, nil),
+		"-json":             complete.PredictNothing,
+	}
+}
+
+func (c *VarCopyCommand) AutocompleteArgs() complete.Predictor {
+	return SecureVariablePathPredictor(c.Meta.Client)
+}
+
+func (c *VarCopyCommand) Synopsis() string {
+	return "Copy secure variables to a new path"
+}
+
+func (c *VarCopyCommand) Name() string { return "var copy" }
+
+func (c *VarCopyCommand) Run(args []string) int {
+	return runVarCopy(&c.Meta, c, args, false)
+}
+
+// varCopyCommand is implemented by the var copy and var move commands.
+type varCopyCommand interface {
+	NamedCommand
+	Help() string
+}
+
+// runVarCopy implements var copy and, with move, var move.
+func runVarCopy(m *Meta, cmd varCopyCommand, args []string, move bool) int {
+	var recurse, json bool
+	var targetNS string
+
+	flags := m.FlagSet(cmd.Name(), FlagSetClient)
+	flags.Usage = func() { m.Ui.Output(cmd.Help()) }
+	flags.BoolVar(&recurse, "recurse", false, "")
+	flags.StringVar(&targetNS, "target-namespace", "", "")
+	flags.BoolVar(&json, "json", false, "")
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	// Check that we got exactly two arguments
+	args = flags.Args()
+	if l := len(args); l != 2 {
+		m.Ui.Error("This command takes two arguments: <source path> <destination path>")
+		m.Ui.Error(commandErrorText(cmd))
+		return 1
+	}
+
+	// Get the HTTP client
+	client, err := m.Client()
+	if err != nil {
+		m.Ui.Error(fmt.Sprintf("Error initializing client: %s", err))
+		return 1
+	}
+
+	if m.clientConfig().Namespace == api.AllNamespacesNamespace || targetNS == api.AllNamespacesNamespace {
+		m.Ui.Error("Secure variables can not be copied or moved to or from the wildcard (\"*\") namespace")
+		return 1
+	}
+
+	copies, _, err := client.SecureVariables().Copy(&api.SecureVariablesCopyRequest{
+		Path:          args[0],
+		DestNamespace: targetNS,
+		DestPath:      args[1],
+		Recurse:       recurse,
+		Move:          move,
+	}, nil)
+	if err != nil {
+		if strings.Contains(err.Error(), "404") {
+			m.Ui.Error(msgSecureVariableNotFound)
+		} else {
+			verb := "copying"
+			if move {
+				verb = "moving"
+			}
+			m.Ui.Error(fmt.Sprintf("Error %s secure variables: %s", verb, err))
+		}
+		return 1
+	}
+
+	if json {
+		out, err := Format(true, "", copies)
+		if err != nil {
+			m.Ui.Error(err.Error())
+			return 1
+		}
+		m.Ui.Output(out)
+		return 0
+	}
+
+	rows := make([]string, len(copies)+1)
+	rows[0] = "Namespace|Path|Destination Namespace|Destination Path"
+	for i, cp := range copies {
+		rows[i+1] = fmt.Sprintf("%s|%s|%s|%s", cp.Namespace, cp.Path, cp.DestNamespace, cp.DestPath)
+	}
+	m.Ui.Output(formatList(rows))
+	return 0
+}
@@ -0,0 +1,93 @@
+package command
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/hashicorp/nomad/api"
+	"github.com/hashicorp/nomad/ci"
+	"github.com/mitchellh/cli"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVarCopyCommand_Implements(t *testing.T) {
+	ci.Parallel(t)
+	var _ cli.Command = &VarCopyCommand{}
+}
+
+func TestVarCopyCommand_Offline(t *testing.T) {
+	ci.Parallel(t)
+	ui := cli.NewMockUi()
+	cmd := &VarCopyCommand{Meta: Meta{Ui: ui}}
+
+	code := cmd.Run([]string{"one"})
+	require.Equal(t, 1, code)
+	require.Contains(t, ui.ErrorWriter.String(), "This command takes two arguments")
+}
+
+func TestVarCopyCommand_Online(t *testing.T) {
+	ci.Parallel(t)
+
+	srv, client, url := testServer(t, false, nil)
+	defer srv.Shutdown()
+
+	_, err := client.Namespaces().Register(&api.Namespace{Name: "ns1"}, nil)
+	require.NoError(t, err)
+	for _, path := range []string{"app/db", "app/web/tls", "apple"} {
+		_, err := client.SecureVariables().Create(&api.SecureVariable{
+			Path:  path,
+			Items: map[string]string{"path": path},
+		}, nil)
+		require.NoError(t, err)
+	}
+
+	ui := cli.NewMockUi()
+	cmd := &VarCopyCommand{Meta: Meta{Ui: ui}}
+
+	t.Run("missing", func(t *testing.T) {
+		defer resetUiWriters(ui)
+		code := cmd.Run([]string{"-address=" + url, "does/not/exist", "other"})
+		require.Equal(t, 1, code)
+		require.Contains(t, ui.ErrorWriter.String(), msgSecureVariableNotFound)
+	})
+
+	t.Run("single", func(t *testing.T) {
+		defer resetUiWriters(ui)
+		code := cmd.Run([]string{"-address=" + url, "app/db", "backup/db"})
+		require.Equal(t, 0, code, "stderr: %s", ui.ErrorWriter.String())
+		require.Contains(t, ui.OutputWriter.String(), "backup/db")
+
+		sv, _, err := client.SecureVariables().Read("backup/db", nil)
+		require.NoError(t, err)
+		require.Equal(t, api.SecureVariableItems{"path": "app/db"}, sv.Items)
+	})
+
+	t.Run("existing destination", func(t *testing.T) {
+		defer resetUiWriters(ui)
+		code := cmd.Run([]string{"-address=" + url, "app/db", "backup/db"})
+		require.Equal(t, 1, code)
+		require.Contains(t, ui.ErrorWriter.String(), "already exists")
+	})
+
+	t.Run("recurse", func(t *testing.T) {
+		defer resetUiWriters(ui)
+		code := cmd.Run([]string{"-address=" + url, "-recurse", "-target-namespace=ns1", "-json", "app", "svc"})
+		require.Equal(t, 0, code, "stderr: %s", ui.ErrorWriter.String())
+
+		var copies []*api.SecureVariableCopy
+		require.NoError(t, json.Unmarshal(ui.OutputWriter.Bytes(), &copies))
+		require.Equal(t, []*api.SecureVariableCopy{
+			{Namespace: "default", Path: "app/db", DestNamespace: "ns1", DestPath: "svc/db"},
+			{Namespace: "default", Path: "app/web/tls", DestNamespace: "ns1", DestPath: "svc/web/tls"},
+		}, copies)
+
+		sv, _, err := client.SecureVariables().Read("svc/web/tls", &api.QueryOptions{Namespace: "ns1"})
+		require.NoError(t, err)
+		require.Equal(t, api.SecureVariableItems{"path": "app/web/tls"}, sv.Items)
+
+		// The sources are kept
+		sv, _, err = client.SecureVariables().Peek("app/web/tls", nil)
+		require.NoError(t, err)
+		require.NotNil(t, sv)
+	})
+}
@@ -0,0 +1,374 @@
+package command
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/nomad/api"
+	"github.com/posener/complete"
+)
+
+const (
+	// varDiffDivergedExitCode is returned when the clusters hold different
+	// secure variables, to distinguish it from errors.
+	varDiffDivergedExitCode = 2
+
+	varDiffOnlyInA = "only in a"
+	varDiffOnlyInB = "only in b"
+	varDiffChanged = "changed"
+)
+
+type VarDiffCommand struct {
+	Meta
+}
+
+func (c *VarDiffCommand) Help() string {
+	helpText := `
+Usage: nomad var diff [options] -between-clusters -a-address=<addr> -b-address=<addr>
+
+  Diff compares the secure variables of two Nomad clusters, referred to as
+  "a" and "b", and reports the paths and items that differ between them. Each
+  cluster is reached with its own address and ACL token; neither is read from
+  the environment.
+
+  Variables are listed from both clusters, and those present on both are
+  fetched and compared item by item. Values are redacted from the output
+  unless -show-values is set.
+
+  The command exits 0 when the clusters hold the same secure variables, 2
+  when they differ, and 1 on error.
+
+  If ACLs are enabled, this command requires tokens with the 'list' and 'read'
+  capabilities on the compared paths of both clusters.
+
+Diff Options:
+
+  -between-clusters
+    Compare the secure variables of the two clusters given by the -a-* and
+    -b-* options. This is currently the only supported comparison and must be
+    set.
+
+  -prefix
+    Only compare secure variables whose path starts with this prefix.
+    Defaults to all paths.
+
+  -namespace
+    The namespace to compare on both clusters. Use "*" to compare the
+    secure variables of all namespaces. Defaults to "default".
+
+  -show-values
+    Include the differing values in the output.
+
+  -concurrency=<n>
+    The maximum number of secure variables fetched from the clusters at
+    once. Defaults to 4.
+
+  -json
+    Output the differences in JSON format.
+
+Cluster A Options:
+
+  ` + varClusterFlagsUsage("a-", `cluster "a"`) + `
+
+Cluster B Options:
+
+  ` + varClusterFlagsUsage("b-", `cluster "b"`)
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *VarDiffCommand) AutocompleteFlags() complete.Flags {
+	var a, b varClusterFlags
+	return mergeAutocompleteFlags(
+		complete.Flags{
+			"-between-clusters": complete.PredictNothing,
+			"-prefix":           complete.PredictAnything,
+			"-namespace":        complete.PredictAnything,
+			"-show-values":      complete.PredictNothing,
+			"-concurrency":      complete.PredictAnything,
+			"-json":             complete.PredictNothing,
+		},
+		a.autocompleteFlags("a-"),
+		b.autocompleteFlags("b-"),
+	)
+}
+
+func (c *VarDiffCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *VarDiffCommand) Synopsis() string {
+	return "Compare the secure variables of two clusters"
+}
+
+func (c *VarDiffCommand) Name() string { return "var diff" }
+
+func (c *VarDiffCommand) Run(args []string) int {
+	var betweenClusters, showValues, json bool
+	var prefix, namespace string
+	var concurrency int
+	var a, b varClusterFlags
+
+	flags := c.Meta.FlagSet(c.Name(), FlagSetNone)
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	flags.BoolVar(&betweenClusters, "between-clusters", false, "")
+	flags.StringVar(&prefix, "prefix", "", "")
+	flags.StringVar(&namespace, "namespace", api.DefaultNamespace, "")
+	flags.BoolVar(&showValues, "show-values", false, "")
+	flags.IntVar(&concurrency, "concurrency", 4, "")
+	flags.BoolVar(&json, "json", false, "")
+	a.register(flags, "a-")
+	b.register(flags, "b-")
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	// Check that we got no arguments
+	args = flags.Args()
+	if l := len(args); l != 0 {
+		c.Ui.Error("This command takes no arguments")
+		c.Ui.Error(commandErrorText(c))
+		return 1
+	}
+
+	if !betweenClusters {
+		c.Ui.Error("The -between-clusters flag is required")
+		c.Ui.Error(commandErrorText(c))
+		return 1
+	}
+
+	if concurrency < 1 {
+		c.Ui.Error("The -concurrency flag must be at least 1")
+		c.Ui.Error(commandErrorText(c))
+		return 1
+	}
+
+	clientA, err := a.client("a-")
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error initializing client for cluster a: %s", err))
+		return 1
+	}
+	clientB, err := b.client("b-")
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error initializing client for cluster b: %s", err))
+		return 1
+	}
+
+	qo := &api.QueryOptions{Namespace: namespace}
+	metasA, _, err := clientA.SecureVariables().PrefixList(prefix, qo)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error retrieving vars from cluster a: %s", err))
+		return 1
+	}
+	metasB, _, err := clientB.SecureVariables().PrefixList(prefix, qo)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error retrieving vars from cluster b: %s", err))
+		return 1
+	}
+
+	results, err := diffVarsBetweenClusters(clientA, clientB, metasA, metasB, concurrency)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return 1
+	}
+	if !showValues {
+		for _, r := range results {
+			for _, k := range r.Keys {
+				k.A, k.B = "", ""
+			}
+		}
+	}
+
+	if json {
+		out, err := Format(true, "", results)
+		if err != nil {
+			c.Ui.Error(err.Error())
+			return 1
+		}
+		c.Ui.Output(out)
+	} else if len(results) == 0 {
+		c.Ui.Output("No differences found")
+	} else {
+		c.Ui.Output(formatVarDiffResults(results, showValues))
+	}
+
+	if len(results) > 0 {
+		return varDiffDivergedExitCode
+	}
+	return 0
+}
+
+// varDiffResult describes how a single secure variable differs between the
+// two clusters.
+type varDiffResult struct {
+	Namespace  string
+	Path       string
+	Difference string
+	Keys       []*varDiffKey `json:",omitempty"`
+}
+
+// varDiffKey describes how a single item of a secure variable present on both
+// clusters differs. The values are only kept when requested.
+type varDiffKey struct {
+	Key        string
+	Difference string
+	A          string `json:",omitempty"`
+	B          string `json:",omitempty"`
+}
+
+type varDiffID struct {
+	namespace, path string
+}
+
+// diffVarsBetweenClusters compares the listed secure variables of the two
+// clusters, fetching the ones present on both with at most concurrency
+// requests in flight. The differences are sorted by namespace and path.
+func diffVarsBetweenClusters(clientA, clientB *api.Client, metasA, metasB []*api.SecureVariableMetadata, concurrency int) ([]*varDiffResult, error) {
+	inA := make(map[varDiffID]bool, len(metasA))
+	for _, m := range metasA {
+		inA[varDiffID{m.Namespace, m.Path}] = true
+	}
+	inB := make(map[varDiffID]bool, len(metasB))
+	for _, m := range metasB {
+		inB[varDiffID{m.Namespace, m.Path}] = true
+	}
+
+	var results []*varDiffResult
+	var shared []varDiffID
+	for id := range inA {
+		if inB[id] {
+			shared = append(shared, id)
+		} else {
+			results = append(results, &varDiffResult{Namespace: id.namespace, Path: id.path, Difference: varDiffOnlyInA})
+		}
+	}
+	for id := range inB {
+		if !inA[id] {
+			results = append(results, &varDiffResult{Namespace: id.namespace, Path: id.path, Difference: varDiffOnlyInB})
+		}
+	}
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+		sem      = make(chan struct{}, concurrency)
+	)
+	for _, id := range shared {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(id varDiffID) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			r, err := diffSharedVar(clientA, clientB, id)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if firstErr == nil {
+					firstErr = err
+				}
+				return
+			}
+			if r != nil {
+				results = append(results, r)
+			}
+		}(id)
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Namespace == results[j].Namespace {
+			return results[i].Path < results[j].Path
+		}
+		return results[i].Namespace < results[j].Namespace
+	})
+	return results, nil
+}
+
+// diffSharedVar fetches a secure variable listed on both clusters and compares
+// its items. It returns nil if the items are the same.
+func diffSharedVar(clientA, clientB *api.Client, id varDiffID) (*varDiffResult, error) {
+	qo := &api.QueryOptions{Namespace: id.namespace}
+	svA, _, err := clientA.SecureVariables().Peek(id.path, qo)
+	if err != nil {
+		return nil, fmt.Errorf("Error retrieving secure variable %q in namespace %q from cluster a: %s", id.path, id.namespace, err)
+	}
+	svB, _, err := clientB.SecureVariables().Peek(id.path, qo)
+	if err != nil {
+		return nil, fmt.Errorf("Error retrieving secure variable %q in namespace %q from cluster b: %s", id.path, id.namespace, err)
+	}
+
+	r := &varDiffResult{Namespace: id.namespace, Path: id.path}
+	switch {
+	// The variable was deleted after it was listed.
+	case svA == nil && svB == nil:
+		return nil, nil
+	case svB == nil:
+		r.Difference = varDiffOnlyInA
+		return r, nil
+	case svA == nil:
+		r.Difference = varDiffOnlyInB
+		return r, nil
+	}
+
+	r.Keys = diffVarItems(svA.Items, svB.Items)
+	if len(r.Keys) == 0 {
+		return nil, nil
+	}
+	r.Difference = varDiffChanged
+	return r, nil
+}
+
+// diffVarItems returns the items that differ between a and b, sorted by key.
+func diffVarItems(a, b api.SecureVariableItems) []*varDiffKey {
+	var keys []*varDiffKey
+	for k, av := range a {
+		bv, ok := b[k]
+		switch {
+		case !ok:
+			keys = append(keys, &varDiffKey{Key: k, Difference: varDiffOnlyInA, A: av})
+		case av != bv:
+			keys = append(keys, &varDiffKey{Key: k, Difference: varDiffChanged, A: av, B: bv})
+		}
+	}
+	for k, bv := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, &varDiffKey{Key: k, Difference: varDiffOnlyInB, B: bv})
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].Key < keys[j].Key })
+	return keys
+}
+
+func formatVarDiffResults(results []*varDiffResult, showValues bool) string {
+	header := "Namespace|Path|Key|Difference"
+	if showValues {
+		header += "|A|B"
+	}
+	rows := []string{header}
+	for _, r := range results {
+		if len(r.Keys) == 0 {
+			row := fmt.Sprintf("%s|%s|%s|%s", r.Namespace, r.Path, "", r.Difference)
+			if showValues {
+				row += "||"
+			}
+			rows = append(rows, row)
+			continue
+		}
+		for _, k := range r.Keys {
+			row := fmt.Sprintf("%s|%s|%s|%s", r.Namespace, r.Path, k.Key, k.Difference)
+			if showValues {
+				row += fmt.Sprintf("|%s|%s", k.A, k.B)
+			}
+			rows = append(rows, row)
+		}
+	}
+	return formatList(rows)
+}
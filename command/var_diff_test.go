@@ -0,0 +1,134 @@
+package command
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/hashicorp/nomad/api"
+	"github.com/hashicorp/nomad/ci"
+	"github.com/mitchellh/cli"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVarDiffCommand_Implements(t *testing.T) {
+	ci.Parallel(t)
+	var _ cli.Command = &VarDiffCommand{}
+}
+
+func TestVarDiffCommand_Offline(t *testing.T) {
+	ci.Parallel(t)
+	ui := cli.NewMockUi()
+	cmd := &VarDiffCommand{Meta: Meta{Ui: ui}}
+
+	testCases := []struct {
+		name      string
+		args      []string
+		expectErr string
+	}{
+		{
+			name:      "bad args",
+			args:      []string{"-between-clusters", "extra"},
+			expectErr: "This command takes no arguments",
+		},
+		{
+			name:      "no comparison",
+			args:      []string{"-a-address=http://127.0.0.1:4646", "-b-address=http://127.0.0.1:4647"},
+			expectErr: "The -between-clusters flag is required",
+		},
+		{
+			name:      "bad concurrency",
+			args:      []string{"-between-clusters", "-concurrency=0"},
+			expectErr: "The -concurrency flag must be at least 1",
+		},
+		{
+			name:      "missing a",
+			args:      []string{"-between-clusters", "-b-address=http://127.0.0.1:4647"},
+			expectErr: "The -a-address flag is required",
+		},
+		{
+			name:      "missing b",
+			args:      []string{"-between-clusters", "-a-address=http://127.0.0.1:4646"},
+			expectErr: "The -b-address flag is required",
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			defer resetUiWriters(ui)
+			code := cmd.Run(tC.args)
+			require.Equal(t, 1, code)
+			require.Contains(t, ui.ErrorWriter.String(), tC.expectErr)
+		})
+	}
+}
+
+func TestVarDiffCommand_Online(t *testing.T) {
+	ci.Parallel(t)
+
+	srvA, clientA, urlA := testServer(t, false, nil)
+	defer srvA.Shutdown()
+	srvB, clientB, urlB := testServer(t, false, nil)
+	defer srvB.Shutdown()
+
+	writeVar := func(c *api.Client, path string, items map[string]string) {
+		_, err := c.SecureVariables().Create(&api.SecureVariable{Path: path, Items: items}, nil)
+		require.NoError(t, err)
+	}
+	writeVar(clientA, "app/same", map[string]string{"k": "v"})
+	writeVar(clientB, "app/same", map[string]string{"k": "v"})
+	writeVar(clientA, "app/db", map[string]string{"user": "app", "pass": "s3cret", "port": "5432"})
+	writeVar(clientB, "app/db", map[string]string{"user": "app", "pass": "other", "host": "db"})
+	writeVar(clientA, "app/only-a", map[string]string{"k": "v"})
+	writeVar(clientB, "web/only-b", map[string]string{"k": "v"})
+
+	ui := cli.NewMockUi()
+	cmd := &VarDiffCommand{Meta: Meta{Ui: ui}}
+	baseArgs := []string{"-between-clusters", "-a-address=" + urlA, "-b-address=" + urlB}
+
+	t.Run("identical", func(t *testing.T) {
+		defer resetUiWriters(ui)
+		code := cmd.Run(append(baseArgs, "-prefix=app/same"))
+		require.Equal(t, 0, code, "stderr: %s", ui.ErrorWriter.String())
+		require.Contains(t, ui.OutputWriter.String(), "No differences found")
+	})
+
+	t.Run("json redacted", func(t *testing.T) {
+		defer resetUiWriters(ui)
+		code := cmd.Run(append(baseArgs, "-json", "-concurrency=1"))
+		require.Equal(t, varDiffDivergedExitCode, code, "stderr: %s", ui.ErrorWriter.String())
+
+		var results []*varDiffResult
+		require.NoError(t, json.Unmarshal(ui.OutputWriter.Bytes(), &results))
+		require.Equal(t, []*varDiffResult{
+			{
+				Namespace:  api.DefaultNamespace,
+				Path:       "app/db",
+				Difference: varDiffChanged,
+				Keys: []*varDiffKey{
+					{Key: "host", Difference: varDiffOnlyInB},
+					{Key: "pass", Difference: varDiffChanged},
+					{Key: "port", Difference: varDiffOnlyInA},
+				},
+			},
+			{Namespace: api.DefaultNamespace, Path: "app/only-a", Difference: varDiffOnlyInA},
+			{Namespace: api.DefaultNamespace, Path: "web/only-b", Difference: varDiffOnlyInB},
+		}, results)
+	})
+
+	t.Run("show values", func(t *testing.T) {
+		defer resetUiWriters(ui)
+		code := cmd.Run(append(baseArgs, "-prefix=app/db", "-show-values"))
+		require.Equal(t, varDiffDivergedExitCode, code, "stderr: %s", ui.ErrorWriter.String())
+		out := ui.OutputWriter.String()
+		require.Contains(t, out, "s3cret")
+		require.Contains(t, out, "other")
+	})
+
+	t.Run("plaintext redacted", func(t *testing.T) {
+		defer resetUiWriters(ui)
+		code := cmd.Run(append(baseArgs, "-prefix=app/db"))
+		require.Equal(t, varDiffDivergedExitCode, code, "stderr: %s", ui.ErrorWriter.String())
+		out := ui.OutputWriter.String()
+		require.Contains(t, out, "pass")
+		require.NotContains(t, out, "s3cret")
+	})
+}
@@ -0,0 +1,284 @@
+package command
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2/hclsimple"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/hashicorp/nomad/api"
+	"github.com/posener/complete"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// varEditDefaultEditor is run when neither $VISUAL nor $EDITOR is set.
+const varEditDefaultEditor = "vi"
+
+type VarEditCommand struct {
+	Meta
+}
+
+func (c *VarEditCommand) Help() string {
+	helpText := `
+Usage: nomad var edit [options] <path>
+
+  Edit opens the items of an existing secure variable in an editor and writes
+  the result back when the editor exits. The editor is taken from the VISUAL
+  or EDITOR environment variable, in that order, and defaults to "vi".
+
+  The update is checked against the version of the variable that was opened,
+  so changes made by others in the meantime are never overwritten. If the
+  update fails, the edited file is kept and its location is reported so the
+  edits aren't lost. Only the items can be changed; the path and namespace are
+  shown for reference.
+
+  If ACLs are enabled, this command requires a token with the 'read' and
+  'write' capabilities for the path.
+
+General Options:
+
+  ` + generalOptionsUsage(usageOptsDefault) + `
+
+Edit Options:
+
+  -json
+    Edit the secure variable as JSON instead of HCL.
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *VarEditCommand) AutocompleteFlags() complete.Flags {
+	return mergeAutocompleteFlags(c.Meta.AutocompleteFlags(FlagSetClient),
+		complete.Flags{
+			"-json": complete.PredictNothing,
+		},
+	)
+}
+
+func (c *VarEditCommand) AutocompleteArgs() complete.Predictor {
+	return SecureVariablePathPredictor(c.Meta.Client)
+}
+
+func (c *VarEditCommand) Synopsis() string {
+	return "Edit a secure variable in an editor"
+}
+
+func (c *VarEditCommand) Name() string { return "var edit" }
+
+func (c *VarEditCommand) Run(args []string) int {
+	var json bool
+
+	flags := c.Meta.FlagSet(c.Name(), FlagSetClient)
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	flags.BoolVar(&json, "json", false, "")
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	// Check that we got exactly one argument
+	args = flags.Args()
+	if l := len(args); l != 1 {
+		c.Ui.Error("This command takes one argument: <path>")
+		c.Ui.Error(commandErrorText(c))
+		return 1
+	}
+	path := args[0]
+
+	// Get the HTTP client
+	client, err := c.Meta.Client()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error initializing client: %s", err))
+		return 1
+	}
+
+	if c.Meta.clientConfig().Namespace == api.AllNamespacesNamespace {
+		c.Ui.Error("Secure variables can not be edited in the wildcard (\"*\") namespace")
+		return 1
+	}
+
+	sv, _, err := client.SecureVariables().Peek(path, nil)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error retrieving secure variable: %s", err))
+		return 1
+	}
+	if sv == nil {
+		c.Ui.Error(msgSecureVariableNotFound)
+		return 1
+	}
+
+	ext, render, parse := ".hcl", renderVarEditHCL, parseVarEditHCL
+	if json {
+		ext, render, parse = ".json", renderVarEditJSON, parseVarEditJSON
+	}
+
+	content, err := render(sv)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error rendering secure variable: %s", err))
+		return 1
+	}
+
+	// CreateTemp opens the file with 0600 permissions, keeping the items
+	// readable only by the current user.
+	f, err := os.CreateTemp("", "nomad-var-*"+ext)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error creating temporary file: %s", err))
+		return 1
+	}
+	fileName := f.Name()
+	_, err = f.Write(content)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(fileName)
+		c.Ui.Error(fmt.Sprintf("Error writing temporary file: %s", err))
+		return 1
+	}
+
+	if err := runVarEditor(fileName); err != nil {
+		os.Remove(fileName)
+		c.Ui.Error(fmt.Sprintf("Error running editor: %s", err))
+		return 1
+	}
+
+	// From here on the edited file is kept on failure, since it may hold
+	// changes that haven't been written anywhere else.
+	edited, err := os.ReadFile(fileName)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error reading edited file %s: %s", fileName, err))
+		return 1
+	}
+
+	items, err := parse(fileName, edited, sv)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error parsing edited secure variable: %s", err))
+		c.Ui.Error(fmt.Sprintf("Your edits were saved to %s", fileName))
+		return 1
+	}
+
+	if varItemsEqual(items, sv.Items) {
+		os.Remove(fileName)
+		c.Ui.Output("No changes made to the secure variable")
+		return 0
+	}
+
+	sv.Items = items
+	if _, err := client.SecureVariables().CheckedUpdate(sv, nil); err != nil {
+		var cas api.ErrCASConflict
+		if errors.As(err, &cas) {
+			c.Ui.Error(fmt.Sprintf("Secure variable %q was modified while it was being edited; nothing was written", sv.Path))
+		} else {
+			c.Ui.Error(fmt.Sprintf("Error updating secure variable: %s", err))
+		}
+		c.Ui.Error(fmt.Sprintf("Your edits were saved to %s", fileName))
+		return 1
+	}
+
+	os.Remove(fileName)
+	c.Ui.Output(fmt.Sprintf("Updated secure variable %q in namespace %q", sv.Path, sv.Namespace))
+	return 0
+}
+
+// runVarEditor opens the file in the user's editor and waits for it to exit.
+func runVarEditor(fileName string) error {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = varEditDefaultEditor
+	}
+
+	// Editors are commonly configured with arguments, as in "code --wait".
+	parts := strings.Fields(editor)
+	cmd := exec.Command(parts[0], append(parts[1:], fileName)...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// varEditSpec is the editable form of a secure variable.
+type varEditSpec struct {
+	Path      string            `hcl:"path,optional"`
+	Namespace string            `hcl:"namespace,optional"`
+	Items     map[string]string `hcl:"items"`
+}
+
+const varEditHCLHeader = `# Edit the items of the secure variable below. The path and namespace are
+# shown for reference and can not be changed.
+
+`
+
+func renderVarEditHCL(sv *api.SecureVariable) ([]byte, error) {
+	f := hclwrite.NewEmptyFile()
+	body := f.Body()
+	body.SetAttributeValue("path", cty.StringVal(sv.Path))
+	body.SetAttributeValue("namespace", cty.StringVal(sv.Namespace))
+	body.AppendNewline()
+
+	items := cty.MapValEmpty(cty.String)
+	if len(sv.Items) > 0 {
+		m := make(map[string]cty.Value, len(sv.Items))
+		for k, v := range sv.Items {
+			m[k] = cty.StringVal(v)
+		}
+		items = cty.MapVal(m)
+	}
+	body.SetAttributeValue("items", items)
+
+	return append([]byte(varEditHCLHeader), f.Bytes()...), nil
+}
+
+func parseVarEditHCL(fileName string, src []byte, sv *api.SecureVariable) (api.SecureVariableItems, error) {
+	var spec varEditSpec
+	if err := hclsimple.Decode(fileName, src, nil, &spec); err != nil {
+		return nil, err
+	}
+	return spec.items(sv)
+}
+
+func renderVarEditJSON(sv *api.SecureVariable) ([]byte, error) {
+	spec := struct {
+		Path      string
+		Namespace string
+		Items     api.SecureVariableItems
+	}{sv.Path, sv.Namespace, sv.Items}
+	out, err := json.MarshalIndent(spec, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(out, '\n'), nil
+}
+
+func parseVarEditJSON(_ string, src []byte, sv *api.SecureVariable) (api.SecureVariableItems, error) {
+	var spec varEditSpec
+	if err := json.Unmarshal(src, &spec); err != nil {
+		return nil, err
+	}
+	if spec.Items == nil {
+		return nil, fmt.Errorf("missing Items")
+	}
+	return spec.items(sv)
+}
+
+// items returns the edited items after checking that the fields which can't
+// be edited were left alone.
+func (s *varEditSpec) items(sv *api.SecureVariable) (api.SecureVariableItems, error) {
+	if s.Path != "" && s.Path != sv.Path {
+		return nil, fmt.Errorf("the path can not be changed from %q", sv.Path)
+	}
+	if s.Namespace != "" && s.Namespace != sv.Namespace {
+		return nil, fmt.Errorf("the namespace can not be changed from %q", sv.Namespace)
+	}
+
+	if _, ok := s.Items[""]; ok {
+		return nil, fmt.Errorf("item keys can not be empty")
+	}
+	return api.SecureVariableItems(s.Items), nil
+}
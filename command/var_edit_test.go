@@ -0,0 +1,107 @@
+package command
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/hashicorp/nomad/api"
+	"github.com/hashicorp/nomad/ci"
+	"github.com/mitchellh/cli"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVarEditCommand_Implements(t *testing.T) {
+	ci.Parallel(t)
+	var _ cli.Command = &VarEditCommand{}
+}
+
+func TestVarEditCommand_Offline(t *testing.T) {
+	ci.Parallel(t)
+	ui := cli.NewMockUi()
+	cmd := &VarEditCommand{Meta: Meta{Ui: ui}}
+
+	code := cmd.Run([]string{"one", "two"})
+	require.Equal(t, 1, code)
+	require.Contains(t, ui.ErrorWriter.String(), "This command takes one argument")
+}
+
+// testVarEditor writes a script to use as the editor, which runs the sed
+// expression against the file being edited. Files kept after a failed edit
+// are written to the test's temporary directory.
+func testVarEditor(t *testing.T, expr string) {
+	dir := t.TempDir()
+	t.Setenv("TMPDIR", dir)
+	script := filepath.Join(dir, "editor.sh")
+	require.NoError(t, os.WriteFile(script, []byte("#!/bin/sh\nsed -i.bak '"+expr+"' \"$1\"\n"), 0700))
+	t.Setenv("VISUAL", script)
+}
+
+// The editor is chosen through the environment, so these tests can't run in
+// parallel.
+func TestVarEditCommand_Online(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("editor script requires a POSIX shell")
+	}
+
+	srv, client, url := testServer(t, false, nil)
+	defer srv.Shutdown()
+
+	_, err := client.SecureVariables().Create(&api.SecureVariable{
+		Path:  "app/db",
+		Items: map[string]string{"user": "app", "db.pass": "s3cret"},
+	}, nil)
+	require.NoError(t, err)
+
+	readItems := func() api.SecureVariableItems {
+		sv, _, err := client.SecureVariables().Read("app/db", nil)
+		require.NoError(t, err)
+		return sv.Items
+	}
+
+	ui := cli.NewMockUi()
+	cmd := &VarEditCommand{Meta: Meta{Ui: ui}}
+
+	t.Run("missing", func(t *testing.T) {
+		defer resetUiWriters(ui)
+		testVarEditor(t, "")
+		code := cmd.Run([]string{"-address=" + url, "does/not/exist"})
+		require.Equal(t, 1, code)
+		require.Contains(t, ui.ErrorWriter.String(), msgSecureVariableNotFound)
+	})
+
+	t.Run("no changes", func(t *testing.T) {
+		defer resetUiWriters(ui)
+		testVarEditor(t, "")
+		code := cmd.Run([]string{"-address=" + url, "app/db"})
+		require.Equal(t, 0, code, "stderr: %s", ui.ErrorWriter.String())
+		require.Contains(t, ui.OutputWriter.String(), "No changes made")
+	})
+
+	t.Run("hcl", func(t *testing.T) {
+		defer resetUiWriters(ui)
+		testVarEditor(t, "s/s3cret/changed/")
+		code := cmd.Run([]string{"-address=" + url, "app/db"})
+		require.Equal(t, 0, code, "stderr: %s", ui.ErrorWriter.String())
+		require.Contains(t, ui.OutputWriter.String(), `Updated secure variable "app/db"`)
+		require.Equal(t, api.SecureVariableItems{"user": "app", "db.pass": "changed"}, readItems())
+	})
+
+	t.Run("json", func(t *testing.T) {
+		defer resetUiWriters(ui)
+		testVarEditor(t, `s/"user": "app"/"owner": "team"/`)
+		code := cmd.Run([]string{"-address=" + url, "-json", "app/db"})
+		require.Equal(t, 0, code, "stderr: %s", ui.ErrorWriter.String())
+		require.Equal(t, api.SecureVariableItems{"owner": "team", "db.pass": "changed"}, readItems())
+	})
+
+	t.Run("path change rejected", func(t *testing.T) {
+		defer resetUiWriters(ui)
+		testVarEditor(t, `s|"app/db"|"app/other"|`)
+		code := cmd.Run([]string{"-address=" + url, "app/db"})
+		require.Equal(t, 1, code)
+		require.Contains(t, ui.ErrorWriter.String(), "the path can not be changed")
+		require.Contains(t, ui.ErrorWriter.String(), "Your edits were saved to")
+	})
+}
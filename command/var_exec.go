@@ -0,0 +1,165 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+
+	"github.com/hashicorp/nomad/api"
+	"github.com/posener/complete"
+)
+
+type VarExecCommand struct {
+	Meta
+}
+
+func (c *VarExecCommand) Help() string {
+	helpText := `
+Usage: nomad var exec [options] <path> [--] <child command> [<args>...]
+
+  Exec reads the secure variable at the path and runs the child command with
+  each of its items exported as an environment variable of the same name. The
+  child inherits the environment of this command, and items override any
+  variables of the same name. Use "--" to separate the child command from the
+  options of this command when it takes flags of its own.
+
+  The exit code of the child command is returned, and interrupt and terminate
+  signals are passed on to it.
+
+  If ACLs are enabled, this command requires a token with the 'read'
+  capability for the path.
+
+General Options:
+
+  ` + generalOptionsUsage(usageOptsDefault) + `
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *VarExecCommand) AutocompleteFlags() complete.Flags {
+	return c.Meta.AutocompleteFlags(FlagSetClient)
+}
+
+func (c *VarExecCommand) AutocompleteArgs() complete.Predictor {
+	return SecureVariablePathPredictor(c.Meta.Client)
+}
+
+func (c *VarExecCommand) Synopsis() string {
+	return "Run a command with secure variable items in its environment"
+}
+
+func (c *VarExecCommand) Name() string { return "var exec" }
+
+func (c *VarExecCommand) Run(args []string) int {
+	flags := c.Meta.FlagSet(c.Name(), FlagSetClient)
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	// Check that we got a path and a child command
+	args = flags.Args()
+	if len(args) > 1 && args[1] == "--" {
+		args = append(args[:1], args[2:]...)
+	}
+	if l := len(args); l < 2 {
+		c.Ui.Error("This command takes at least two arguments: <path> <child command>")
+		c.Ui.Error(commandErrorText(c))
+		return 1
+	}
+
+	// Get the HTTP client
+	client, err := c.Meta.Client()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error initializing client: %s", err))
+		return 1
+	}
+
+	if c.Meta.clientConfig().Namespace == api.AllNamespacesNamespace {
+		c.Ui.Error("Secure variables can not be read from the wildcard (\"*\") namespace")
+		return 1
+	}
+
+	sv, _, err := client.SecureVariables().Peek(args[0], nil)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error retrieving secure variable: %s", err))
+		return 1
+	}
+	if sv == nil {
+		c.Ui.Error(msgSecureVariableNotFound)
+		return 1
+	}
+
+	return c.runChild(varExecEnv(os.Environ(), sv.Items), args[1:])
+}
+
+// runChild runs the child command with the environment and returns its exit
+// code.
+func (c *VarExecCommand) runChild(env []string, command []string) int {
+	cmd := exec.Command(command[0], command[1:]...)
+	cmd.Env = env
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Start(); err != nil {
+		c.Ui.Error(fmt.Sprintf("Error starting child command: %s", err))
+		return 1
+	}
+
+	doneCh := make(chan error, 1)
+	go func() {
+		doneCh <- cmd.Wait()
+	}()
+
+	signalCh := make(chan os.Signal, 1)
+	signal.Notify(signalCh, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signalCh)
+
+	for {
+		select {
+		case sig := <-signalCh:
+			_ = cmd.Process.Signal(sig)
+
+		case err := <-doneCh:
+			var exitErr *exec.ExitError
+			if errors.As(err, &exitErr) {
+				return exitErr.ExitCode()
+			} else if err != nil {
+				c.Ui.Error(fmt.Sprintf("Error running child command: %s", err))
+				return 1
+			}
+			return 0
+		}
+	}
+}
+
+// varExecEnv returns the environment with the items added, replacing any
+// variables of the same name.
+func varExecEnv(environ []string, items api.SecureVariableItems) []string {
+	env := make([]string, 0, len(environ)+len(items))
+	for _, kv := range environ {
+		name := kv
+		if i := strings.Index(kv, "="); i >= 0 {
+			name = kv[:i]
+		}
+		if _, ok := items[name]; !ok {
+			env = append(env, kv)
+		}
+	}
+
+	keys := make([]string, 0, len(items))
+	for k := range items {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		env = append(env, k+"="+items[k])
+	}
+	return env
+}
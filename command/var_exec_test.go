@@ -0,0 +1,80 @@
+package command
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/hashicorp/nomad/api"
+	"github.com/hashicorp/nomad/ci"
+	"github.com/mitchellh/cli"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVarExecCommand_Implements(t *testing.T) {
+	ci.Parallel(t)
+	var _ cli.Command = &VarExecCommand{}
+}
+
+func TestVarExecCommand_Offline(t *testing.T) {
+	ci.Parallel(t)
+	ui := cli.NewMockUi()
+	cmd := &VarExecCommand{Meta: Meta{Ui: ui}}
+
+	code := cmd.Run([]string{"app/db", "--"})
+	require.Equal(t, 1, code)
+	require.Contains(t, ui.ErrorWriter.String(), "This command takes at least two arguments")
+}
+
+func TestVarExecCommand_Online(t *testing.T) {
+	ci.Parallel(t)
+	if runtime.GOOS == "windows" {
+		t.Skip("child commands require a POSIX shell")
+	}
+
+	srv, client, url := testServer(t, false, nil)
+	defer srv.Shutdown()
+
+	_, err := client.SecureVariables().Create(&api.SecureVariable{
+		Path:  "app/db",
+		Items: map[string]string{"user": "admin", "pass": "hunter2"},
+	}, nil)
+	require.NoError(t, err)
+
+	ui := cli.NewMockUi()
+	cmd := &VarExecCommand{Meta: Meta{Ui: ui}}
+
+	t.Run("environment", func(t *testing.T) {
+		defer resetUiWriters(ui)
+		out := filepath.Join(t.TempDir(), "out")
+		code := cmd.Run([]string{"-address=" + url, "app/db", "--",
+			"sh", "-c", `printf "%s:%s" "$user" "$pass" > ` + out})
+		require.Equal(t, 0, code, "stderr: %s", ui.ErrorWriter.String())
+
+		b, err := os.ReadFile(out)
+		require.NoError(t, err)
+		require.Equal(t, "admin:hunter2", string(b))
+	})
+
+	t.Run("exit code", func(t *testing.T) {
+		defer resetUiWriters(ui)
+		code := cmd.Run([]string{"-address=" + url, "app/db", "sh", "-c", "exit 3"})
+		require.Equal(t, 3, code, "stderr: %s", ui.ErrorWriter.String())
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		defer resetUiWriters(ui)
+		code := cmd.Run([]string{"-address=" + url, "app/missing", "true"})
+		require.Equal(t, 1, code)
+		require.Contains(t, ui.ErrorWriter.String(), msgSecureVariableNotFound)
+	})
+}
+
+func TestVarExecEnv(t *testing.T) {
+	ci.Parallel(t)
+
+	env := varExecEnv([]string{"HOME=/root", "user=nobody", "EMPTY"},
+		api.SecureVariableItems{"user": "admin", "pass": "hunter2"})
+	require.Equal(t, []string{"HOME=/root", "EMPTY", "pass=hunter2", "user=admin"}, env)
+}
@@ -0,0 +1,146 @@
+package command
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/hashicorp/nomad/api"
+	"github.com/posener/complete"
+)
+
+// varBundle is the file format written by var export and read by var import.
+type varBundle struct {
+	Variables []*varBundleEntry
+}
+
+type varBundleEntry struct {
+	Namespace string
+	Path      string
+	Items     api.SecureVariableItems
+}
+
+type VarExportCommand struct {
+	Meta
+}
+
+func (c *VarExportCommand) Help() string {
+	helpText := `
+Usage: nomad var export [options] [<prefix>]
+
+  Export writes every secure variable whose path starts with the prefix to a
+  single JSON bundle, including the items of each variable. The bundle can be
+  restored to this or another cluster with "nomad var import". Use
+  "-namespace=*" to export the matching variables of all namespaces.
+
+  The bundle holds the items in plaintext. It is written with permissions
+  that only allow the current user to read it, but should otherwise be
+  protected like any other secret, for example by encrypting it at rest.
+
+  If ACLs are enabled, this command requires a token with the 'list' and
+  'read' capabilities on the exported paths.
+
+General Options:
+
+  ` + generalOptionsUsage(usageOptsDefault) + `
+
+Export Options:
+
+  -out=<file>
+    Write the bundle to this file instead of stdout. An existing file is
+    overwritten.
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *VarExportCommand) AutocompleteFlags() complete.Flags {
+	return mergeAutocompleteFlags(c.Meta.AutocompleteFlags(FlagSetClient),
+		complete.Flags{
+			"-out": complete.PredictFiles("*.json"),
+		},
+	)
+}
+
+func (c *VarExportCommand) AutocompleteArgs() complete.Predictor {
+	return SecureVariablePathPredictor(c.Meta.Client)
+}
+
+func (c *VarExportCommand) Synopsis() string {
+	return "Export secure variables to a bundle"
+}
+
+func (c *VarExportCommand) Name() string { return "var export" }
+
+func (c *VarExportCommand) Run(args []string) int {
+	var out string
+
+	flags := c.Meta.FlagSet(c.Name(), FlagSetClient)
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	flags.StringVar(&out, "out", "", "")
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	// Check that we got zero or one argument
+	args = flags.Args()
+	if l := len(args); l > 1 {
+		c.Ui.Error("This command takes flags and either no arguments or one: <prefix>")
+		c.Ui.Error(commandErrorText(c))
+		return 1
+	}
+	prefix := ""
+	if len(args) == 1 {
+		prefix = args[0]
+	}
+
+	// Get the HTTP client
+	client, err := c.Meta.Client()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error initializing client: %s", err))
+		return 1
+	}
+
+	vars, err := readVarsByPrefix(client, prefix)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return 1
+	}
+
+	bundle := &varBundle{Variables: make([]*varBundleEntry, len(vars))}
+	for i, sv := range vars {
+		bundle.Variables[i] = &varBundleEntry{
+			Namespace: sv.Namespace,
+			Path:      sv.Path,
+			Items:     sv.Items,
+		}
+	}
+	buf, err := json.MarshalIndent(bundle, "", "    ")
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error encoding bundle: %s", err))
+		return 1
+	}
+
+	if out == "" {
+		c.Ui.Output(string(buf))
+		return 0
+	}
+
+	f, err := os.OpenFile(out, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error opening %s: %s", out, err))
+		return 1
+	}
+	_, err = f.Write(append(buf, '\n'))
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error writing %s: %s", out, err))
+		return 1
+	}
+
+	c.Ui.Output(fmt.Sprintf("Exported %d secure variable(s) to %s", len(vars), out))
+	return 0
+}
@@ -0,0 +1,68 @@
+package command
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/nomad/api"
+	"github.com/hashicorp/nomad/ci"
+	"github.com/mitchellh/cli"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVarExportCommand_Implements(t *testing.T) {
+	ci.Parallel(t)
+	var _ cli.Command = &VarExportCommand{}
+}
+
+func TestVarExportCommand_Online(t *testing.T) {
+	ci.Parallel(t)
+
+	srv, client, url := testServer(t, false, nil)
+	defer srv.Shutdown()
+
+	_, err := client.Namespaces().Register(&api.Namespace{Name: "ns1"}, nil)
+	require.NoError(t, err)
+	for _, sv := range []*api.SecureVariable{
+		{Namespace: api.DefaultNamespace, Path: "app/db", Items: map[string]string{"pass": "s3cret"}},
+		{Namespace: "ns1", Path: "app/web", Items: map[string]string{"port": "8080"}},
+		{Namespace: api.DefaultNamespace, Path: "other", Items: map[string]string{"k": "v"}},
+	} {
+		_, err := client.SecureVariables().Create(sv, &api.WriteOptions{Namespace: sv.Namespace})
+		require.NoError(t, err)
+	}
+
+	ui := cli.NewMockUi()
+	cmd := &VarExportCommand{Meta: Meta{Ui: ui}}
+
+	t.Run("stdout", func(t *testing.T) {
+		defer resetUiWriters(ui)
+		code := cmd.Run([]string{"-address=" + url, "-namespace=*", "app"})
+		require.Equal(t, 0, code, "stderr: %s", ui.ErrorWriter.String())
+
+		var bundle varBundle
+		require.NoError(t, json.Unmarshal(ui.OutputWriter.Bytes(), &bundle))
+		require.Equal(t, []*varBundleEntry{
+			{Namespace: api.DefaultNamespace, Path: "app/db", Items: api.SecureVariableItems{"pass": "s3cret"}},
+			{Namespace: "ns1", Path: "app/web", Items: api.SecureVariableItems{"port": "8080"}},
+		}, bundle.Variables)
+	})
+
+	t.Run("file", func(t *testing.T) {
+		defer resetUiWriters(ui)
+		out := filepath.Join(t.TempDir(), "bundle.json")
+		code := cmd.Run([]string{"-address=" + url, "-out=" + out})
+		require.Equal(t, 0, code, "stderr: %s", ui.ErrorWriter.String())
+		require.Contains(t, ui.OutputWriter.String(), "Exported 2 secure variable(s)")
+
+		fi, err := os.Stat(out)
+		require.NoError(t, err)
+		require.Equal(t, os.FileMode(0600), fi.Mode().Perm())
+
+		vars, err := readVarBundle(out, nil)
+		require.NoError(t, err)
+		require.Len(t, vars, 2)
+	})
+}
@@ -0,0 +1,131 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/nomad/api"
+	"github.com/posener/complete"
+)
+
+type VarHistoryCommand struct {
+	Meta
+}
+
+func (c *VarHistoryCommand) Help() string {
+	helpText := `
+Usage: nomad var history [options] <path>
+
+  History lists the versions of a secure variable, newest first. Each version
+  is identified by the modify index it was written at. The current version is
+  followed by the previous versions kept by the servers, which can be restored
+  with "nomad var rollback".
+
+  Previous versions are removed when the secure variable is deleted, and when
+  the root key they were encrypted with is removed after a key rotation.
+
+  If ACLs are enabled, this command requires a token with the 'read'
+  capability for the path.
+
+General Options:
+
+  ` + generalOptionsUsage(usageOptsDefault) + `
+
+History Options:
+
+  -json
+    Output the versions in JSON format.
+
+  -t
+    Format and display the versions using a Go template.
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *VarHistoryCommand) AutocompleteFlags() complete.Flags {
+	return mergeAutocompleteFlags(c.Meta.AutocompleteFlags(FlagSetClient),
+		complete.Flags{
+			"-json": complete.PredictNothing,
+			"-t":    complete.PredictAnything,
+		},
+	)
+}
+
+func (c *VarHistoryCommand) AutocompleteArgs() complete.Predictor {
+	return SecureVariablePathPredictor(c.Meta.Client)
+}
+
+func (c *VarHistoryCommand) Synopsis() string {
+	return "List the versions of a secure variable"
+}
+
+func (c *VarHistoryCommand) Name() string { return "var history" }
+
+func (c *VarHistoryCommand) Run(args []string) int {
+	var json bool
+	var tmpl string
+
+	flags := c.Meta.FlagSet(c.Name(), FlagSetClient)
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	flags.BoolVar(&json, "json", false, "")
+	flags.StringVar(&tmpl, "t", "", "")
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	// Check that we got exactly one argument
+	args = flags.Args()
+	if l := len(args); l != 1 {
+		c.Ui.Error("This command takes one argument: <path>")
+		c.Ui.Error(commandErrorText(c))
+		return 1
+	}
+	path := args[0]
+
+	// Get the HTTP client
+	client, err := c.Meta.Client()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error initializing client: %s", err))
+		return 1
+	}
+
+	versions, _, err := client.SecureVariables().History(path, nil)
+	if err != nil {
+		if strings.Contains(err.Error(), "404") {
+			c.Ui.Error(msgSecureVariableNotFound)
+		} else {
+			c.Ui.Error(fmt.Sprintf("Error retrieving secure variable history: %s", err))
+		}
+		return 1
+	}
+
+	if json || len(tmpl) > 0 {
+		out, err := Format(json, tmpl, versions)
+		if err != nil {
+			c.Ui.Error(err.Error())
+			return 1
+		}
+		c.Ui.Output(out)
+		return 0
+	}
+
+	c.Ui.Output(formatVarHistory(versions))
+	return 0
+}
+
+// formatVarHistory formats the versions of a secure variable, which the
+// server returns with the current version first.
+func formatVarHistory(versions []*api.SecureVariableMetadata) string {
+	rows := make([]string, len(versions)+1)
+	rows[0] = "Version|Current|Last Updated"
+	for i, sv := range versions {
+		rows[i+1] = fmt.Sprintf("%d|%t|%s",
+			sv.ModifyIndex,
+			i == 0,
+			time.Unix(0, sv.ModifyTime),
+		)
+	}
+	return formatList(rows)
+}
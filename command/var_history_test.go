@@ -0,0 +1,79 @@
+package command
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/nomad/api"
+	"github.com/hashicorp/nomad/ci"
+	"github.com/mitchellh/cli"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVarHistoryCommand_Implements(t *testing.T) {
+	ci.Parallel(t)
+	var _ cli.Command = &VarHistoryCommand{}
+}
+
+func TestVarHistoryCommand_Offline(t *testing.T) {
+	ci.Parallel(t)
+	ui := cli.NewMockUi()
+	cmd := &VarHistoryCommand{Meta: Meta{Ui: ui}}
+
+	code := cmd.Run([]string{"one", "two"})
+	require.Equal(t, 1, code)
+	require.Contains(t, ui.ErrorWriter.String(), "This command takes one argument")
+}
+
+func TestVarHistoryCommand_Online(t *testing.T) {
+	ci.Parallel(t)
+
+	srv, client, url := testServer(t, false, nil)
+	defer srv.Shutdown()
+
+	sv := &api.SecureVariable{Path: "app/db", Items: map[string]string{"pass": "one"}}
+	_, err := client.SecureVariables().Create(sv, nil)
+	require.NoError(t, err)
+	first, _, err := client.SecureVariables().Read(sv.Path, nil)
+	require.NoError(t, err)
+
+	first.Items = map[string]string{"pass": "two"}
+	_, err = client.SecureVariables().CheckedUpdate(first.Copy(), nil)
+	require.NoError(t, err)
+	current, _, err := client.SecureVariables().Read(sv.Path, nil)
+	require.NoError(t, err)
+
+	ui := cli.NewMockUi()
+	cmd := &VarHistoryCommand{Meta: Meta{Ui: ui}}
+
+	t.Run("missing", func(t *testing.T) {
+		defer resetUiWriters(ui)
+		code := cmd.Run([]string{"-address=" + url, "does/not/exist"})
+		require.Equal(t, 1, code)
+		require.Contains(t, ui.ErrorWriter.String(), msgSecureVariableNotFound)
+	})
+
+	t.Run("table", func(t *testing.T) {
+		defer resetUiWriters(ui)
+		code := cmd.Run([]string{"-address=" + url, sv.Path})
+		require.Equal(t, 0, code, "stderr: %s", ui.ErrorWriter.String())
+
+		out := ui.OutputWriter.String()
+		require.Contains(t, out, "Version")
+		require.Regexp(t, fmt.Sprintf(`%d\s+true`, current.ModifyIndex), out)
+		require.Regexp(t, fmt.Sprintf(`%d\s+false`, first.ModifyIndex), out)
+	})
+
+	t.Run("json", func(t *testing.T) {
+		defer resetUiWriters(ui)
+		code := cmd.Run([]string{"-address=" + url, "-json", sv.Path})
+		require.Equal(t, 0, code, "stderr: %s", ui.ErrorWriter.String())
+
+		var versions []*api.SecureVariableMetadata
+		require.NoError(t, json.Unmarshal(ui.OutputWriter.Bytes(), &versions))
+		require.Len(t, versions, 2)
+		require.Equal(t, current.ModifyIndex, versions[0].ModifyIndex)
+		require.Equal(t, first.ModifyIndex, versions[1].ModifyIndex)
+	})
+}
@@ -0,0 +1,201 @@
+package command
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/hashicorp/nomad/api"
+	"github.com/posener/complete"
+)
+
+type VarImportCommand struct {
+	Meta
+
+	// testStdin is the input for testing.
+	testStdin io.Reader
+}
+
+func (c *VarImportCommand) Help() string {
+	helpText := `
+Usage: nomad var import [options] <file>
+
+  Import restores the secure variables of a bundle written by "nomad var
+  export". If the file is "-", the bundle is read from stdin. Each variable is
+  written to the namespace and path it was exported from.
+
+  The cluster is compared against the bundle before anything is written.
+  Variables that don't exist are created, and those whose items already match
+  are left unchanged. Variables that exist with different items are conflicts
+  and are handled according to -on-conflict. When the strategy is "fail" and
+  a conflict is found, nothing is written.
+
+  If ACLs are enabled, this command requires a token with the 'read' and
+  'write' capabilities on the imported paths.
+
+General Options:
+
+  ` + generalOptionsUsage(usageOptsDefault|usageOptsNoNamespace) + `
+
+Import Options:
+
+  -on-conflict=<fail|skip|overwrite>
+    How to handle variables that exist with different items. "fail" aborts
+    without writing anything, "skip" leaves the existing variable in place,
+    and "overwrite" replaces it. Defaults to "fail".
+
+  -dry-run
+    Report what would be imported without writing anything.
+
+  -json
+    Output the per-path results in JSON format.
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *VarImportCommand) AutocompleteFlags() complete.Flags {
+	return mergeAutocompleteFlags(c.Meta.AutocompleteFlags(FlagSetClient),
+		complete.Flags{
+			"-on-conflict": complete.PredictSet(varConflictFail, varConflictSkip, varConflictOverwrite),
+			"-dry-run":     complete.PredictNothing,
+			"-json":        complete.PredictNothing,
+		},
+	)
+}
+
+func (c *VarImportCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictOr(
+		complete.PredictFiles("*.json"),
+		complete.PredictSet("-"),
+	)
+}
+
+func (c *VarImportCommand) Synopsis() string {
+	return "Import secure variables from a bundle"
+}
+
+func (c *VarImportCommand) Name() string { return "var import" }
+
+func (c *VarImportCommand) Run(args []string) int {
+	var dryRun, json bool
+	var onConflict string
+
+	flags := c.Meta.FlagSet(c.Name(), FlagSetClient)
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	flags.StringVar(&onConflict, "on-conflict", varConflictFail, "")
+	flags.BoolVar(&dryRun, "dry-run", false, "")
+	flags.BoolVar(&json, "json", false, "")
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	// Check that we got exactly one argument
+	args = flags.Args()
+	if l := len(args); l != 1 {
+		c.Ui.Error("This command takes one argument: <file>")
+		c.Ui.Error(commandErrorText(c))
+		return 1
+	}
+
+	switch onConflict {
+	case varConflictFail, varConflictSkip, varConflictOverwrite:
+	default:
+		c.Ui.Error(fmt.Sprintf("Invalid -on-conflict value %q; must be one of fail, skip, or overwrite", onConflict))
+		c.Ui.Error(commandErrorText(c))
+		return 1
+	}
+
+	var r io.Reader = os.Stdin
+	if c.testStdin != nil {
+		r = c.testStdin
+	}
+	vars, err := readVarBundle(args[0], r)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return 1
+	}
+
+	// Get the HTTP client
+	client, err := c.Meta.Client()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error initializing client: %s", err))
+		return 1
+	}
+
+	results, conflicts, err := planVarWrites(client, vars, "", onConflict)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return 1
+	}
+	code := applyVarWrites(c.Ui, client, results, conflicts, dryRun)
+
+	if json {
+		out, err := Format(true, "", results)
+		if err != nil {
+			c.Ui.Error(err.Error())
+			return 1
+		}
+		c.Ui.Output(out)
+	} else if len(results) == 0 {
+		c.Ui.Output("No secure variables found in the bundle")
+	} else {
+		c.Ui.Output(formatVarImportResults(results))
+	}
+	return code
+}
+
+// readVarBundle reads and validates a bundle from the file, or from r if the
+// file is "-".
+func readVarBundle(file string, r io.Reader) ([]*api.SecureVariable, error) {
+	var buf []byte
+	var err error
+	if file == "-" {
+		buf, err = io.ReadAll(r)
+	} else {
+		buf, err = os.ReadFile(file)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Error reading bundle: %s", err)
+	}
+
+	var bundle varBundle
+	if err := json.Unmarshal(buf, &bundle); err != nil {
+		return nil, fmt.Errorf("Error parsing bundle; expected the output of `nomad var export`: %s", err)
+	}
+
+	vars := make([]*api.SecureVariable, len(bundle.Variables))
+	for i, e := range bundle.Variables {
+		if e == nil || e.Path == "" {
+			return nil, fmt.Errorf("Error parsing bundle: variable %d has no path", i)
+		}
+		ns := e.Namespace
+		switch ns {
+		case "":
+			ns = api.DefaultNamespace
+		case api.AllNamespacesNamespace:
+			return nil, fmt.Errorf("Error parsing bundle: variable %q is in the wildcard (\"*\") namespace", e.Path)
+		}
+		vars[i] = &api.SecureVariable{
+			Namespace: ns,
+			Path:      e.Path,
+			Items:     e.Items,
+		}
+	}
+	return vars, nil
+}
+
+func formatVarImportResults(results []*varReplicateResult) string {
+	rows := make([]string, len(results)+1)
+	rows[0] = "Namespace|Path|Result"
+	for i, r := range results {
+		result := r.Result
+		if r.Error != "" {
+			result = fmt.Sprintf("%s: %s", r.Result, r.Error)
+		}
+		rows[i+1] = fmt.Sprintf("%s|%s|%s", r.Namespace, r.Path, result)
+	}
+	return formatList(rows)
+}
@@ -0,0 +1,146 @@
+package command
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/nomad/api"
+	"github.com/hashicorp/nomad/ci"
+	"github.com/mitchellh/cli"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVarImportCommand_Implements(t *testing.T) {
+	ci.Parallel(t)
+	var _ cli.Command = &VarImportCommand{}
+}
+
+func TestVarImportCommand_Offline(t *testing.T) {
+	ci.Parallel(t)
+	ui := cli.NewMockUi()
+	cmd := &VarImportCommand{Meta: Meta{Ui: ui}}
+
+	testCases := []struct {
+		name      string
+		args      []string
+		stdin     string
+		expectErr string
+	}{
+		{
+			name:      "no args",
+			args:      []string{},
+			expectErr: "This command takes one argument",
+		},
+		{
+			name:      "bad conflict strategy",
+			args:      []string{"-on-conflict=merge", "-"},
+			expectErr: `Invalid -on-conflict value "merge"`,
+		},
+		{
+			name:      "bad bundle",
+			args:      []string{"-"},
+			stdin:     `[]`,
+			expectErr: "Error parsing bundle",
+		},
+		{
+			name:      "missing path",
+			args:      []string{"-"},
+			stdin:     `{"Variables": [{"Namespace": "default"}]}`,
+			expectErr: "variable 0 has no path",
+		},
+		{
+			name:      "wildcard namespace",
+			args:      []string{"-"},
+			stdin:     `{"Variables": [{"Namespace": "*", "Path": "a"}]}`,
+			expectErr: "wildcard",
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			defer resetUiWriters(ui)
+			cmd.testStdin = strings.NewReader(tC.stdin)
+			code := cmd.Run(tC.args)
+			require.Equal(t, 1, code)
+			require.Contains(t, ui.ErrorWriter.String(), tC.expectErr)
+		})
+	}
+}
+
+func TestVarImportCommand_Online(t *testing.T) {
+	ci.Parallel(t)
+
+	srv, client, url := testServer(t, false, nil)
+	defer srv.Shutdown()
+
+	_, err := client.SecureVariables().Create(&api.SecureVariable{
+		Path: "app/cache", Items: map[string]string{"url": "redis://old"}}, nil)
+	require.NoError(t, err)
+
+	bundle := filepath.Join(t.TempDir(), "bundle.json")
+	require.NoError(t, os.WriteFile(bundle, []byte(`{
+  "Variables": [
+    {"Namespace": "default", "Path": "app/cache", "Items": {"url": "redis://cache"}},
+    {"Path": "app/db", "Items": {"pass": "s3cret"}}
+  ]
+}`), 0600))
+
+	readItems := func(path string) api.SecureVariableItems {
+		sv, _, err := client.SecureVariables().Read(path, nil)
+		require.NoError(t, err)
+		return sv.Items
+	}
+
+	ui := cli.NewMockUi()
+	cmd := &VarImportCommand{Meta: Meta{Ui: ui}}
+
+	decode := func(t *testing.T) map[string]string {
+		var results []*varReplicateResult
+		require.NoError(t, json.Unmarshal(ui.OutputWriter.Bytes(), &results))
+		out := make(map[string]string)
+		for _, r := range results {
+			out[r.Path] = r.Result
+		}
+		return out
+	}
+
+	t.Run("conflict fails without writing", func(t *testing.T) {
+		defer resetUiWriters(ui)
+		code := cmd.Run([]string{"-address=" + url, "-json", bundle})
+		require.Equal(t, 1, code)
+		require.Contains(t, ui.ErrorWriter.String(), "nothing was written")
+		require.Equal(t, map[string]string{
+			"app/cache": varReplicateConflict,
+			"app/db":    varReplicateCreated,
+		}, decode(t))
+
+		sv, _, err := client.SecureVariables().Peek("app/db", nil)
+		require.NoError(t, err)
+		require.Nil(t, sv)
+	})
+
+	t.Run("skip", func(t *testing.T) {
+		defer resetUiWriters(ui)
+		code := cmd.Run([]string{"-address=" + url, "-on-conflict=skip", bundle})
+		require.Equal(t, 0, code, "stderr: %s", ui.ErrorWriter.String())
+		require.Contains(t, ui.OutputWriter.String(), varReplicateSkipped)
+		require.Equal(t, "s3cret", readItems("app/db")["pass"])
+		require.Equal(t, "redis://old", readItems("app/cache")["url"])
+	})
+
+	t.Run("overwrite from stdin", func(t *testing.T) {
+		defer resetUiWriters(ui)
+		buf, err := os.ReadFile(bundle)
+		require.NoError(t, err)
+		cmd.testStdin = strings.NewReader(string(buf))
+		code := cmd.Run([]string{"-address=" + url, "-on-conflict=overwrite", "-json", "-"})
+		require.Equal(t, 0, code, "stderr: %s", ui.ErrorWriter.String())
+		require.Equal(t, map[string]string{
+			"app/cache": varReplicateUpdated,
+			"app/db":    varReplicateUnchanged,
+		}, decode(t))
+		require.Equal(t, "redis://cache", readItems("app/cache")["url"])
+	})
+}
@@ -1,18 +1,26 @@
 package command
 
 import (
+	"encoding/json"
 	"fmt"
+	"os"
 	"sort"
 	"strings"
 	"time"
 
+	humanize "github.com/dustin/go-humanize"
 	"github.com/hashicorp/nomad/api"
 	"github.com/posener/complete"
+	"github.com/ryanuber/columnize"
 )
 
 const (
 	msgSecureVariableNotFound = "No matching secure variables found"
 	msgWarnFilterPerformance  = "Filter queries require a full scan of the data; use prefix searching where possible"
+
+	// jobVarsPathPrefix is the path prefix under which secure variables are
+	// implicitly accessible to the job named in the next path segment.
+	jobVarsPathPrefix = "jobs/"
 )
 
 type VarListCommand struct {
@@ -27,6 +35,9 @@ Usage: nomad var list [options] <prefix>
   List is used to list available secure variables. Supplying an optional prefix,
   filters the list to variables having a path starting with the prefix.
 
+  Expired secure variables are not listed. If any of the listed secure
+  variables has an expire time, the time left until each expires is shown.
+
   If ACLs are enabled, this command will return only secure variables stored at
   namespaced paths where the token has the ` + "`read`" + ` capability.
 
@@ -56,6 +67,54 @@ List Options:
   -q
     Output matching secure variable paths with no additional information.
     This option overrides the ` + "`-t`" + ` option.
+
+  -group-by-namespace
+    Group the secure variables by namespace, with a header and count for each
+    namespace, rather than showing a namespace column. This is most useful
+    when listing across all namespaces with ` + "`-namespace=*`" + `. In JSON
+    mode, the results are nested under their namespace. This option has no
+    effect on template or plain quiet output.
+
+  -orphans
+    Only list secure variables stored under a "jobs/<job ID>" path whose job
+    no longer exists. When no prefix is given, the "jobs/" prefix is used.
+    Variables whose job can not be read with the current token are skipped
+    with a warning. This option can not be combined with pagination.
+
+  -purge-orphans
+    Delete the orphaned secure variables after listing them. Implies
+    ` + "`-orphans`" + `. Each variable is deleted with a check-and-set against
+    the index it was listed at, so variables modified in the meantime are
+    left in place.
+
+  -yes
+    Automatic yes to prompts.
+
+  -since-snapshot=<file>
+    Compare the secure variables against a snapshot previously saved from
+    ` + "`nomad var list -json`" + ` and only list those created or modified since
+    it was taken, as determined by their modify index. Variables present in
+    the snapshot, within the listed namespace and prefix, but absent from the
+    cluster are reported separately as deleted: after the table in the
+    default output, on stderr with ` + "`-q`" + `, and under the "Deleted" key
+    in JSON. This option can not be combined with pagination, -filter,
+    -orphans, or -group-by-namespace.
+
+  -only-empty-namespaces
+    List the namespaces that contain no secure variables under the prefix,
+    instead of listing secure variables. Every namespace visible to the
+    current token is checked, one at a time, regardless of -namespace.
+    Namespaces whose secure variables can not be listed with the current
+    token are skipped with a warning. This option can not be combined with
+    pagination, -orphans, -since-snapshot, or -group-by-namespace.
+
+  -tree
+    List the secure variables as a tree of their path segments, showing the
+    number of items, size, modify index, and create time of each. Pagination
+    applies to the secure variables rather than the segments, so each page is
+    a tree of its own. This option requires the ` + "`read`" + ` capability and
+    can not be combined with -q, -filter, -orphans, -since-snapshot,
+    -only-empty-namespaces, -group-by-namespace, or the wildcard namespace.
 `
 	return strings.TrimSpace(helpText)
 }
@@ -63,14 +122,21 @@ List Options:
 func (c *VarListCommand) AutocompleteFlags() complete.Flags {
 	return mergeAutocompleteFlags(c.Meta.AutocompleteFlags(FlagSetClient),
 		complete.Flags{
-			"-json": complete.PredictNothing,
-			"-t":    complete.PredictAnything,
+			"-json":                  complete.PredictNothing,
+			"-t":                     complete.PredictAnything,
+			"-group-by-namespace":    complete.PredictNothing,
+			"-orphans":               complete.PredictNothing,
+			"-purge-orphans":         complete.PredictNothing,
+			"-yes":                   complete.PredictNothing,
+			"-since-snapshot":        complete.PredictFiles("*.json"),
+			"-only-empty-namespaces": complete.PredictNothing,
+			"-tree":                  complete.PredictNothing,
 		},
 	)
 }
 
 func (c *VarListCommand) AutocompleteArgs() complete.Predictor {
-	return complete.PredictNothing
+	return SecureVariablePathPredictor(c.Meta.Client)
 }
 
 func (c *VarListCommand) Synopsis() string {
@@ -79,9 +145,9 @@ func (c *VarListCommand) Synopsis() string {
 
 func (c *VarListCommand) Name() string { return "var list" }
 func (c *VarListCommand) Run(args []string) int {
-	var json, quiet bool
+	var json, quiet, groupByNS, orphans, purgeOrphans, autoYes, emptyNS, tree bool
 	var perPage int
-	var tmpl, pageToken, filter, prefix string
+	var tmpl, pageToken, filter, prefix, sinceSnapshot string
 
 	flags := c.Meta.FlagSet(c.Name(), FlagSetClient)
 	flags.Usage = func() { c.Ui.Output(c.Help()) }
@@ -91,6 +157,13 @@ func (c *VarListCommand) Run(args []string) int {
 	flags.IntVar(&perPage, "per-page", 0, "")
 	flags.StringVar(&pageToken, "page-token", "", "")
 	flags.StringVar(&filter, "filter", "", "")
+	flags.BoolVar(&groupByNS, "group-by-namespace", false, "")
+	flags.BoolVar(&orphans, "orphans", false, "")
+	flags.BoolVar(&purgeOrphans, "purge-orphans", false, "")
+	flags.BoolVar(&autoYes, "yes", false, "")
+	flags.StringVar(&sinceSnapshot, "since-snapshot", "", "")
+	flags.BoolVar(&emptyNS, "only-empty-namespaces", false, "")
+	flags.BoolVar(&tree, "tree", false, "")
 
 	if err := flags.Parse(args); err != nil {
 		return 1
@@ -108,6 +181,46 @@ func (c *VarListCommand) Run(args []string) int {
 		prefix = args[0]
 	}
 
+	orphans = orphans || purgeOrphans
+	if orphans {
+		if perPage > 0 || pageToken != "" {
+			c.Ui.Error("The -orphans and -purge-orphans flags can not be combined with pagination")
+			c.Ui.Error(commandErrorText(c))
+			return 1
+		}
+		if prefix == "" {
+			prefix = jobVarsPathPrefix
+		}
+	}
+
+	if emptyNS && (perPage > 0 || pageToken != "" || orphans || sinceSnapshot != "" || groupByNS) {
+		c.Ui.Error("The -only-empty-namespaces flag can not be combined with pagination, -orphans, -since-snapshot, or -group-by-namespace")
+		c.Ui.Error(commandErrorText(c))
+		return 1
+	}
+
+	if tree && (quiet || filter != "" || orphans || sinceSnapshot != "" || emptyNS || groupByNS) {
+		c.Ui.Error("The -tree flag can not be combined with -q, -filter, -orphans, -since-snapshot, -only-empty-namespaces, or -group-by-namespace")
+		c.Ui.Error(commandErrorText(c))
+		return 1
+	}
+
+	var snapshot []*api.SecureVariableMetadata
+	if sinceSnapshot != "" {
+		if perPage > 0 || pageToken != "" || filter != "" || orphans || groupByNS {
+			c.Ui.Error("The -since-snapshot flag can not be combined with pagination, -filter, -orphans, or -group-by-namespace")
+			c.Ui.Error(commandErrorText(c))
+			return 1
+		}
+
+		var err error
+		snapshot, err = loadVarListSnapshot(sinceSnapshot)
+		if err != nil {
+			c.Ui.Error(err.Error())
+			return 1
+		}
+	}
+
 	// Get the HTTP client
 	client, err := c.Meta.Client()
 	if err != nil {
@@ -119,6 +232,14 @@ func (c *VarListCommand) Run(args []string) int {
 		c.Ui.Warn(msgWarnFilterPerformance)
 	}
 
+	if emptyNS {
+		return c.outputEmptyNamespaces(client, prefix, filter, json, quiet, tmpl)
+	}
+
+	if tree {
+		return c.outputTree(client, prefix, perPage, pageToken, json, tmpl)
+	}
+
 	qo := &api.QueryOptions{
 		Filter:    filter,
 		PerPage:   int32(perPage),
@@ -132,6 +253,19 @@ func (c *VarListCommand) Run(args []string) int {
 		return 1
 	}
 
+	if orphans {
+		vars, err = c.orphanedVars(client, vars)
+		if err != nil {
+			c.Ui.Error(err.Error())
+			return 1
+		}
+	}
+
+	if sinceSnapshot != "" {
+		diff := diffVarListSnapshot(vars, snapshot, c.Meta.clientConfig().Namespace, prefix)
+		return c.outputSnapshotDiff(diff, json, quiet, tmpl)
+	}
+
 	switch {
 	case json:
 
@@ -141,7 +275,14 @@ func (c *VarListCommand) Run(args []string) int {
 		obj = vars
 		items = vars
 
-		if quiet {
+		switch {
+		case groupByNS && quiet:
+			items = dataToQuietGroupedJSONReadyMap(vars)
+			obj = items
+		case groupByNS:
+			items = groupVarStubsByNamespace(vars)
+			obj = items
+		case quiet:
 			items = dataToQuietJSONReadySlice(vars, c.Meta.namespace)
 			obj = items
 		}
@@ -173,6 +314,9 @@ func (c *VarListCommand) Run(args []string) int {
 
 		// Since the JSON formatting deals with the pagination information
 		// itself, exit the command here so that it doesn't double print.
+		if purgeOrphans {
+			return c.purgeOrphans(client, vars, autoYes)
+		}
 		return 0
 
 	case quiet:
@@ -189,6 +333,9 @@ func (c *VarListCommand) Run(args []string) int {
 
 		c.Ui.Output(out)
 
+	case groupByNS:
+		c.Ui.Output(formatVarStubsGroupedByNamespace(vars))
+
 	default:
 		c.Ui.Output(formatVarStubs(vars))
 	}
@@ -200,9 +347,298 @@ func (c *VarListCommand) Run(args []string) int {
 		c.Ui.Warn(fmt.Sprintf("Next page token: %s", qm.NextToken))
 	}
 
+	if purgeOrphans {
+		return c.purgeOrphans(client, vars, autoYes)
+	}
 	return 0
 }
 
+// outputEmptyNamespaces lists the namespaces holding no secure variables
+// under the prefix. Namespaces are checked sequentially, requesting a single
+// result from each, to keep the load on the servers low.
+func (c *VarListCommand) outputEmptyNamespaces(client *api.Client, prefix, filter string, json, quiet bool, tmpl string) int {
+	namespaces, _, err := client.Namespaces().List(nil)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error retrieving namespaces: %s", err))
+		return 1
+	}
+
+	empty := make([]string, 0, len(namespaces))
+	for _, ns := range namespaces {
+		vars, _, err := client.SecureVariables().PrefixList(prefix, &api.QueryOptions{
+			Namespace: ns.Name,
+			Filter:    filter,
+			PerPage:   1,
+		})
+		if err != nil {
+			if strings.Contains(err.Error(), "403") {
+				c.Ui.Warn(fmt.Sprintf("Skipping namespace %q: permission denied listing secure variables", ns.Name))
+				continue
+			}
+			c.Ui.Error(fmt.Sprintf("Error retrieving vars in namespace %q: %s", ns.Name, err))
+			return 1
+		}
+		if len(vars) == 0 {
+			empty = append(empty, ns.Name)
+		}
+	}
+	sort.Strings(empty)
+
+	switch {
+	case json || len(tmpl) > 0:
+		out, err := Format(json, tmpl, empty)
+		if err != nil {
+			c.Ui.Error(err.Error())
+			return 1
+		}
+		c.Ui.Output(out)
+
+	case quiet:
+		c.Ui.Output(strings.Join(empty, "\n"))
+
+	case len(empty) == 0:
+		c.Ui.Output("No empty namespaces found")
+
+	default:
+		c.Ui.Output(formatList(append([]string{"Namespace"}, empty...)))
+	}
+	return 0
+}
+
+// outputTree lists the secure variables under the prefix as a tree of path
+// segments.
+func (c *VarListCommand) outputTree(client *api.Client, prefix string, perPage int, pageToken string, json bool, tmpl string) int {
+	if c.Meta.clientConfig().Namespace == api.AllNamespacesNamespace {
+		c.Ui.Error("Secure variables can not be listed as a tree in the wildcard (\"*\") namespace")
+		return 1
+	}
+
+	root, qm, err := client.SecureVariables().Tree(prefix, &api.QueryOptions{
+		PerPage:   int32(perPage),
+		NextToken: pageToken,
+	})
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error retrieving vars: %s", err))
+		return 1
+	}
+
+	if json || len(tmpl) > 0 {
+		var obj interface{} = root
+		if json && perPage > 0 {
+			obj = struct {
+				Data      interface{}
+				QueryMeta *api.QueryMeta
+			}{
+				root,
+				qm,
+			}
+		}
+		out, err := Format(json, tmpl, obj)
+		if err != nil {
+			c.Ui.Error(err.Error())
+			return 1
+		}
+		c.Ui.Output(out)
+		if json {
+			return 0
+		}
+	} else {
+		c.Ui.Output(formatVarTree(root))
+	}
+
+	if qm.NextToken != "" {
+		c.Ui.Warn(fmt.Sprintf("Next page token: %s", qm.NextToken))
+	}
+	return 0
+}
+
+// formatVarTree formats the tree as a table, indenting each path segment
+// under its parent. Segments that do not hold a secure variable themselves
+// are shown with a trailing slash.
+func formatVarTree(root *api.SecureVariableTreeNode) string {
+	if len(root.Children) == 0 {
+		return msgSecureVariableNotFound
+	}
+
+	rows := []string{"Path|Items|Size|Modify Index|Created"}
+	var walk func(n *api.SecureVariableTreeNode, depth int)
+	walk = func(n *api.SecureVariableTreeNode, depth int) {
+		name := strings.Repeat("  ", depth) + n.Name
+		if v := n.Variable; v != nil {
+			rows = append(rows, fmt.Sprintf("%s|%d|%s|%d|%s",
+				name,
+				v.ItemCount,
+				humanize.IBytes(uint64(v.Size)),
+				v.ModifyIndex,
+				formatUnixNanoTime(v.CreateTime),
+			))
+		} else {
+			rows = append(rows, name+"/||||")
+		}
+		for _, child := range n.Children {
+			walk(child, depth+1)
+		}
+	}
+	for _, child := range root.Children {
+		walk(child, 0)
+	}
+
+	// The indentation is significant, so the columns are not trimmed
+	columnConf := columnize.DefaultConfig()
+	columnConf.Empty = "<none>"
+	columnConf.NoTrim = true
+	return columnize.Format(rows, columnConf)
+}
+
+// outputSnapshotDiff renders the result of comparing the listing against a
+// snapshot.
+func (c *VarListCommand) outputSnapshotDiff(diff *varListSnapshotDiff, json, quiet bool, tmpl string) int {
+	switch {
+	case json:
+		var obj interface{} = diff
+		if quiet {
+			obj = struct {
+				Changed interface{}
+				Deleted interface{}
+			}{
+				dataToQuietJSONReadySlice(diff.Changed, c.Meta.namespace),
+				dataToQuietJSONReadySlice(diff.Deleted, c.Meta.namespace),
+			}
+		}
+		out, err := Format(json, "", obj)
+		if err != nil {
+			c.Ui.Error(err.Error())
+			return 1
+		}
+		c.Ui.Output(out)
+
+	case quiet:
+		c.Ui.Output(
+			formatList(
+				dataToQuietStringSlice(diff.Changed, c.Meta.namespace)))
+		for _, p := range dataToQuietStringSlice(diff.Deleted, c.Meta.namespace) {
+			c.Ui.Warn(fmt.Sprintf("Deleted since snapshot: %s", p))
+		}
+
+	case len(tmpl) > 0:
+		out, err := Format(false, tmpl, diff)
+		if err != nil {
+			c.Ui.Error(err.Error())
+			return 1
+		}
+		c.Ui.Output(out)
+
+	default:
+		if len(diff.Changed) == 0 {
+			c.Ui.Output("No secure variables created or modified since the snapshot")
+		} else {
+			c.Ui.Output(formatVarStubs(diff.Changed))
+		}
+		if len(diff.Deleted) > 0 {
+			rows := make([]string, len(diff.Deleted)+1)
+			rows[0] = "Namespace|Path"
+			for i, sv := range diff.Deleted {
+				rows[i+1] = fmt.Sprintf("%s|%s", sv.Namespace, sv.Path)
+			}
+			c.Ui.Output(fmt.Sprintf("\nDeleted since snapshot\n%s", formatList(rows)))
+		}
+	}
+	return 0
+}
+
+// orphanedVars reduces vars to the job-scoped secure variables whose job can
+// no longer be found. Each job is only looked up once per namespace.
+func (c *VarListCommand) orphanedVars(client *api.Client, vars []*api.SecureVariableMetadata) ([]*api.SecureVariableMetadata, error) {
+	type nsJob struct {
+		Namespace string
+		JobID     string
+	}
+	jobGone := make(map[nsJob]bool)
+
+	out := make([]*api.SecureVariableMetadata, 0, len(vars))
+	for _, sv := range vars {
+		jobID, ok := jobIDFromVarPath(sv.Path)
+		if !ok {
+			continue
+		}
+
+		key := nsJob{sv.Namespace, jobID}
+		gone, seen := jobGone[key]
+		if !seen {
+			_, _, err := client.Jobs().Info(jobID, &api.QueryOptions{Namespace: sv.Namespace})
+			switch {
+			case err == nil:
+			case strings.Contains(err.Error(), "404"):
+				gone = true
+			case strings.Contains(err.Error(), "403"):
+				c.Ui.Warn(fmt.Sprintf("Skipping secure variables for job %q in namespace %q: permission denied reading job", jobID, sv.Namespace))
+			default:
+				return nil, fmt.Errorf("Error looking up job %q in namespace %q: %s", jobID, sv.Namespace, err)
+			}
+			jobGone[key] = gone
+		}
+
+		if gone {
+			out = append(out, sv)
+		}
+	}
+	return out, nil
+}
+
+// purgeOrphans deletes the listed orphaned secure variables after asking for
+// confirmation.
+func (c *VarListCommand) purgeOrphans(client *api.Client, vars []*api.SecureVariableMetadata, autoYes bool) int {
+	if len(vars) == 0 {
+		return 0
+	}
+
+	if !autoYes {
+		question := fmt.Sprintf("Are you sure you want to purge %d orphaned secure variable(s)? [y/N]", len(vars))
+		answer, err := c.Ui.Ask(question)
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Failed to parse answer: %v", err))
+			return 1
+		}
+
+		if answer == "" || strings.ToLower(answer)[0] == 'n' {
+			// No case
+			c.Ui.Output("Cancelling orphaned secure variable purge")
+			return 0
+		} else if strings.ToLower(answer)[0] == 'y' && len(answer) > 1 {
+			// Non exact match yes
+			c.Ui.Output("For confirmation, an exact ‘y’ is required.")
+			return 0
+		} else if answer != "y" {
+			c.Ui.Output("No confirmation detected. For confirmation, an exact 'y' is required.")
+			return 1
+		}
+	}
+
+	code := 0
+	for _, sv := range vars {
+		_, err := client.SecureVariables().CheckedDelete(sv.Path, sv.ModifyIndex,
+			&api.WriteOptions{Namespace: sv.Namespace})
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Error purging secure variable %q in namespace %q: %s", sv.Path, sv.Namespace, err))
+			code = 1
+			continue
+		}
+		c.Ui.Warn(fmt.Sprintf("Purged secure variable %q in namespace %q", sv.Path, sv.Namespace))
+	}
+	return code
+}
+
+// jobIDFromVarPath returns the job ID for secure variable paths that are
+// implicitly scoped to a job, of the form "jobs/<job ID>[/...]".
+func jobIDFromVarPath(path string) (string, bool) {
+	rest := strings.TrimPrefix(path, jobVarsPathPrefix)
+	if rest == path {
+		return "", false
+	}
+	jobID := strings.SplitN(rest, "/", 2)[0]
+	return jobID, jobID != ""
+}
+
 func formatVarStubs(vars []*api.SecureVariableMetadata) string {
 	if len(vars) == 0 {
 		return msgSecureVariableNotFound
@@ -216,18 +652,117 @@ func formatVarStubs(vars []*api.SecureVariableMetadata) string {
 		return vars[i].Namespace < vars[j].Namespace
 	})
 
+	expiring := varStubsExpire(vars)
 	rows := make([]string, len(vars)+1)
 	rows[0] = "Namespace|Path|Last Updated"
+	if expiring {
+		rows[0] += "|Expires In"
+	}
 	for i, sv := range vars {
 		rows[i+1] = fmt.Sprintf("%s|%s|%s",
 			sv.Namespace,
 			sv.Path,
 			time.Unix(0, sv.ModifyTime),
 		)
+		if expiring {
+			rows[i+1] += "|" + formatVarExpiresIn(sv.ExpireTime)
+		}
 	}
 	return formatList(rows)
 }
 
+// varStubsExpire returns whether any of the secure variables expires, in
+// which case the remaining time is shown for each of them.
+func varStubsExpire(vars []*api.SecureVariableMetadata) bool {
+	for _, sv := range vars {
+		if sv.ExpireTime != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// formatVarExpiresIn formats the time left until the expire time, in
+// UnixNanos, rounded to the second.
+func formatVarExpiresIn(expireTime int64) string {
+	if expireTime == 0 {
+		return "<none>"
+	}
+	left := time.Until(time.Unix(0, expireTime))
+	if left <= 0 {
+		return "expired"
+	}
+	return left.Round(time.Second).String()
+}
+
+func formatVarStubsGroupedByNamespace(vars []*api.SecureVariableMetadata) string {
+	if len(vars) == 0 {
+		return msgSecureVariableNotFound
+	}
+
+	groups := groupVarStubsByNamespace(vars)
+	namespaces := make([]string, 0, len(groups))
+	for ns := range groups {
+		namespaces = append(namespaces, ns)
+	}
+	sort.Strings(namespaces)
+
+	out := make([]string, len(namespaces))
+	for i, ns := range namespaces {
+		nsVars := groups[ns]
+		noun := "secure variables"
+		if len(nsVars) == 1 {
+			noun = "secure variable"
+		}
+
+		expiring := varStubsExpire(nsVars)
+		rows := make([]string, len(nsVars)+1)
+		rows[0] = "Path|Last Updated"
+		if expiring {
+			rows[0] += "|Expires In"
+		}
+		for j, sv := range nsVars {
+			rows[j+1] = fmt.Sprintf("%s|%s",
+				sv.Path,
+				time.Unix(0, sv.ModifyTime),
+			)
+			if expiring {
+				rows[j+1] += "|" + formatVarExpiresIn(sv.ExpireTime)
+			}
+		}
+		out[i] = fmt.Sprintf("Namespace: %s (%d %s)\n%s",
+			ns, len(nsVars), noun, formatList(rows))
+	}
+	return strings.Join(out, "\n\n")
+}
+
+// groupVarStubsByNamespace buckets the variables by their namespace. Each
+// bucket is sorted by path.
+func groupVarStubsByNamespace(vars []*api.SecureVariableMetadata) map[string][]*api.SecureVariableMetadata {
+	out := make(map[string][]*api.SecureVariableMetadata)
+	for _, sv := range vars {
+		out[sv.Namespace] = append(out[sv.Namespace], sv)
+	}
+	for _, nsVars := range out {
+		sort.Slice(nsVars, func(i, j int) bool {
+			return nsVars[i].Path < nsVars[j].Path
+		})
+	}
+	return out
+}
+
+func dataToQuietGroupedJSONReadyMap(vars []*api.SecureVariableMetadata) map[string][]string {
+	out := make(map[string][]string)
+	for ns, nsVars := range groupVarStubsByNamespace(vars) {
+		pList := make([]string, len(nsVars))
+		for i, sv := range nsVars {
+			pList[i] = sv.Path
+		}
+		out[ns] = pList
+	}
+	return out
+}
+
 func dataToQuietStringSlice(vars []*api.SecureVariableMetadata, ns string) []string {
 	// If ns is the wildcard namespace, we have to provide namespace
 	// as part of the quiet output, otherwise it can be a simple list
@@ -274,3 +809,81 @@ func dataToQuietJSONReadySlice(vars []*api.SecureVariableMetadata, ns string) in
 
 	return pList
 }
+
+// varListSnapshotDiff holds the secure variables that changed relative to a
+// snapshot of an earlier listing.
+type varListSnapshotDiff struct {
+	// Changed are the variables created or modified since the snapshot.
+	Changed []*api.SecureVariableMetadata
+
+	// Deleted are the variables in the snapshot that no longer exist, as
+	// recorded in the snapshot.
+	Deleted []*api.SecureVariableMetadata
+}
+
+// loadVarListSnapshot reads a snapshot saved from the JSON output of the var
+// list command.
+func loadVarListSnapshot(path string) ([]*api.SecureVariableMetadata, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Error reading snapshot: %s", err)
+	}
+
+	var out []*api.SecureVariableMetadata
+	if err := json.Unmarshal(b, &out); err != nil {
+		return nil, fmt.Errorf("Error parsing snapshot %q; expected the output of `nomad var list -json`: %s", path, err)
+	}
+	return out, nil
+}
+
+// diffVarListSnapshot compares the current listing with a snapshot. A
+// variable is changed if it is absent from the snapshot or has a higher
+// modify index than recorded. Snapshot entries outside of the namespace and
+// prefix of the current listing are ignored for the purposes of detecting
+// deletions, since the listing could not have returned them.
+func diffVarListSnapshot(current, snapshot []*api.SecureVariableMetadata, ns, prefix string) *varListSnapshotDiff {
+	if ns == "" {
+		ns = api.DefaultNamespace
+	}
+
+	type nsPath struct {
+		Namespace string
+		Path      string
+	}
+	known := make(map[nsPath]*api.SecureVariableMetadata, len(snapshot))
+	for _, sv := range snapshot {
+		known[nsPath{sv.Namespace, sv.Path}] = sv
+	}
+
+	diff := &varListSnapshotDiff{
+		Changed: make([]*api.SecureVariableMetadata, 0),
+		Deleted: make([]*api.SecureVariableMetadata, 0),
+	}
+	seen := make(map[nsPath]struct{}, len(current))
+	for _, sv := range current {
+		key := nsPath{sv.Namespace, sv.Path}
+		seen[key] = struct{}{}
+		if old, ok := known[key]; !ok || sv.ModifyIndex > old.ModifyIndex {
+			diff.Changed = append(diff.Changed, sv)
+		}
+	}
+
+	for _, sv := range snapshot {
+		if ns != api.AllNamespacesNamespace && sv.Namespace != ns {
+			continue
+		}
+		if !strings.HasPrefix(sv.Path, prefix) {
+			continue
+		}
+		if _, ok := seen[nsPath{sv.Namespace, sv.Path}]; !ok {
+			diff.Deleted = append(diff.Deleted, sv)
+		}
+	}
+	sort.Slice(diff.Deleted, func(i, j int) bool {
+		if diff.Deleted[i].Namespace == diff.Deleted[j].Namespace {
+			return diff.Deleted[i].Path < diff.Deleted[j].Path
+		}
+		return diff.Deleted[i].Namespace < diff.Deleted[j].Namespace
+	})
+	return diff
+}
@@ -3,6 +3,8 @@ package command
 import (
 	"encoding/json"
 	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
@@ -10,6 +12,7 @@ import (
 	"github.com/hashicorp/nomad/api"
 	"github.com/hashicorp/nomad/ci"
 	"github.com/mitchellh/cli"
+	"github.com/posener/complete"
 	"github.com/stretchr/testify/require"
 )
 
@@ -20,6 +23,24 @@ func TestVarListCommand_Implements(t *testing.T) {
 
 // TestVarListCommand_Offline contains all of the tests that do not require a
 // testagent to complete
+func TestVarListCommand_AutocompleteArgs(t *testing.T) {
+	ci.Parallel(t)
+
+	srv, client, url := testServer(t, false, nil)
+	defer srv.Shutdown()
+
+	var variables SVMSlice
+	setupTestVariable(client, api.DefaultNamespace, "app/db", &variables)
+	setupTestVariable(client, api.DefaultNamespace, "other", &variables)
+
+	ui := cli.NewMockUi()
+	cmd := &VarListCommand{Meta: Meta{Ui: ui, flagAddress: url}}
+
+	predictor := cmd.AutocompleteArgs()
+	res := predictor.Predict(complete.Args{Last: "ap"})
+	require.Equal(t, []string{"app/db"}, res)
+}
+
 func TestVarListCommand_Offline(t *testing.T) {
 	ci.Parallel(t)
 	ui := cli.NewMockUi()
@@ -199,6 +220,23 @@ func TestVarListCommand_Online(t *testing.T) {
 			expectStdOut:       variables.HavingPrefix("a/b/c/d").Strings()[0],
 			expectStdErrPrefix: "Next page token",
 		},
+		{
+			name: "plaintext/group by namespace/wildcard ns",
+			args: []string{"-group-by-namespace", "-namespace", "*", "a/b/c/d"},
+			expectStdOut: strings.Join([]string{
+				"Namespace: default (1 secure variable)",
+				formatList([]string{
+					"Path|Last Updated",
+					fmt.Sprintf("a/b/c/d|%s", time.Unix(0, variables.HavingNSPrefix(api.DefaultNamespace, "a/b/c/d")[0].ModifyTime)),
+				}),
+				"",
+				"Namespace: ns1 (1 secure variable)",
+				formatList([]string{
+					"Path|Last Updated",
+					fmt.Sprintf("a/b/c/d|%s", time.Unix(0, variables.HavingNSPrefix("ns1", "a/b/c/d")[0].ModifyTime)),
+				}),
+			}, "\n"),
+		},
 		{
 			name: "json/not found",
 			args: []string{"-json", "does/not/exist"},
@@ -266,6 +304,25 @@ func TestVarListCommand_Online(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "json/group by namespace/wildcard ns",
+			args: []string{"-json", "-group-by-namespace", "-namespace", "*"},
+			jsonTest: &testVarListJSONTest{
+				jsonDest: &GroupedSVMSlice{},
+				expectFns: []testVarListJSONTestExpectFn{
+					hasLength(t, variables.Len()),
+					pathsEqual(t, variables),
+				},
+			},
+		},
+		{
+			name: "json/quiet/group by namespace/wildcard ns",
+			args: []string{"-q", "-json", "-group-by-namespace", "-namespace", "*", "z"},
+			expectStdOut: toJSON(map[string][]string{
+				api.DefaultNamespace: {"z/y", "z/y/x"},
+				"ns1":                {"z/y", "z/y/x"},
+			}),
+		},
 		{
 			name:         "template/not found",
 			args:         []string{"-t", testTmpl, "does/not/exist"},
@@ -312,7 +369,13 @@ func TestVarListCommand_Online(t *testing.T) {
 				tC.exitCode, code, stdOut, errOut)
 
 			if tC.expectStdOut != "" {
-				require.Equal(t, tC.expectStdOut, strings.TrimSpace(stdOut))
+				// Map keys aren't ordered in JSON output, so it is compared
+				// by value
+				if json.Valid([]byte(tC.expectStdOut)) {
+					require.JSONEq(t, tC.expectStdOut, strings.TrimSpace(stdOut))
+				} else {
+					require.Equal(t, tC.expectStdOut, strings.TrimSpace(stdOut))
+				}
 
 				// Test that stdout ends with a linefeed since we trim them for
 				// convenience in the equality tests.
@@ -473,6 +536,20 @@ func (s *PaginatedSVMSlice) NSPaths() testSVNamespacePaths {
 	return out
 }
 
+type GroupedSVMSlice map[string]SVMSlice
+
+func (g GroupedSVMSlice) Len() int { return g.NSPaths().Len() }
+func (g GroupedSVMSlice) NSPaths() testSVNamespacePaths {
+
+	var out testSVNamespacePaths
+	for ns, vars := range g {
+		for _, v := range vars {
+			out = append(out, testSVNamespacePath{ns, v.Path})
+		}
+	}
+	return out
+}
+
 type PaginatedSVQuietSlice struct {
 	Data      []string
 	QueryMeta api.QueryMeta
@@ -487,3 +564,333 @@ func (s *PaginatedSVQuietSlice) NSPaths() testSVNamespacePaths {
 	}
 	return out
 }
+
+func TestVarListCommand_Orphans(t *testing.T) {
+	ci.Parallel(t)
+
+	srv, client, url := testServer(t, false, nil)
+	defer srv.Shutdown()
+
+	_, _, err := client.Jobs().Register(testJob("existing"), nil)
+	require.NoError(t, err)
+
+	var variables SVMSlice
+	for _, p := range []string{"jobs/existing", "jobs/existing/group1", "jobs/gone", "jobs/gone/web", "other/path"} {
+		setupTestVariable(client, api.DefaultNamespace, p, &variables)
+	}
+	require.Len(t, variables, 5)
+
+	ui := cli.NewMockUi()
+	cmd := &VarListCommand{Meta: Meta{Ui: ui}}
+
+	t.Run("pagination", func(t *testing.T) {
+		defer resetUiWriters(ui)
+		code := cmd.Run([]string{"-address=" + url, "-orphans", "-per-page=1"})
+		require.Equal(t, 1, code)
+		require.Contains(t, ui.ErrorWriter.String(), "can not be combined with pagination")
+	})
+
+	t.Run("list", func(t *testing.T) {
+		defer resetUiWriters(ui)
+		code := cmd.Run([]string{"-address=" + url, "-orphans", "-q"})
+		require.Equal(t, 0, code, "stderr: %s", ui.ErrorWriter.String())
+		require.Equal(t, "jobs/gone\njobs/gone/web", strings.TrimSpace(ui.OutputWriter.String()))
+	})
+
+	t.Run("list with prefix", func(t *testing.T) {
+		defer resetUiWriters(ui)
+		code := cmd.Run([]string{"-address=" + url, "-orphans", "-q", "jobs/gone/"})
+		require.Equal(t, 0, code, "stderr: %s", ui.ErrorWriter.String())
+		require.Equal(t, "jobs/gone/web", strings.TrimSpace(ui.OutputWriter.String()))
+	})
+
+	t.Run("purge", func(t *testing.T) {
+		defer resetUiWriters(ui)
+		code := cmd.Run([]string{"-address=" + url, "-purge-orphans", "-yes", "-q"})
+		require.Equal(t, 0, code, "stderr: %s", ui.ErrorWriter.String())
+		require.Contains(t, ui.ErrorWriter.String(), `Purged secure variable "jobs/gone/web"`)
+
+		remaining, _, err := client.SecureVariables().List(nil)
+		require.NoError(t, err)
+		paths := make([]string, len(remaining))
+		for i, sv := range remaining {
+			paths[i] = sv.Path
+		}
+		require.ElementsMatch(t, []string{"jobs/existing", "jobs/existing/group1", "other/path"}, paths)
+	})
+}
+
+func TestVarListCommand_jobIDFromVarPath(t *testing.T) {
+	ci.Parallel(t)
+
+	testCases := []struct {
+		path   string
+		expect string
+		ok     bool
+	}{
+		{"jobs/example", "example", true},
+		{"jobs/example/group/task", "example", true},
+		{"jobs/", "", false},
+		{"jobs", "", false},
+		{"other/jobs/example", "", false},
+	}
+	for _, tC := range testCases {
+		jobID, ok := jobIDFromVarPath(tC.path)
+		require.Equal(t, tC.ok, ok, tC.path)
+		require.Equal(t, tC.expect, jobID, tC.path)
+	}
+}
+
+func TestVarListCommand_formatVarStubsExpiresIn(t *testing.T) {
+	ci.Parallel(t)
+
+	now := time.Now()
+	vars := []*api.SecureVariableMetadata{
+		{Namespace: "default", Path: "a"},
+		{Namespace: "default", Path: "b", ExpireTime: now.Add(time.Hour + 30*time.Second).UnixNano()},
+		{Namespace: "default", Path: "c", ExpireTime: now.Add(-time.Minute).UnixNano()},
+	}
+
+	out := formatVarStubs(vars)
+	require.Contains(t, out, "Expires In")
+	lines := strings.Split(out, "\n")
+	require.Len(t, lines, 4)
+	require.True(t, strings.HasSuffix(lines[1], "<none>"), lines[1])
+	require.True(t, strings.HasSuffix(lines[2], "1h0m30s"), lines[2])
+	require.True(t, strings.HasSuffix(lines[3], "expired"), lines[3])
+
+	// The column is only shown when a secure variable expires
+	require.NotContains(t, formatVarStubs(vars[:1]), "Expires In")
+}
+
+func TestVarListCommand_SinceSnapshot(t *testing.T) {
+	ci.Parallel(t)
+
+	srv, client, url := testServer(t, false, nil)
+	defer srv.Shutdown()
+
+	var variables SVMSlice
+	for _, p := range []string{"a/keep", "a/modify", "a/delete", "b/other"} {
+		setupTestVariable(client, api.DefaultNamespace, p, &variables)
+	}
+
+	ui := cli.NewMockUi()
+	cmd := &VarListCommand{Meta: Meta{Ui: ui}}
+
+	// Take the snapshot the same way an operator would.
+	code := cmd.Run([]string{"-address=" + url, "-json"})
+	require.Equal(t, 0, code, "stderr: %s", ui.ErrorWriter.String())
+	snapshotFile := filepath.Join(t.TempDir(), "snapshot.json")
+	require.NoError(t, os.WriteFile(snapshotFile, ui.OutputWriter.Bytes(), 0600))
+	resetUiWriters(ui)
+
+	_, err := client.SecureVariables().Update(&api.SecureVariable{
+		Path: "a/modify", Items: map[string]string{"k": "changed"}}, nil)
+	require.NoError(t, err)
+	_, err = client.SecureVariables().Delete("a/delete", nil)
+	require.NoError(t, err)
+	setupTestVariable(client, api.DefaultNamespace, "a/new", &variables)
+
+	t.Run("incompatible flags", func(t *testing.T) {
+		defer resetUiWriters(ui)
+		code := cmd.Run([]string{"-address=" + url, "-since-snapshot=" + snapshotFile, "-orphans"})
+		require.Equal(t, 1, code)
+		require.Contains(t, ui.ErrorWriter.String(), "can not be combined")
+	})
+
+	t.Run("bad snapshot", func(t *testing.T) {
+		defer resetUiWriters(ui)
+		badFile := filepath.Join(t.TempDir(), "bad.json")
+		require.NoError(t, os.WriteFile(badFile, []byte(`{"not": "a list"}`), 0600))
+		code := cmd.Run([]string{"-address=" + url, "-since-snapshot=" + badFile})
+		require.Equal(t, 1, code)
+		require.Contains(t, ui.ErrorWriter.String(), "Error parsing snapshot")
+	})
+
+	t.Run("json", func(t *testing.T) {
+		defer resetUiWriters(ui)
+		code := cmd.Run([]string{"-address=" + url, "-since-snapshot=" + snapshotFile, "-q", "-json"})
+		require.Equal(t, 0, code, "stderr: %s", ui.ErrorWriter.String())
+
+		var out struct {
+			Changed []string
+			Deleted []string
+		}
+		require.NoError(t, json.Unmarshal(ui.OutputWriter.Bytes(), &out))
+		require.ElementsMatch(t, []string{"a/modify", "a/new"}, out.Changed)
+		require.Equal(t, []string{"a/delete"}, out.Deleted)
+	})
+
+	t.Run("quiet with prefix", func(t *testing.T) {
+		defer resetUiWriters(ui)
+		code := cmd.Run([]string{"-address=" + url, "-since-snapshot=" + snapshotFile, "-q", "b"})
+		require.Equal(t, 0, code, "stderr: %s", ui.ErrorWriter.String())
+		require.Empty(t, strings.TrimSpace(ui.OutputWriter.String()))
+		require.Empty(t, ui.ErrorWriter.String())
+	})
+
+	t.Run("plaintext", func(t *testing.T) {
+		defer resetUiWriters(ui)
+		code := cmd.Run([]string{"-address=" + url, "-since-snapshot=" + snapshotFile})
+		require.Equal(t, 0, code, "stderr: %s", ui.ErrorWriter.String())
+		out := ui.OutputWriter.String()
+		require.Contains(t, out, "a/new")
+		require.Contains(t, out, "Deleted since snapshot")
+		require.Contains(t, out, "a/delete")
+		require.NotContains(t, out, "a/keep")
+	})
+}
+
+func TestVarListCommand_OnlyEmptyNamespaces(t *testing.T) {
+	ci.Parallel(t)
+
+	srv, client, url := testServer(t, false, nil)
+	defer srv.Shutdown()
+
+	for _, ns := range []string{"ns1", "ns2"} {
+		_, err := client.Namespaces().Register(&api.Namespace{Name: ns}, nil)
+		require.NoError(t, err)
+	}
+
+	var variables SVMSlice
+	setupTestVariable(client, api.DefaultNamespace, "app/db", &variables)
+	setupTestVariable(client, "ns1", "other/path", &variables)
+
+	ui := cli.NewMockUi()
+	cmd := &VarListCommand{Meta: Meta{Ui: ui}}
+
+	t.Run("incompatible flags", func(t *testing.T) {
+		defer resetUiWriters(ui)
+		code := cmd.Run([]string{"-address=" + url, "-only-empty-namespaces", "-orphans"})
+		require.Equal(t, 1, code)
+		require.Contains(t, ui.ErrorWriter.String(), "can not be combined")
+	})
+
+	t.Run("quiet", func(t *testing.T) {
+		defer resetUiWriters(ui)
+		code := cmd.Run([]string{"-address=" + url, "-only-empty-namespaces", "-q"})
+		require.Equal(t, 0, code, "stderr: %s", ui.ErrorWriter.String())
+		require.Equal(t, "ns2", strings.TrimSpace(ui.OutputWriter.String()))
+	})
+
+	t.Run("json with prefix", func(t *testing.T) {
+		defer resetUiWriters(ui)
+		code := cmd.Run([]string{"-address=" + url, "-only-empty-namespaces", "-json", "app"})
+		require.Equal(t, 0, code, "stderr: %s", ui.ErrorWriter.String())
+
+		var out []string
+		require.NoError(t, json.Unmarshal(ui.OutputWriter.Bytes(), &out))
+		require.Equal(t, []string{"ns1", "ns2"}, out)
+	})
+
+	t.Run("plaintext", func(t *testing.T) {
+		defer resetUiWriters(ui)
+		code := cmd.Run([]string{"-address=" + url, "-only-empty-namespaces", "jobs/"})
+		require.Equal(t, 0, code, "stderr: %s", ui.ErrorWriter.String())
+		out := ui.OutputWriter.String()
+		require.Contains(t, out, "Namespace")
+		require.Contains(t, out, api.DefaultNamespace)
+		require.Contains(t, out, "ns1")
+		require.Contains(t, out, "ns2")
+	})
+}
+
+func TestVarListCommand_Tree(t *testing.T) {
+	ci.Parallel(t)
+
+	srv, client, url := testServer(t, false, nil)
+	defer srv.Shutdown()
+
+	var variables SVMSlice
+	for _, p := range []string{"app/db", "app/db/replica", "app/web", "other"} {
+		setupTestVariable(client, api.DefaultNamespace, p, &variables)
+	}
+
+	ui := cli.NewMockUi()
+	cmd := &VarListCommand{Meta: Meta{Ui: ui}}
+
+	t.Run("incompatible flags", func(t *testing.T) {
+		defer resetUiWriters(ui)
+		code := cmd.Run([]string{"-address=" + url, "-tree", "-q"})
+		require.Equal(t, 1, code)
+		require.Contains(t, ui.ErrorWriter.String(), "can not be combined")
+	})
+
+	t.Run("wildcard namespace", func(t *testing.T) {
+		defer resetUiWriters(ui)
+		code := cmd.Run([]string{"-address=" + url, "-namespace=*", "-tree"})
+		require.Equal(t, 1, code)
+		require.Contains(t, ui.ErrorWriter.String(), "wildcard")
+	})
+
+	t.Run("plaintext", func(t *testing.T) {
+		defer resetUiWriters(ui)
+		code := cmd.Run([]string{"-address=" + url, "-tree"})
+		require.Equal(t, 0, code, "stderr: %s", ui.ErrorWriter.String())
+		lines := strings.Split(strings.TrimSpace(ui.OutputWriter.String()), "\n")
+		require.Len(t, lines, 6)
+		require.Contains(t, lines[0], "Items")
+		require.True(t, strings.HasPrefix(lines[1], "app/ "), lines[1])
+		require.True(t, strings.HasPrefix(lines[2], "  db "), lines[2])
+		require.True(t, strings.HasPrefix(lines[3], "    replica "), lines[3])
+		require.True(t, strings.HasPrefix(lines[4], "  web "), lines[4])
+		require.True(t, strings.HasPrefix(lines[5], "other "), lines[5])
+	})
+
+	t.Run("json with prefix", func(t *testing.T) {
+		defer resetUiWriters(ui)
+		code := cmd.Run([]string{"-address=" + url, "-tree", "-json", "app/db"})
+		require.Equal(t, 0, code, "stderr: %s", ui.ErrorWriter.String())
+
+		var root api.SecureVariableTreeNode
+		require.NoError(t, json.Unmarshal(ui.OutputWriter.Bytes(), &root))
+		require.Len(t, root.Children, 1)
+		app := root.Children[0]
+		require.Equal(t, "app", app.Path)
+		require.Nil(t, app.Variable)
+		require.Len(t, app.Children, 1)
+		db := app.Children[0]
+		require.Equal(t, "app/db", db.Path)
+		require.NotNil(t, db.Variable)
+		require.Equal(t, 1, db.Variable.ItemCount)
+		require.NotZero(t, db.Variable.Size)
+		require.NotZero(t, db.Variable.CreateTime)
+		require.Len(t, db.Children, 1)
+		require.Equal(t, "app/db/replica", db.Children[0].Path)
+	})
+
+	t.Run("pagination", func(t *testing.T) {
+		defer resetUiWriters(ui)
+		code := cmd.Run([]string{"-address=" + url, "-tree", "-per-page=2"})
+		require.Equal(t, 0, code, "stderr: %s", ui.ErrorWriter.String())
+		require.Contains(t, ui.ErrorWriter.String(), "Next page token: default.app/web")
+		require.NotContains(t, ui.OutputWriter.String(), "web")
+	})
+}
+
+func TestVarListCommand_diffVarListSnapshot(t *testing.T) {
+	ci.Parallel(t)
+
+	meta := func(ns, path string, idx uint64) *api.SecureVariableMetadata {
+		return &api.SecureVariableMetadata{Namespace: ns, Path: path, ModifyIndex: idx}
+	}
+	snapshot := []*api.SecureVariableMetadata{
+		meta("default", "a/same", 10),
+		meta("default", "a/modified", 10),
+		meta("default", "a/deleted", 10),
+		meta("default", "b/out-of-prefix", 10),
+		meta("ns1", "a/other-namespace", 10),
+	}
+	current := []*api.SecureVariableMetadata{
+		meta("default", "a/same", 10),
+		meta("default", "a/modified", 11),
+		meta("default", "a/created", 12),
+	}
+
+	diff := diffVarListSnapshot(current, snapshot, "", "a/")
+	require.Equal(t, []*api.SecureVariableMetadata{current[1], current[2]}, diff.Changed)
+	require.Equal(t, []*api.SecureVariableMetadata{snapshot[2]}, diff.Deleted)
+
+	diff = diffVarListSnapshot(current, snapshot, "*", "a/")
+	require.Equal(t, []*api.SecureVariableMetadata{snapshot[2], snapshot[4]}, diff.Deleted)
+}
@@ -0,0 +1,320 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/hashicorp/nomad/api"
+	"github.com/hashicorp/nomad/helper/uuid"
+	"github.com/posener/complete"
+)
+
+const (
+	// varLockHolderKey and varLockExpiresKey are the items of a lock
+	// variable, recording who holds the lock and until when.
+	varLockHolderKey  = "holder"
+	varLockExpiresKey = "expires"
+
+	varLockDefaultTTL = 15 * time.Second
+)
+
+type VarLockCommand struct {
+	Meta
+}
+
+func (c *VarLockCommand) Help() string {
+	helpText := `
+Usage: nomad var lock [options] <path> <child command> [<args>...]
+
+  Lock acquires a lock held in the secure variable at the path, runs the child
+  command while holding it, and releases the lock when the child exits. Only
+  one holder at a time can run a command under the same lock, which can be
+  used to serialize batch jobs or deploy scripts.
+
+  The lock variable records its holder and the time the lock expires. The lock
+  is renewed at half the TTL while the child runs, and can be taken over by
+  another holder once it expires, for example if the holding process was
+  killed. If the lock is lost, the child is killed. The expiry is compared
+  against the local clock, so the clocks of the hosts sharing a lock should
+  be kept in sync.
+
+  If ACLs are enabled, this command requires a token with the 'read', 'write',
+  and 'destroy' capabilities for the path.
+
+General Options:
+
+  ` + generalOptionsUsage(usageOptsDefault) + `
+
+Lock Options:
+
+  -ttl=<duration>
+    How long the lock is held without being renewed. Defaults to 15s.
+
+  -timeout=<duration>
+    How long to wait for the lock to become available. Waits indefinitely if
+    not set.
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *VarLockCommand) AutocompleteFlags() complete.Flags {
+	return mergeAutocompleteFlags(c.Meta.AutocompleteFlags(FlagSetClient),
+		complete.Flags{
+			"-ttl":     complete.PredictAnything,
+			"-timeout": complete.PredictAnything,
+		},
+	)
+}
+
+func (c *VarLockCommand) AutocompleteArgs() complete.Predictor {
+	return SecureVariablePathPredictor(c.Meta.Client)
+}
+
+func (c *VarLockCommand) Synopsis() string {
+	return "Run a command while holding a lock"
+}
+
+func (c *VarLockCommand) Name() string { return "var lock" }
+
+func (c *VarLockCommand) Run(args []string) int {
+	var ttl, timeout time.Duration
+
+	flags := c.Meta.FlagSet(c.Name(), FlagSetClient)
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	flags.DurationVar(&ttl, "ttl", varLockDefaultTTL, "")
+	flags.DurationVar(&timeout, "timeout", 0, "")
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	// Check that we got a path and a child command
+	args = flags.Args()
+	if l := len(args); l < 2 {
+		c.Ui.Error("This command takes at least two arguments: <path> <child command>")
+		c.Ui.Error(commandErrorText(c))
+		return 1
+	}
+
+	if ttl <= 0 {
+		c.Ui.Error("The -ttl flag must be greater than zero")
+		c.Ui.Error(commandErrorText(c))
+		return 1
+	}
+
+	// Get the HTTP client
+	client, err := c.Meta.Client()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error initializing client: %s", err))
+		return 1
+	}
+
+	if c.Meta.clientConfig().Namespace == api.AllNamespacesNamespace {
+		c.Ui.Error("Locks can not be held in the wildcard (\"*\") namespace")
+		return 1
+	}
+
+	lock := &varLock{
+		client: client.SecureVariables(),
+		path:   args[0],
+		holder: uuid.Generate(),
+		ttl:    ttl,
+	}
+	if err := lock.acquire(timeout); err != nil {
+		c.Ui.Error(fmt.Sprintf("Error acquiring lock: %s", err))
+		return 1
+	}
+
+	code := c.runChild(lock, args[1:])
+
+	if err := lock.release(); err != nil {
+		c.Ui.Error(fmt.Sprintf("Error releasing lock: %s", err))
+		if code == 0 {
+			code = 1
+		}
+	}
+	return code
+}
+
+// runChild runs the child command, renewing the lock until it exits, and
+// returns its exit code.
+func (c *VarLockCommand) runChild(lock *varLock, command []string) int {
+	cmd := exec.Command(command[0], command[1:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Start(); err != nil {
+		c.Ui.Error(fmt.Sprintf("Error starting child command: %s", err))
+		return 1
+	}
+
+	doneCh := make(chan error, 1)
+	go func() {
+		doneCh <- cmd.Wait()
+	}()
+
+	// Signals are passed on to the child, so the lock is only released once
+	// the child has exited.
+	signalCh := make(chan os.Signal, 1)
+	signal.Notify(signalCh, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signalCh)
+
+	ticker := time.NewTicker(lock.ttl / 2)
+	defer ticker.Stop()
+
+	lost := false
+	for {
+		select {
+		case sig := <-signalCh:
+			_ = cmd.Process.Signal(sig)
+
+		case <-ticker.C:
+			if lost {
+				continue
+			}
+			if err := lock.renew(); err != nil {
+				c.Ui.Error(fmt.Sprintf("Lock lost, killing child command: %s", err))
+				lost = true
+				_ = cmd.Process.Kill()
+			}
+
+		case err := <-doneCh:
+			if lost {
+				return 1
+			}
+			var exitErr *exec.ExitError
+			if errors.As(err, &exitErr) {
+				return exitErr.ExitCode()
+			} else if err != nil {
+				c.Ui.Error(fmt.Sprintf("Error running child command: %s", err))
+				return 1
+			}
+			return 0
+		}
+	}
+}
+
+// varLock is a lock held in a secure variable. Writes are checked against the
+// modify index of the last write, so the lock is only ever changed by the
+// holder that last saw it.
+type varLock struct {
+	client *api.SecureVariables
+	path   string
+	holder string
+	ttl    time.Duration
+
+	// index is the modify index of the lock variable once it is held.
+	index uint64
+}
+
+func (l *varLock) items() api.SecureVariableItems {
+	return api.SecureVariableItems{
+		varLockHolderKey:  l.holder,
+		varLockExpiresKey: time.Now().Add(l.ttl).UTC().Format(time.RFC3339Nano),
+	}
+}
+
+// acquire waits for the lock to be free or expired and takes it. Waiting is
+// abandoned after the timeout, unless it is zero.
+func (l *varLock) acquire(timeout time.Duration) error {
+	var deadline time.Time
+	if timeout > 0 {
+		deadline = time.Now().Add(timeout)
+	}
+
+	for {
+		sv, _, err := l.client.Peek(l.path, nil)
+		if err != nil {
+			return err
+		}
+
+		var wm *api.WriteMeta
+		var wait time.Duration
+		if sv == nil {
+			wm, err = l.client.CheckedCreate(&api.SecureVariable{
+				Path:  l.path,
+				Items: l.items(),
+			}, nil)
+		} else {
+			var expires time.Time
+			if expires, err = varLockExpiry(sv); err != nil {
+				return err
+			}
+			if wait = time.Until(expires); wait <= 0 {
+				sv.Items = l.items()
+				wm, err = l.client.CheckedUpdate(sv, nil)
+			}
+		}
+
+		var cas api.ErrCASConflict
+		switch {
+		case errors.As(err, &cas):
+			// Another holder got there first, so look again
+			continue
+		case err != nil:
+			return err
+		case wm != nil:
+			l.index = wm.LastIndex
+			return nil
+		}
+
+		// The lock is held, so wait for it to change or expire
+		if !deadline.IsZero() {
+			remaining := time.Until(deadline)
+			if remaining <= 0 {
+				return fmt.Errorf("timed out waiting for the lock held by %q", sv.Items[varLockHolderKey])
+			}
+			if remaining < wait {
+				wait = remaining
+			}
+		}
+		_, _, err = l.client.Peek(l.path, &api.QueryOptions{
+			WaitIndex: sv.ModifyIndex,
+			WaitTime:  wait,
+		})
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// renew extends the expiry of the held lock.
+func (l *varLock) renew() error {
+	wm, err := l.client.CheckedUpdate(&api.SecureVariable{
+		Path:        l.path,
+		Items:       l.items(),
+		ModifyIndex: l.index,
+	}, nil)
+	if err != nil {
+		return err
+	}
+	l.index = wm.LastIndex
+	return nil
+}
+
+// release deletes the lock variable, unless it was taken over.
+func (l *varLock) release() error {
+	_, err := l.client.CheckedDelete(l.path, l.index, nil)
+	return err
+}
+
+// varLockExpiry returns when the lock held in the secure variable expires.
+// Variables that weren't written by this command are rejected, so they are
+// never overwritten.
+func varLockExpiry(sv *api.SecureVariable) (time.Time, error) {
+	raw, ok := sv.Items[varLockExpiresKey]
+	if !ok || len(sv.Items) != 2 || sv.Items[varLockHolderKey] == "" {
+		return time.Time{}, fmt.Errorf("secure variable %q exists and is not a lock", sv.Path)
+	}
+	expires, err := time.Parse(time.RFC3339Nano, raw)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("secure variable %q has an invalid lock expiry: %s", sv.Path, err)
+	}
+	return expires, nil
+}
@@ -0,0 +1,107 @@
+package command
+
+import (
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/nomad/api"
+	"github.com/hashicorp/nomad/ci"
+	"github.com/mitchellh/cli"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVarLockCommand_Implements(t *testing.T) {
+	ci.Parallel(t)
+	var _ cli.Command = &VarLockCommand{}
+}
+
+func TestVarLockCommand_Offline(t *testing.T) {
+	ci.Parallel(t)
+	ui := cli.NewMockUi()
+	cmd := &VarLockCommand{Meta: Meta{Ui: ui}}
+
+	code := cmd.Run([]string{"locks/one"})
+	require.Equal(t, 1, code)
+	require.Contains(t, ui.ErrorWriter.String(), "This command takes at least two arguments")
+	resetUiWriters(ui)
+
+	code = cmd.Run([]string{"-ttl=0s", "locks/one", "true"})
+	require.Equal(t, 1, code)
+	require.Contains(t, ui.ErrorWriter.String(), "The -ttl flag must be greater than zero")
+}
+
+func TestVarLockCommand_Online(t *testing.T) {
+	ci.Parallel(t)
+	if runtime.GOOS == "windows" {
+		t.Skip("child commands require a POSIX shell")
+	}
+
+	srv, client, url := testServer(t, false, nil)
+	defer srv.Shutdown()
+
+	ui := cli.NewMockUi()
+	cmd := &VarLockCommand{Meta: Meta{Ui: ui}}
+
+	t.Run("exit code", func(t *testing.T) {
+		defer resetUiWriters(ui)
+		code := cmd.Run([]string{"-address=" + url, "locks/exit", "sh", "-c", "exit 3"})
+		require.Equal(t, 3, code, "stderr: %s", ui.ErrorWriter.String())
+
+		// The lock is released when the child exits
+		sv, _, err := client.SecureVariables().Peek("locks/exit", nil)
+		require.NoError(t, err)
+		require.Nil(t, sv)
+	})
+
+	t.Run("renew", func(t *testing.T) {
+		defer resetUiWriters(ui)
+		code := cmd.Run([]string{"-address=" + url, "-ttl=200ms", "locks/renew", "sleep", "0.5"})
+		require.Equal(t, 0, code, "stderr: %s", ui.ErrorWriter.String())
+		require.Empty(t, ui.ErrorWriter.String())
+	})
+
+	t.Run("held", func(t *testing.T) {
+		defer resetUiWriters(ui)
+		_, err := client.SecureVariables().Create(&api.SecureVariable{
+			Path: "locks/held",
+			Items: map[string]string{
+				varLockHolderKey:  "other",
+				varLockExpiresKey: time.Now().Add(time.Hour).UTC().Format(time.RFC3339Nano),
+			},
+		}, nil)
+		require.NoError(t, err)
+
+		code := cmd.Run([]string{"-address=" + url, "-timeout=200ms", "locks/held", "true"})
+		require.Equal(t, 1, code)
+		require.Contains(t, ui.ErrorWriter.String(), `timed out waiting for the lock held by "other"`)
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		defer resetUiWriters(ui)
+		_, err := client.SecureVariables().Create(&api.SecureVariable{
+			Path: "locks/expired",
+			Items: map[string]string{
+				varLockHolderKey:  "other",
+				varLockExpiresKey: time.Now().Add(-time.Minute).UTC().Format(time.RFC3339Nano),
+			},
+		}, nil)
+		require.NoError(t, err)
+
+		code := cmd.Run([]string{"-address=" + url, "-timeout=5s", "locks/expired", "true"})
+		require.Equal(t, 0, code, "stderr: %s", ui.ErrorWriter.String())
+	})
+
+	t.Run("not a lock", func(t *testing.T) {
+		defer resetUiWriters(ui)
+		_, err := client.SecureVariables().Create(&api.SecureVariable{
+			Path:  "app/db",
+			Items: map[string]string{"pass": "s3cret"},
+		}, nil)
+		require.NoError(t, err)
+
+		code := cmd.Run([]string{"-address=" + url, "app/db", "true"})
+		require.Equal(t, 1, code)
+		require.Contains(t, ui.ErrorWriter.String(), `secure variable "app/db" exists and is not a lock`)
+	})
+}
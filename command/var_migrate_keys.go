@@ -0,0 +1,311 @@
+package command
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/nomad/api"
+	flaghelper "github.com/hashicorp/nomad/helper/flags"
+	"github.com/posener/complete"
+)
+
+type VarMigrateKeysCommand struct {
+	Meta
+}
+
+func (c *VarMigrateKeysCommand) Help() string {
+	helpText := `
+Usage: nomad var migrate-keys [options] -rename=<old>:<new> [<prefix>]
+
+  Migrate-keys renames items across every secure variable whose path starts
+  with the prefix. Each matching variable holding one of the old keys has the
+  item moved to the new key and is written back. Variables lacking all of the
+  old keys are left alone.
+
+  Writes are checked against the version of each variable that was read, so
+  a variable changed concurrently is reported as failed rather than
+  overwritten.
+
+  A collision occurs when a variable already holds both the old and the new
+  key, and is handled according to -on-collision. When the strategy is
+  "fail" and a collision is found, nothing is written.
+
+  If ACLs are enabled, this command requires a token with the 'list', 'read',
+  and 'write' capabilities on the matching paths.
+
+General Options:
+
+  ` + generalOptionsUsage(usageOptsDefault) + `
+
+Migrate-Keys Options:
+
+  -rename=<old>:<new>
+    The item key to rename and its new name. May be specified multiple times;
+    renames are applied in the order given.
+
+  -on-collision=<fail|skip|overwrite>
+    How to handle variables that already hold the new key. "fail" aborts
+    without writing anything, "skip" leaves the variable unchanged, and
+    "overwrite" replaces the existing item with the renamed one. Defaults to
+    "fail".
+
+  -dry-run
+    Report the renames that would be made without writing any variables.
+
+  -json
+    Output the per-path results in JSON format.
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *VarMigrateKeysCommand) AutocompleteFlags() complete.Flags {
+	return mergeAutocompleteFlags(c.Meta.AutocompleteFlags(FlagSetClient),
+		complete.Flags{
+			"-rename":       complete.PredictAnything,
+			"-on-collision": complete.PredictSet(varConflictFail, varConflictSkip, varConflictOverwrite),
+			"-dry-run":      complete.PredictNothing,
+			"-json":         complete.PredictNothing,
+		},
+	)
+}
+
+func (c *VarMigrateKeysCommand) AutocompleteArgs() complete.Predictor {
+	return SecureVariablePathPredictor(c.Meta.Client)
+}
+
+func (c *VarMigrateKeysCommand) Synopsis() string {
+	return "Rename item keys across secure variables"
+}
+
+func (c *VarMigrateKeysCommand) Name() string { return "var migrate-keys" }
+
+func (c *VarMigrateKeysCommand) Run(args []string) int {
+	var dryRun, json bool
+	var onCollision string
+	var renameArgs []string
+
+	flags := c.Meta.FlagSet(c.Name(), FlagSetClient)
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	flags.Var((*flaghelper.StringFlag)(&renameArgs), "rename", "")
+	flags.StringVar(&onCollision, "on-collision", varConflictFail, "")
+	flags.BoolVar(&dryRun, "dry-run", false, "")
+	flags.BoolVar(&json, "json", false, "")
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	// Check that we got zero or one argument
+	args = flags.Args()
+	if l := len(args); l > 1 {
+		c.Ui.Error("This command takes flags and either no arguments or one: <prefix>")
+		c.Ui.Error(commandErrorText(c))
+		return 1
+	}
+	prefix := ""
+	if len(args) == 1 {
+		prefix = args[0]
+	}
+
+	renames, err := parseVarKeyRenames(renameArgs)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		c.Ui.Error(commandErrorText(c))
+		return 1
+	}
+
+	switch onCollision {
+	case varConflictFail, varConflictSkip, varConflictOverwrite:
+	default:
+		c.Ui.Error(fmt.Sprintf("Invalid -on-collision value %q; must be one of fail, skip, or overwrite", onCollision))
+		c.Ui.Error(commandErrorText(c))
+		return 1
+	}
+
+	// Get the HTTP client
+	client, err := c.Meta.Client()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error initializing client: %s", err))
+		return 1
+	}
+
+	metas, _, err := client.SecureVariables().PrefixList(prefix, nil)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error retrieving vars: %s", err))
+		return 1
+	}
+	sort.Slice(metas, func(i, j int) bool {
+		if metas[i].Namespace == metas[j].Namespace {
+			return metas[i].Path < metas[j].Path
+		}
+		return metas[i].Namespace < metas[j].Namespace
+	})
+
+	// Plan every rename before writing anything so that a collision under
+	// the fail strategy leaves all variables untouched.
+	var results []*varMigrateKeysResult
+	collisions := 0
+	for _, m := range metas {
+		sv, _, err := client.SecureVariables().Peek(m.Path, &api.QueryOptions{Namespace: m.Namespace})
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Error retrieving secure variable %q in namespace %q: %s", m.Path, m.Namespace, err))
+			return 1
+		}
+		// The variable was deleted after it was listed.
+		if sv == nil {
+			continue
+		}
+
+		r := planVarKeyRenames(sv, renames, onCollision)
+		if r == nil {
+			continue
+		}
+		if r.Result == varMigrateKeysCollision {
+			collisions++
+		}
+		results = append(results, r)
+	}
+
+	code := 0
+	switch {
+	case collisions > 0:
+		c.Ui.Error(fmt.Sprintf("Found %d secure variable(s) that already hold a new key; nothing was written", collisions))
+		code = 1
+	case dryRun:
+		c.Ui.Warn("Dry run; no secure variables were written")
+	default:
+		for _, r := range results {
+			if err := r.apply(client); err != nil {
+				r.Result = varMigrateKeysFailed
+				r.Error = err.Error()
+				code = 1
+			}
+		}
+	}
+
+	if json {
+		out, err := Format(true, "", results)
+		if err != nil {
+			c.Ui.Error(err.Error())
+			return 1
+		}
+		c.Ui.Output(out)
+	} else if len(results) == 0 {
+		c.Ui.Output("No secure variables hold the keys to rename")
+	} else {
+		c.Ui.Output(formatVarMigrateKeysResults(results))
+	}
+	return code
+}
+
+// varKeyRename is a single -rename argument.
+type varKeyRename struct {
+	from, to string
+}
+
+func parseVarKeyRenames(args []string) ([]varKeyRename, error) {
+	if len(args) == 0 {
+		return nil, fmt.Errorf("At least one -rename flag must be provided")
+	}
+
+	renames := make([]varKeyRename, 0, len(args))
+	for _, arg := range args {
+		parts := strings.SplitN(arg, ":", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("Invalid -rename value %q; must be of the form <old>:<new>", arg)
+		}
+		if parts[0] == parts[1] {
+			return nil, fmt.Errorf("Invalid -rename value %q; the old and new keys are the same", arg)
+		}
+		renames = append(renames, varKeyRename{from: parts[0], to: parts[1]})
+	}
+	return renames, nil
+}
+
+const (
+	varMigrateKeysRenamed   = "renamed"
+	varMigrateKeysSkipped   = "skipped"
+	varMigrateKeysCollision = "collision"
+	varMigrateKeysFailed    = "failed"
+)
+
+// varMigrateKeysResult is the outcome of renaming the keys of a single
+// variable.
+type varMigrateKeysResult struct {
+	Namespace string
+	Path      string
+	Renamed   []string
+	Result    string
+	Error     string `json:",omitempty"`
+
+	variable *api.SecureVariable
+}
+
+// planVarKeyRenames applies the renames to a copy of the variable's items. It
+// returns nil if the variable holds none of the old keys.
+func planVarKeyRenames(sv *api.SecureVariable, renames []varKeyRename, onCollision string) *varMigrateKeysResult {
+	items := make(api.SecureVariableItems, len(sv.Items))
+	for k, v := range sv.Items {
+		items[k] = v
+	}
+
+	r := &varMigrateKeysResult{
+		Namespace: sv.Namespace,
+		Path:      sv.Path,
+		Result:    varMigrateKeysRenamed,
+	}
+	for _, rn := range renames {
+		v, ok := items[rn.from]
+		if !ok {
+			continue
+		}
+		r.Renamed = append(r.Renamed, fmt.Sprintf("%s:%s", rn.from, rn.to))
+		if _, exists := items[rn.to]; exists && onCollision != varConflictOverwrite {
+			if onCollision == varConflictSkip {
+				r.Result = varMigrateKeysSkipped
+			} else {
+				r.Result = varMigrateKeysCollision
+			}
+			continue
+		}
+		delete(items, rn.from)
+		items[rn.to] = v
+	}
+
+	if len(r.Renamed) == 0 {
+		return nil
+	}
+	if r.Result == varMigrateKeysRenamed {
+		r.variable = &api.SecureVariable{
+			Namespace:   sv.Namespace,
+			Path:        sv.Path,
+			ModifyIndex: sv.ModifyIndex,
+			Items:       items,
+		}
+	}
+	return r
+}
+
+// apply writes the renamed items back if the result calls for it.
+func (r *varMigrateKeysResult) apply(client *api.Client) error {
+	if r.Result != varMigrateKeysRenamed {
+		return nil
+	}
+	_, err := client.SecureVariables().CheckedUpdate(r.variable,
+		&api.WriteOptions{Namespace: r.Namespace})
+	return err
+}
+
+func formatVarMigrateKeysResults(results []*varMigrateKeysResult) string {
+	rows := make([]string, len(results)+1)
+	rows[0] = "Namespace|Path|Renamed|Result"
+	for i, r := range results {
+		result := r.Result
+		if r.Error != "" {
+			result = fmt.Sprintf("%s: %s", r.Result, r.Error)
+		}
+		rows[i+1] = fmt.Sprintf("%s|%s|%s|%s", r.Namespace, r.Path, strings.Join(r.Renamed, ","), result)
+	}
+	return formatList(rows)
+}
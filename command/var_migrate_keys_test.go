@@ -0,0 +1,145 @@
+package command
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/hashicorp/nomad/api"
+	"github.com/hashicorp/nomad/ci"
+	"github.com/mitchellh/cli"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVarMigrateKeysCommand_Implements(t *testing.T) {
+	ci.Parallel(t)
+	var _ cli.Command = &VarMigrateKeysCommand{}
+}
+
+func TestVarMigrateKeysCommand_Offline(t *testing.T) {
+	ci.Parallel(t)
+	ui := cli.NewMockUi()
+	cmd := &VarMigrateKeysCommand{Meta: Meta{Ui: ui}}
+
+	testCases := []struct {
+		name      string
+		args      []string
+		expectErr string
+	}{
+		{
+			name:      "too many args",
+			args:      []string{"-rename=a:b", "one", "two"},
+			expectErr: "either no arguments or one",
+		},
+		{
+			name:      "no renames",
+			args:      []string{"app"},
+			expectErr: "At least one -rename flag must be provided",
+		},
+		{
+			name:      "malformed rename",
+			args:      []string{"-rename=a"},
+			expectErr: `Invalid -rename value "a"`,
+		},
+		{
+			name:      "same key",
+			args:      []string{"-rename=a:a"},
+			expectErr: "the old and new keys are the same",
+		},
+		{
+			name:      "bad collision strategy",
+			args:      []string{"-rename=a:b", "-on-collision=merge"},
+			expectErr: `Invalid -on-collision value "merge"`,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			defer resetUiWriters(ui)
+			code := cmd.Run(tC.args)
+			require.Equal(t, 1, code)
+			require.Contains(t, ui.ErrorWriter.String(), tC.expectErr)
+		})
+	}
+}
+
+func TestVarMigrateKeysCommand_Online(t *testing.T) {
+	ci.Parallel(t)
+
+	srv, client, url := testServer(t, false, nil)
+	defer srv.Shutdown()
+
+	writeVar := func(path string, items map[string]string) {
+		_, err := client.SecureVariables().Create(&api.SecureVariable{Path: path, Items: items}, nil)
+		require.NoError(t, err)
+	}
+	readItems := func(path string) api.SecureVariableItems {
+		sv, _, err := client.SecureVariables().Read(path, nil)
+		require.NoError(t, err)
+		return sv.Items
+	}
+
+	writeVar("app/a", map[string]string{"db_pass": "one", "user": "app"})
+	writeVar("app/b", map[string]string{"db_pass": "two", "password": "old"})
+	writeVar("app/c", map[string]string{"user": "app"})
+	writeVar("other/d", map[string]string{"db_pass": "three"})
+
+	ui := cli.NewMockUi()
+	cmd := &VarMigrateKeysCommand{Meta: Meta{Ui: ui}}
+	baseArgs := []string{"-address=" + url, "-json", "-rename=db_pass:password"}
+
+	decode := func(t *testing.T) map[string]string {
+		var results []*varMigrateKeysResult
+		require.NoError(t, json.Unmarshal(ui.OutputWriter.Bytes(), &results))
+		out := make(map[string]string)
+		for _, r := range results {
+			out[r.Path] = r.Result
+		}
+		return out
+	}
+
+	t.Run("collision fails without writing", func(t *testing.T) {
+		defer resetUiWriters(ui)
+		code := cmd.Run(append(baseArgs, "app"))
+		require.Equal(t, 1, code)
+		require.Contains(t, ui.ErrorWriter.String(), "nothing was written")
+		require.Equal(t, map[string]string{
+			"app/a": varMigrateKeysRenamed,
+			"app/b": varMigrateKeysCollision,
+		}, decode(t))
+		require.Equal(t, "one", readItems("app/a")["db_pass"])
+	})
+
+	t.Run("dry run", func(t *testing.T) {
+		defer resetUiWriters(ui)
+		code := cmd.Run(append(baseArgs, "-on-collision=skip", "-dry-run", "app"))
+		require.Equal(t, 0, code, "stderr: %s", ui.ErrorWriter.String())
+		require.Equal(t, map[string]string{
+			"app/a": varMigrateKeysRenamed,
+			"app/b": varMigrateKeysSkipped,
+		}, decode(t))
+		require.Equal(t, "one", readItems("app/a")["db_pass"])
+	})
+
+	t.Run("skip", func(t *testing.T) {
+		defer resetUiWriters(ui)
+		code := cmd.Run(append(baseArgs, "-on-collision=skip", "app"))
+		require.Equal(t, 0, code, "stderr: %s", ui.ErrorWriter.String())
+		require.Equal(t, api.SecureVariableItems{"password": "one", "user": "app"}, readItems("app/a"))
+		require.Equal(t, api.SecureVariableItems{"db_pass": "two", "password": "old"}, readItems("app/b"))
+		require.Equal(t, api.SecureVariableItems{"db_pass": "three"}, readItems("other/d"))
+	})
+
+	t.Run("overwrite", func(t *testing.T) {
+		defer resetUiWriters(ui)
+		code := cmd.Run(append(baseArgs, "-on-collision=overwrite", "app"))
+		require.Equal(t, 0, code, "stderr: %s", ui.ErrorWriter.String())
+		require.Equal(t, map[string]string{"app/b": varMigrateKeysRenamed}, decode(t))
+		require.Equal(t, api.SecureVariableItems{"password": "two"}, readItems("app/b"))
+	})
+
+	t.Run("nothing to rename", func(t *testing.T) {
+		defer resetUiWriters(ui)
+		code := cmd.Run([]string{"-address=" + url, "-rename=missing:key"})
+		require.Equal(t, 0, code, "stderr: %s", ui.ErrorWriter.String())
+		require.Contains(t, ui.OutputWriter.String(), "No secure variables hold the keys to rename")
+	})
+}
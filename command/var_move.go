@@ -0,0 +1,68 @@
+package command
+
+import (
+	"strings"
+
+	"github.com/posener/complete"
+)
+
+type VarMoveCommand struct {
+	Meta
+}
+
+func (c *VarMoveCommand) Help() string {
+	helpText := `
+Usage: nomad var move [options] <source path> <destination path>
+
+  Move moves the secure variable at the source path to the destination path.
+  With -recurse, every secure variable at or below the source path is moved,
+  keeping its path relative to the source. The variables are moved from the
+  namespace given by -namespace, to the namespace given by -target-namespace.
+
+  All the variables are moved in a single transaction, so either every
+  variable is moved or none are. Nothing is moved if any of the destination
+  paths already holds a secure variable, or if any of the variables is
+  modified while the move runs. The previous versions of a moved variable are
+  not kept.
+
+  If ACLs are enabled, this command requires a token with the 'read' and
+  'write' capabilities for the source paths and the 'write' capability for
+  the destination paths.
+
+General Options:
+
+  ` + generalOptionsUsage(usageOptsDefault) + `
+
+Move Options:
+
+  -recurse
+    Move every secure variable at or below the source path.
+
+  -target-namespace=<namespace>
+    The namespace to move the secure variables to. Defaults to the namespace
+    they are moved from.
+
+  -json
+    Output the moved secure variables in JSON format.
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *VarMoveCommand) AutocompleteFlags() complete.Flags {
+	return mergeAutocompleteFlags(c.Meta.AutocompleteFlags(FlagSetClient),
+		varCopyAutocompleteFlags(c.Meta))
+}
+
+func (c *VarMoveCommand) AutocompleteArgs() complete.Predictor {
+	return SecureVariablePathPredictor(c.Meta.Client)
+}
+
+func (c *VarMoveCommand) Synopsis() string {
+	return "Move secure variables to a new path"
+}
+
+func (c *VarMoveCommand) Name() string { return "var move" }
+
+func (c *VarMoveCommand) Run(args []string) int {
+	return runVarCopy(&c.Meta, c, args, true)
+}
@@ -0,0 +1,62 @@
+package command
+
+import (
+	"testing"
+
+	"github.com/hashicorp/nomad/api"
+	"github.com/hashicorp/nomad/ci"
+	"github.com/mitchellh/cli"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVarMoveCommand_Implements(t *testing.T) {
+	ci.Parallel(t)
+	var _ cli.Command = &VarMoveCommand{}
+}
+
+func TestVarMoveCommand_Online(t *testing.T) {
+	ci.Parallel(t)
+
+	srv, client, url := testServer(t, false, nil)
+	defer srv.Shutdown()
+
+	for _, path := range []string{"app/db", "app/web", "other/web"} {
+		_, err := client.SecureVariables().Create(&api.SecureVariable{
+			Path:  path,
+			Items: map[string]string{"path": path},
+		}, nil)
+		require.NoError(t, err)
+	}
+
+	ui := cli.NewMockUi()
+	cmd := &VarMoveCommand{Meta: Meta{Ui: ui}}
+
+	paths := func() []string {
+		vars, _, err := client.SecureVariables().List(nil)
+		require.NoError(t, err)
+		out := make([]string, len(vars))
+		for i, sv := range vars {
+			out[i] = sv.Path
+		}
+		return out
+	}
+
+	t.Run("conflict moves nothing", func(t *testing.T) {
+		defer resetUiWriters(ui)
+		code := cmd.Run([]string{"-address=" + url, "-recurse", "app", "other"})
+		require.Equal(t, 1, code)
+		require.Contains(t, ui.ErrorWriter.String(), `"other/web" in namespace "default" already exists`)
+		require.Equal(t, []string{"app/db", "app/web", "other/web"}, paths())
+	})
+
+	t.Run("recurse", func(t *testing.T) {
+		defer resetUiWriters(ui)
+		code := cmd.Run([]string{"-address=" + url, "-recurse", "app", "svc"})
+		require.Equal(t, 0, code, "stderr: %s", ui.ErrorWriter.String())
+		require.Equal(t, []string{"other/web", "svc/db", "svc/web"}, paths())
+
+		sv, _, err := client.SecureVariables().Read("svc/db", nil)
+		require.NoError(t, err)
+		require.Equal(t, api.SecureVariableItems{"path": "app/db"}, sv.Items)
+	})
+}
@@ -0,0 +1,375 @@
+package command
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/nomad/api"
+	"github.com/mitchellh/cli"
+	"github.com/posener/complete"
+)
+
+const (
+	varConflictSkip      = "skip"
+	varConflictOverwrite = "overwrite"
+	varConflictFail      = "fail"
+)
+
+type VarReplicateCommand struct {
+	Meta
+}
+
+func (c *VarReplicateCommand) Help() string {
+	helpText := `
+Usage: nomad var replicate [options] (-path=<path> | -prefix=<prefix>) -to-address=<addr>
+
+  Replicate copies secure variables from this cluster to another Nomad
+  cluster. A single variable is selected with -path, or every variable whose
+  path starts with a prefix is selected with -prefix. Variables are read from
+  this cluster and written to the destination cluster with its own address and
+  ACL token.
+
+  The destination is compared against the source before anything is written.
+  Variables that don't exist at the destination are created, and those whose
+  items already match are left unchanged. Variables that exist with different
+  items are conflicts and are handled according to -on-conflict. When the
+  strategy is "fail" and a conflict is found, nothing is written.
+
+  If ACLs are enabled, this command requires a token with the 'read'
+  capability on the source paths, and a destination token with the 'write'
+  capability on the destination paths.
+
+General Options:
+
+  ` + generalOptionsUsage(usageOptsDefault) + `
+
+Replicate Options:
+
+  -path
+    The path of a single secure variable to replicate.
+
+  -prefix
+    Replicate every secure variable whose path starts with this prefix. Use
+    "-namespace=*" to replicate the matching variables of all namespaces.
+
+  -to-namespace
+    The namespace to write the variables to at the destination. Defaults to
+    the namespace of each source variable.
+
+  -on-conflict=<fail|skip|overwrite>
+    How to handle variables that exist at the destination with different
+    items. "fail" aborts without writing anything, "skip" leaves the
+    destination variable in place, and "overwrite" replaces it. Defaults to
+    "fail".
+
+  -dry-run
+    Report what would be replicated without writing to the destination.
+
+  -json
+    Output the per-path results in JSON format.
+
+Destination Options:
+
+  ` + varClusterFlagsUsage("to-", "the destination cluster")
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *VarReplicateCommand) AutocompleteFlags() complete.Flags {
+	var dest varClusterFlags
+	return mergeAutocompleteFlags(c.Meta.AutocompleteFlags(FlagSetClient),
+		complete.Flags{
+			"-path":         SecureVariablePathPredictor(c.Meta.Client),
+			"-prefix":       SecureVariablePathPredictor(c.Meta.Client),
+			"-to-namespace": complete.PredictAnything,
+			"-on-conflict":  complete.PredictSet(varConflictFail, varConflictSkip, varConflictOverwrite),
+			"-dry-run":      complete.PredictNothing,
+			"-json":         complete.PredictNothing,
+		},
+		dest.autocompleteFlags("to-"),
+	)
+}
+
+func (c *VarReplicateCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *VarReplicateCommand) Synopsis() string {
+	return "Replicate secure variables to another cluster"
+}
+
+func (c *VarReplicateCommand) Name() string { return "var replicate" }
+
+func (c *VarReplicateCommand) Run(args []string) int {
+	var dryRun, json bool
+	var path, prefix, toNamespace, onConflict string
+	var dest varClusterFlags
+
+	flags := c.Meta.FlagSet(c.Name(), FlagSetClient)
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	flags.StringVar(&path, "path", "", "")
+	flags.StringVar(&prefix, "prefix", "", "")
+	flags.StringVar(&toNamespace, "to-namespace", "", "")
+	flags.StringVar(&onConflict, "on-conflict", varConflictFail, "")
+	flags.BoolVar(&dryRun, "dry-run", false, "")
+	flags.BoolVar(&json, "json", false, "")
+	dest.register(flags, "to-")
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	// Check that we got no arguments
+	args = flags.Args()
+	if l := len(args); l != 0 {
+		c.Ui.Error("This command takes no arguments")
+		c.Ui.Error(commandErrorText(c))
+		return 1
+	}
+
+	if (path == "") == (prefix == "") {
+		c.Ui.Error("Exactly one of -path or -prefix must be provided")
+		c.Ui.Error(commandErrorText(c))
+		return 1
+	}
+
+	switch onConflict {
+	case varConflictFail, varConflictSkip, varConflictOverwrite:
+	default:
+		c.Ui.Error(fmt.Sprintf("Invalid -on-conflict value %q; must be one of fail, skip, or overwrite", onConflict))
+		c.Ui.Error(commandErrorText(c))
+		return 1
+	}
+
+	if toNamespace == api.AllNamespacesNamespace {
+		c.Ui.Error("Secure variables can not be written to the wildcard (\"*\") namespace")
+		return 1
+	}
+
+	// Get the HTTP clients
+	client, err := c.Meta.Client()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error initializing client: %s", err))
+		return 1
+	}
+	destClient, err := dest.client("to-")
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error initializing destination client: %s", err))
+		return 1
+	}
+
+	srcVars, err := c.readSource(client, path, prefix)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return 1
+	}
+	if len(srcVars) == 0 {
+		c.Ui.Output(msgSecureVariableNotFound)
+		return 0
+	}
+
+	results, conflicts, err := planVarWrites(destClient, srcVars, toNamespace, onConflict)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return 1
+	}
+	code := applyVarWrites(c.Ui, destClient, results, conflicts, dryRun)
+
+	if json {
+		out, err := Format(true, "", results)
+		if err != nil {
+			c.Ui.Error(err.Error())
+			return 1
+		}
+		c.Ui.Output(out)
+	} else {
+		c.Ui.Output(formatVarReplicateResults(results))
+	}
+	return code
+}
+
+// readSource fetches the full secure variables to replicate from the local
+// cluster, sorted by namespace and path.
+func (c *VarReplicateCommand) readSource(client *api.Client, path, prefix string) ([]*api.SecureVariable, error) {
+	if path != "" {
+		sv, _, err := client.SecureVariables().Peek(path, nil)
+		if err != nil {
+			return nil, fmt.Errorf("Error retrieving secure variable: %s", err)
+		}
+		if sv == nil {
+			return nil, nil
+		}
+		return []*api.SecureVariable{sv}, nil
+	}
+	return readVarsByPrefix(client, prefix)
+}
+
+// readVarsByPrefix fetches the full secure variables whose path starts with
+// the prefix, sorted by namespace and path.
+func readVarsByPrefix(client *api.Client, prefix string) ([]*api.SecureVariable, error) {
+	metas, _, err := client.SecureVariables().PrefixList(prefix, nil)
+	if err != nil {
+		return nil, fmt.Errorf("Error retrieving vars: %s", err)
+	}
+	sort.Slice(metas, func(i, j int) bool {
+		if metas[i].Namespace == metas[j].Namespace {
+			return metas[i].Path < metas[j].Path
+		}
+		return metas[i].Namespace < metas[j].Namespace
+	})
+
+	out := make([]*api.SecureVariable, 0, len(metas))
+	for _, m := range metas {
+		sv, _, err := client.SecureVariables().Peek(m.Path, &api.QueryOptions{Namespace: m.Namespace})
+		if err != nil {
+			return nil, fmt.Errorf("Error retrieving secure variable %q in namespace %q: %s", m.Path, m.Namespace, err)
+		}
+		// The variable was deleted after it was listed.
+		if sv == nil {
+			continue
+		}
+		out = append(out, sv)
+	}
+	return out, nil
+}
+
+// planVarWrites compares the variables against those at the destination and
+// returns the write each calls for, along with the number of conflicts found
+// under the fail strategy. Nothing is written, so that a conflict can leave
+// the destination untouched. The variables are written to toNamespace, or to
+// their own namespace if it's empty.
+func planVarWrites(destClient *api.Client, vars []*api.SecureVariable, toNamespace, onConflict string) ([]*varReplicateResult, int, error) {
+	results := make([]*varReplicateResult, 0, len(vars))
+	conflicts := 0
+	for _, sv := range vars {
+		r := &varReplicateResult{
+			Namespace:   sv.Namespace,
+			Path:        sv.Path,
+			ToNamespace: toNamespace,
+			source:      sv,
+		}
+		if r.ToNamespace == "" {
+			r.ToNamespace = sv.Namespace
+		}
+
+		existing, _, err := destClient.SecureVariables().Peek(sv.Path,
+			&api.QueryOptions{Namespace: r.ToNamespace})
+		if err != nil {
+			return nil, 0, fmt.Errorf("Error reading %q in namespace %q from destination: %s", sv.Path, r.ToNamespace, err)
+		}
+
+		switch {
+		case existing == nil:
+			r.Result = varReplicateCreated
+		case varItemsEqual(existing.Items, sv.Items):
+			r.Result = varReplicateUnchanged
+		case onConflict == varConflictOverwrite:
+			r.Result = varReplicateUpdated
+			r.destIndex = existing.ModifyIndex
+		case onConflict == varConflictSkip:
+			r.Result = varReplicateSkipped
+		default:
+			r.Result = varReplicateConflict
+			conflicts++
+		}
+		results = append(results, r)
+	}
+	return results, conflicts, nil
+}
+
+// applyVarWrites performs the writes planned by planVarWrites, unless there
+// were conflicts or this is a dry run, and returns the exit code.
+func applyVarWrites(ui cli.Ui, destClient *api.Client, results []*varReplicateResult, conflicts int, dryRun bool) int {
+	switch {
+	case conflicts > 0:
+		ui.Error(fmt.Sprintf("Found %d conflicting secure variable(s) at the destination; nothing was written", conflicts))
+		return 1
+	case dryRun:
+		ui.Warn("Dry run; nothing was written to the destination")
+		return 0
+	}
+
+	code := 0
+	for _, r := range results {
+		if err := r.apply(destClient); err != nil {
+			r.Result = varReplicateFailed
+			r.Error = err.Error()
+			code = 1
+		}
+	}
+	return code
+}
+
+const (
+	varReplicateCreated   = "created"
+	varReplicateUpdated   = "updated"
+	varReplicateUnchanged = "unchanged"
+	varReplicateSkipped   = "skipped"
+	varReplicateConflict  = "conflict"
+	varReplicateFailed    = "failed"
+)
+
+// varReplicateResult is the outcome of replicating a single variable.
+type varReplicateResult struct {
+	Namespace   string
+	Path        string
+	ToNamespace string
+	Result      string
+	Error       string `json:",omitempty"`
+
+	source    *api.SecureVariable
+	destIndex uint64
+}
+
+// apply writes the source variable to the destination if the result calls
+// for it. Writes are checked against the state of the destination observed
+// during the comparison, so concurrent changes there aren't clobbered.
+func (r *varReplicateResult) apply(client *api.Client) error {
+	if r.Result != varReplicateCreated && r.Result != varReplicateUpdated {
+		return nil
+	}
+
+	sv := &api.SecureVariable{
+		Namespace:   r.ToNamespace,
+		Path:        r.Path,
+		ModifyIndex: r.destIndex,
+		Items:       r.source.Items,
+	}
+	wo := &api.WriteOptions{Namespace: r.ToNamespace}
+
+	var err error
+	if r.Result == varReplicateCreated {
+		_, err = client.SecureVariables().CheckedCreate(sv, wo)
+	} else {
+		_, err = client.SecureVariables().CheckedUpdate(sv, wo)
+	}
+	return err
+}
+
+func formatVarReplicateResults(results []*varReplicateResult) string {
+	rows := make([]string, len(results)+1)
+	rows[0] = "Namespace|Path|To Namespace|Result"
+	for i, r := range results {
+		result := r.Result
+		if r.Error != "" {
+			result = fmt.Sprintf("%s: %s", r.Result, r.Error)
+		}
+		rows[i+1] = fmt.Sprintf("%s|%s|%s|%s", r.Namespace, r.Path, r.ToNamespace, result)
+	}
+	return formatList(rows)
+}
+
+// varItemsEqual reports whether two sets of secure variable items hold the
+// same keys and values.
+func varItemsEqual(a, b api.SecureVariableItems) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if bv, ok := b[k]; !ok || bv != v {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,170 @@
+package command
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/hashicorp/nomad/api"
+	"github.com/hashicorp/nomad/ci"
+	"github.com/mitchellh/cli"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVarReplicateCommand_Implements(t *testing.T) {
+	ci.Parallel(t)
+	var _ cli.Command = &VarReplicateCommand{}
+}
+
+func TestVarReplicateCommand_Offline(t *testing.T) {
+	ci.Parallel(t)
+	ui := cli.NewMockUi()
+	cmd := &VarReplicateCommand{Meta: Meta{Ui: ui}}
+
+	testCases := []struct {
+		name      string
+		args      []string
+		expectErr string
+	}{
+		{
+			name:      "bad args",
+			args:      []string{"-path=a", "extra"},
+			expectErr: "This command takes no arguments",
+		},
+		{
+			name:      "no selection",
+			args:      []string{"-to-address=http://127.0.0.1:4646"},
+			expectErr: "Exactly one of -path or -prefix must be provided",
+		},
+		{
+			name:      "both selections",
+			args:      []string{"-path=a", "-prefix=a", "-to-address=http://127.0.0.1:4646"},
+			expectErr: "Exactly one of -path or -prefix must be provided",
+		},
+		{
+			name:      "bad conflict strategy",
+			args:      []string{"-path=a", "-on-conflict=merge", "-to-address=http://127.0.0.1:4646"},
+			expectErr: `Invalid -on-conflict value "merge"`,
+		},
+		{
+			name:      "missing destination",
+			args:      []string{"-path=a"},
+			expectErr: "The -to-address flag is required",
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			defer resetUiWriters(ui)
+			code := cmd.Run(tC.args)
+			require.Equal(t, 1, code)
+			require.Contains(t, ui.ErrorWriter.String(), tC.expectErr)
+		})
+	}
+}
+
+func TestVarReplicateCommand_Online(t *testing.T) {
+	ci.Parallel(t)
+
+	srcSrv, srcClient, srcURL := testServer(t, false, nil)
+	defer srcSrv.Shutdown()
+	dstSrv, dstClient, dstURL := testServer(t, false, nil)
+	defer dstSrv.Shutdown()
+
+	writeVar := func(c *api.Client, path string, items map[string]string) {
+		_, err := c.SecureVariables().Create(&api.SecureVariable{Path: path, Items: items}, nil)
+		require.NoError(t, err)
+	}
+	readItems := func(c *api.Client, path string) api.SecureVariableItems {
+		sv, _, err := c.SecureVariables().Read(path, nil)
+		require.NoError(t, err)
+		return sv.Items
+	}
+
+	writeVar(srcClient, "app/db", map[string]string{"user": "app", "pass": "s3cret"})
+	writeVar(srcClient, "app/cache", map[string]string{"url": "redis://cache"})
+	writeVar(srcClient, "other", map[string]string{"k": "v"})
+	writeVar(dstClient, "app/cache", map[string]string{"url": "redis://old"})
+
+	ui := cli.NewMockUi()
+	cmd := &VarReplicateCommand{Meta: Meta{Ui: ui}}
+	baseArgs := []string{"-address=" + srcURL, "-to-address=" + dstURL, "-json"}
+
+	decode := func(t *testing.T) map[string]string {
+		var results []*varReplicateResult
+		require.NoError(t, json.Unmarshal([]byte(ui.OutputWriter.String()), &results))
+		out := make(map[string]string)
+		for _, r := range results {
+			out[r.Path] = r.Result
+		}
+		return out
+	}
+
+	t.Run("conflict fails without writing", func(t *testing.T) {
+		defer resetUiWriters(ui)
+		code := cmd.Run(append(baseArgs, "-prefix=app"))
+		require.Equal(t, 1, code)
+		require.Contains(t, ui.ErrorWriter.String(), "nothing was written")
+		require.Equal(t, map[string]string{
+			"app/cache": varReplicateConflict,
+			"app/db":    varReplicateCreated,
+		}, decode(t))
+
+		sv, _, err := dstClient.SecureVariables().Peek("app/db", nil)
+		require.NoError(t, err)
+		require.Nil(t, sv)
+	})
+
+	t.Run("dry run", func(t *testing.T) {
+		defer resetUiWriters(ui)
+		code := cmd.Run(append(baseArgs, "-prefix=app", "-on-conflict=overwrite", "-dry-run"))
+		require.Equal(t, 0, code, "stderr: %s", ui.ErrorWriter.String())
+		require.Equal(t, map[string]string{
+			"app/cache": varReplicateUpdated,
+			"app/db":    varReplicateCreated,
+		}, decode(t))
+		require.Equal(t, "redis://old", readItems(dstClient, "app/cache")["url"])
+	})
+
+	t.Run("skip", func(t *testing.T) {
+		defer resetUiWriters(ui)
+		code := cmd.Run(append(baseArgs, "-prefix=app", "-on-conflict=skip"))
+		require.Equal(t, 0, code, "stderr: %s", ui.ErrorWriter.String())
+		require.Equal(t, map[string]string{
+			"app/cache": varReplicateSkipped,
+			"app/db":    varReplicateCreated,
+		}, decode(t))
+		require.Equal(t, "s3cret", readItems(dstClient, "app/db")["pass"])
+		require.Equal(t, "redis://old", readItems(dstClient, "app/cache")["url"])
+	})
+
+	t.Run("overwrite", func(t *testing.T) {
+		defer resetUiWriters(ui)
+		code := cmd.Run(append(baseArgs, "-prefix=app", "-on-conflict=overwrite"))
+		require.Equal(t, 0, code, "stderr: %s", ui.ErrorWriter.String())
+		require.Equal(t, map[string]string{
+			"app/cache": varReplicateUpdated,
+			"app/db":    varReplicateUnchanged,
+		}, decode(t))
+		require.Equal(t, "redis://cache", readItems(dstClient, "app/cache")["url"])
+	})
+
+	t.Run("single path to namespace", func(t *testing.T) {
+		defer resetUiWriters(ui)
+		_, err := dstClient.Namespaces().Register(&api.Namespace{Name: "ns1"}, nil)
+		require.NoError(t, err)
+
+		code := cmd.Run(append(baseArgs, "-path=other", "-to-namespace=ns1"))
+		require.Equal(t, 0, code, "stderr: %s", ui.ErrorWriter.String())
+		require.Equal(t, map[string]string{"other": varReplicateCreated}, decode(t))
+
+		sv, _, err := dstClient.SecureVariables().Read("other", &api.QueryOptions{Namespace: "ns1"})
+		require.NoError(t, err)
+		require.Equal(t, "v", sv.Items["k"])
+	})
+
+	t.Run("missing path", func(t *testing.T) {
+		defer resetUiWriters(ui)
+		code := cmd.Run([]string{"-address=" + srcURL, "-to-address=" + dstURL, "-path=does/not/exist"})
+		require.Equal(t, 0, code, "stderr: %s", ui.ErrorWriter.String())
+		require.Contains(t, ui.OutputWriter.String(), msgSecureVariableNotFound)
+	})
+}
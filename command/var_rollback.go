@@ -0,0 +1,143 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/nomad/api"
+	"github.com/posener/complete"
+)
+
+type VarRollbackCommand struct {
+	Meta
+}
+
+func (c *VarRollbackCommand) Help() string {
+	helpText := `
+Usage: nomad var rollback [options] -version=<version> <path>
+
+  Rollback restores the items of a previous version of a secure variable. The
+  items are written as a new version, so the version being replaced is kept
+  and the rollback can itself be undone. Versions are identified by the
+  modify index reported by "nomad var history".
+
+  The update is checked against the current version of the variable, so
+  changes made by others while the rollback runs are never overwritten.
+
+  If ACLs are enabled, this command requires a token with the 'read' and
+  'write' capabilities for the path.
+
+General Options:
+
+  ` + generalOptionsUsage(usageOptsDefault) + `
+
+Rollback Options:
+
+  -version=<version>
+    The version to restore, as reported by "nomad var history". Required.
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *VarRollbackCommand) AutocompleteFlags() complete.Flags {
+	return mergeAutocompleteFlags(c.Meta.AutocompleteFlags(FlagSetClient),
+		complete.Flags{
+			"-version": complete.PredictAnything,
+		},
+	)
+}
+
+func (c *VarRollbackCommand) AutocompleteArgs() complete.Predictor {
+	return SecureVariablePathPredictor(c.Meta.Client)
+}
+
+func (c *VarRollbackCommand) Synopsis() string {
+	return "Restore a previous version of a secure variable"
+}
+
+func (c *VarRollbackCommand) Name() string { return "var rollback" }
+
+func (c *VarRollbackCommand) Run(args []string) int {
+	var version uint64
+
+	flags := c.Meta.FlagSet(c.Name(), FlagSetClient)
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	flags.Uint64Var(&version, "version", 0, "")
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	// Check that we got exactly one argument
+	args = flags.Args()
+	if l := len(args); l != 1 {
+		c.Ui.Error("This command takes one argument: <path>")
+		c.Ui.Error(commandErrorText(c))
+		return 1
+	}
+	path := args[0]
+
+	if version == 0 {
+		c.Ui.Error("The -version flag is required")
+		c.Ui.Error(commandErrorText(c))
+		return 1
+	}
+
+	// Get the HTTP client
+	client, err := c.Meta.Client()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error initializing client: %s", err))
+		return 1
+	}
+
+	if c.Meta.clientConfig().Namespace == api.AllNamespacesNamespace {
+		c.Ui.Error("Secure variables can not be rolled back in the wildcard (\"*\") namespace")
+		return 1
+	}
+
+	sv, _, err := client.SecureVariables().Peek(path, nil)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error retrieving secure variable: %s", err))
+		return 1
+	}
+	if sv == nil {
+		c.Ui.Error(msgSecureVariableNotFound)
+		return 1
+	}
+	if sv.ModifyIndex == version {
+		c.Ui.Output(fmt.Sprintf("Secure variable %q is already at version %d", sv.Path, version))
+		return 0
+	}
+
+	prev, _, err := client.SecureVariables().ReadVersion(path, version, nil)
+	if err != nil {
+		if err.Error() == api.ErrVariableNotFound {
+			c.Ui.Error(fmt.Sprintf("Version %d of secure variable %q not found; use \"nomad var history\" to list the kept versions", version, sv.Path))
+		} else {
+			c.Ui.Error(fmt.Sprintf("Error retrieving secure variable version: %s", err))
+		}
+		return 1
+	}
+
+	if varItemsEqual(prev.Items, sv.Items) {
+		c.Ui.Output(fmt.Sprintf("Secure variable %q already has the items of version %d", sv.Path, version))
+		return 0
+	}
+
+	// The current version's modify index is kept on the variable, so the
+	// update only succeeds if nothing was written since it was read.
+	sv.Items = prev.Items
+	if _, err := client.SecureVariables().CheckedUpdate(sv, nil); err != nil {
+		var cas api.ErrCASConflict
+		if errors.As(err, &cas) {
+			c.Ui.Error(fmt.Sprintf("Secure variable %q was modified during the rollback; nothing was written", sv.Path))
+		} else {
+			c.Ui.Error(fmt.Sprintf("Error updating secure variable: %s", err))
+		}
+		return 1
+	}
+
+	c.Ui.Output(fmt.Sprintf("Rolled back secure variable %q in namespace %q to version %d", sv.Path, sv.Namespace, version))
+	return 0
+}
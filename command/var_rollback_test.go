@@ -0,0 +1,86 @@
+package command
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/nomad/api"
+	"github.com/hashicorp/nomad/ci"
+	"github.com/mitchellh/cli"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVarRollbackCommand_Implements(t *testing.T) {
+	ci.Parallel(t)
+	var _ cli.Command = &VarRollbackCommand{}
+}
+
+func TestVarRollbackCommand_Offline(t *testing.T) {
+	ci.Parallel(t)
+	ui := cli.NewMockUi()
+	cmd := &VarRollbackCommand{Meta: Meta{Ui: ui}}
+
+	code := cmd.Run([]string{"one", "two"})
+	require.Equal(t, 1, code)
+	require.Contains(t, ui.ErrorWriter.String(), "This command takes one argument")
+	resetUiWriters(ui)
+
+	code = cmd.Run([]string{"app/db"})
+	require.Equal(t, 1, code)
+	require.Contains(t, ui.ErrorWriter.String(), "The -version flag is required")
+}
+
+func TestVarRollbackCommand_Online(t *testing.T) {
+	ci.Parallel(t)
+
+	srv, client, url := testServer(t, false, nil)
+	defer srv.Shutdown()
+
+	sv := &api.SecureVariable{Path: "app/db", Items: map[string]string{"pass": "one"}}
+	_, err := client.SecureVariables().Create(sv, nil)
+	require.NoError(t, err)
+	first, _, err := client.SecureVariables().Read(sv.Path, nil)
+	require.NoError(t, err)
+
+	update := first.Copy()
+	update.Items = map[string]string{"pass": "two"}
+	_, err = client.SecureVariables().CheckedUpdate(update, nil)
+	require.NoError(t, err)
+
+	readItems := func() api.SecureVariableItems {
+		sv, _, err := client.SecureVariables().Read(sv.Path, nil)
+		require.NoError(t, err)
+		return sv.Items
+	}
+
+	ui := cli.NewMockUi()
+	cmd := &VarRollbackCommand{Meta: Meta{Ui: ui}}
+
+	t.Run("missing", func(t *testing.T) {
+		defer resetUiWriters(ui)
+		code := cmd.Run([]string{"-address=" + url, "-version=1", "does/not/exist"})
+		require.Equal(t, 1, code)
+		require.Contains(t, ui.ErrorWriter.String(), msgSecureVariableNotFound)
+	})
+
+	t.Run("missing version", func(t *testing.T) {
+		defer resetUiWriters(ui)
+		code := cmd.Run([]string{"-address=" + url, "-version=1", sv.Path})
+		require.Equal(t, 1, code)
+		require.Contains(t, ui.ErrorWriter.String(), `Version 1 of secure variable "app/db" not found`)
+	})
+
+	t.Run("rollback", func(t *testing.T) {
+		defer resetUiWriters(ui)
+		version := fmt.Sprintf("-version=%d", first.ModifyIndex)
+		code := cmd.Run([]string{"-address=" + url, version, sv.Path})
+		require.Equal(t, 0, code, "stderr: %s", ui.ErrorWriter.String())
+		require.Contains(t, ui.OutputWriter.String(), `Rolled back secure variable "app/db"`)
+		require.Equal(t, api.SecureVariableItems{"pass": "one"}, readItems())
+
+		// The replaced version is kept, so the rollback can be undone.
+		versions, _, err := client.SecureVariables().History(sv.Path, nil)
+		require.NoError(t, err)
+		require.Len(t, versions, 3)
+	})
+}
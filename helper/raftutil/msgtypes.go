@@ -58,6 +58,9 @@ var msgTypeNames = map[structs.MessageType]string{
 	structs.SecureVariableDeleteRequestType:              "SecureVariableDeleteRequestType",
 	structs.RootKeyMetaUpsertRequestType:                 "RootKeyMetaUpsertRequestType",
 	structs.RootKeyMetaDeleteRequestType:                 "RootKeyMetaDeleteRequestType",
+	structs.SecureVariableCopyRequestType:                "SecureVariableCopyRequestType",
+	structs.SecureVariableRekeyRequestType:               "SecureVariableRekeyRequestType",
+	structs.SecureVariablesSinkCheckpointRequestType:     "SecureVariablesSinkCheckpointRequestType",
 	structs.NamespaceUpsertRequestType:                   "NamespaceUpsertRequestType",
 	structs.NamespaceDeleteRequestType:                   "NamespaceDeleteRequestType",
 }
@@ -257,3 +257,64 @@ func Restore(logger hclog.Logger, in io.Reader, r *raft.Raft) error {
 
 	return nil
 }
+
+// Rewrite copies the snapshot archive from in to out, passing the snapshot
+// data through fn on the way. The metadata of the snapshot is kept other than
+// its size, and the integrity information is regenerated for the new data.
+func Rewrite(in io.Reader, out io.Writer, fn func(io.Reader, io.Writer) error) (*raft.SnapshotMeta, error) {
+	// Make scratch files for the original and rewritten snapshot data so we
+	// can avoid buffering in memory.
+	orig, err := ioutil.TempFile("", "snapshot")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp snapshot file: %v", err)
+	}
+	defer os.Remove(orig.Name())
+
+	// CopySnapshot closes the file, so it is reopened for reading
+	metadata, err := CopySnapshot(in, orig)
+	if err != nil {
+		return nil, err
+	}
+	snap, err := os.Open(orig.Name())
+	if err != nil {
+		return nil, fmt.Errorf("failed to reopen temp snapshot: %v", err)
+	}
+	defer snap.Close()
+
+	rewritten, err := ioutil.TempFile("", "snapshot")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp snapshot file: %v", err)
+	}
+	defer func() {
+		rewritten.Close()
+		os.Remove(rewritten.Name())
+	}()
+
+	if err := fn(snap, rewritten); err != nil {
+		return nil, fmt.Errorf("failed to rewrite snapshot: %v", err)
+	}
+
+	size, err := rewritten.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, fmt.Errorf("failed to size rewritten snapshot: %v", err)
+	}
+	if _, err := rewritten.Seek(0, 0); err != nil {
+		return nil, fmt.Errorf("failed to rewind rewritten snapshot: %v", err)
+	}
+	metadata.Size = size
+
+	// Wrap the writer in a gzip compressor.
+	compressor := gzip.NewWriter(out)
+
+	// Write the archive.
+	if err := write(compressor, metadata, rewritten); err != nil {
+		return nil, fmt.Errorf("failed to write snapshot file: %v", err)
+	}
+
+	// Finish the compressed stream.
+	if err := compressor.Close(); err != nil {
+		return nil, fmt.Errorf("failed to compress snapshot file: %v", err)
+	}
+
+	return metadata, nil
+}
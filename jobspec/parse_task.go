@@ -38,6 +38,7 @@ var (
 		"service",
 		"template",
 		"vault",
+		"vars",
 		"kind",
 		"volume_mount",
 		"csi_plugin",
@@ -110,6 +111,7 @@ func parseTask(item *ast.ObjectItem, keys []string) (*api.Task, error) {
 	delete(m, "service")
 	delete(m, "template")
 	delete(m, "vault")
+	delete(m, "vars")
 	delete(m, "volume_mount")
 	delete(m, "csi_plugin")
 	delete(m, "scaling")
@@ -287,6 +289,13 @@ func parseTask(item *ast.ObjectItem, keys []string) (*api.Task, error) {
 		}
 	}
 
+	// Parse secure variables
+	if o := listVal.Filter("vars"); len(o.Items) > 0 {
+		if err := parseTaskSecureVariables(&t.SecureVariables, o); err != nil {
+			return nil, multierror.Prefix(err, "vars ->")
+		}
+	}
+
 	// Parse scaling policies
 	if o := listVal.Filter("scaling"); len(o.Items) > 0 {
 		if err := parseTaskScalingPolicies(&t.ScalingPolicies, o); err != nil {
@@ -480,6 +489,36 @@ func parseTemplates(result *[]*api.Template, list *ast.ObjectList) error {
 	return nil
 }
 
+func parseTaskSecureVariables(result *[]*api.TaskSecureVariable, list *ast.ObjectList) error {
+	for _, o := range list.Elem().Items {
+		// Check for invalid keys
+		valid := []string{
+			"path",
+			"items",
+			"change_mode",
+		}
+		if err := checkHCLKeys(o.Val, valid); err != nil {
+			return err
+		}
+
+		var m map[string]interface{}
+		if err := hcl.DecodeObject(&m, o.Val); err != nil {
+			return err
+		}
+
+		sv := &api.TaskSecureVariable{
+			ChangeMode: stringToPtr("restart"),
+		}
+		if err := mapstructure.WeakDecode(m, sv); err != nil {
+			return err
+		}
+
+		*result = append(*result, sv)
+	}
+
+	return nil
+}
+
 func parseTaskScalingPolicies(result *[]*api.ScalingPolicy, list *ast.ObjectList) error {
 	if len(list.Items) == 0 {
 		return nil
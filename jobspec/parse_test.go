@@ -638,6 +638,36 @@ func TestParse(t *testing.T) {
 			},
 			false,
 		},
+		{
+			"secure-variables.hcl",
+			&api.Job{
+				ID:   stringToPtr("example"),
+				Name: stringToPtr("example"),
+				TaskGroups: []*api.TaskGroup{
+					{
+						Name: stringToPtr("cache"),
+						Tasks: []*api.Task{
+							{
+								Name:   "redis",
+								Driver: "docker",
+								SecureVariables: []*api.TaskSecureVariable{
+									{
+										Path:       stringToPtr("app/redis"),
+										Items:      []string{"user", "password"},
+										ChangeMode: stringToPtr("restart"),
+									},
+									{
+										Path:       stringToPtr("app/shared"),
+										ChangeMode: stringToPtr("noop"),
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			false,
+		},
 		{
 			"service-check-initial-status.hcl",
 			&api.Job{
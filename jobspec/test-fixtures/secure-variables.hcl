@@ -0,0 +1,17 @@
+job "example" {
+  group "cache" {
+    task "redis" {
+      driver = "docker"
+
+      vars {
+        path  = "app/redis"
+        items = ["user", "password"]
+      }
+
+      vars {
+        path        = "app/shared"
+        change_mode = "noop"
+      }
+    }
+  }
+}
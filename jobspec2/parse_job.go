@@ -42,6 +42,7 @@ func normalizeJob(jc *jobConfig) {
 			}
 
 			normalizeTemplates(t.Templates)
+			normalizeSecureVariables(t.SecureVariables)
 
 			// normalize Vault
 			normalizeVault(t.Vault)
@@ -113,6 +114,14 @@ func normalizeTemplates(templates []*api.Template) {
 	}
 }
 
+func normalizeSecureVariables(vars []*api.TaskSecureVariable) {
+	for _, sv := range vars {
+		if sv.ChangeMode == nil {
+			sv.ChangeMode = stringToPtr("restart")
+		}
+	}
+}
+
 func int8ToPtr(v int8) *int8 {
 	return &v
 }
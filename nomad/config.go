@@ -212,6 +212,18 @@ type Config struct {
 	// rekey any variables associated with a key in the Rekeying state
 	SecureVariablesRekeyInterval time.Duration
 
+	// SecureVariablesRekeyBatchSize is the number of secure variables
+	// re-encrypted in each Raft write by the rekey job
+	SecureVariablesRekeyBatchSize int
+
+	// SecureVariablesRekeyBatchWait is how long the rekey job waits between
+	// batches, to limit the load it puts on Raft
+	SecureVariablesRekeyBatchWait time.Duration
+
+	// SecureVariablesGCInterval is how often we dispatch a job to GC
+	// expired secure variables
+	SecureVariablesGCInterval time.Duration
+
 	// EvalNackTimeout controls how long we allow a sub-scheduler to
 	// work on an evaluation before we consider it failed and Nack it.
 	// This allows that evaluation to be handed to another sub-scheduler
@@ -369,6 +381,10 @@ type Config struct {
 	// RaftBoltNoFreelistSync configures whether freelist syncing is enabled.
 	RaftBoltNoFreelistSync bool
 
+	// SecureVariablesSinks are the external systems that secure variable
+	// change events are forwarded to.
+	SecureVariablesSinks []*config.SecureVariablesSink
+
 	// AgentShutdown is used to call agent.Shutdown from the context of a Server
 	// It is used primarily for licensing
 	AgentShutdown func() error
@@ -416,6 +432,9 @@ func DefaultConfig() *Config {
 		RootKeyGCThreshold:               1 * time.Hour,
 		RootKeyRotationThreshold:         720 * time.Hour, // 30 days
 		SecureVariablesRekeyInterval:     10 * time.Minute,
+		SecureVariablesRekeyBatchSize:    20,
+		SecureVariablesRekeyBatchWait:    100 * time.Millisecond,
+		SecureVariablesGCInterval:        5 * time.Minute,
 		EvalNackTimeout:                  60 * time.Second,
 		EvalDeliveryLimit:                3,
 		EvalNackInitialReenqueueDelay:    1 * time.Second,
@@ -1,7 +1,6 @@
 package nomad
 
 import (
-	"encoding/json"
 	"fmt"
 	"math"
 	"strings"
@@ -9,6 +8,7 @@ import (
 
 	log "github.com/hashicorp/go-hclog"
 	memdb "github.com/hashicorp/go-memdb"
+	multierror "github.com/hashicorp/go-multierror"
 	version "github.com/hashicorp/go-version"
 	"github.com/hashicorp/nomad/nomad/state"
 	"github.com/hashicorp/nomad/nomad/structs"
@@ -56,6 +56,8 @@ func (c *CoreScheduler) Process(eval *structs.Evaluation) error {
 		return c.rootKeyRotateOrGC(eval)
 	case structs.CoreJobSecureVariablesRekey:
 		return c.secureVariablesRekey(eval)
+	case structs.CoreJobSecureVariablesGC:
+		return c.secureVariablesGC(eval)
 	case structs.CoreJobForceGC:
 		return c.forceGC(eval)
 	default:
@@ -86,6 +88,9 @@ func (c *CoreScheduler) forceGC(eval *structs.Evaluation) error {
 	if err := c.rootKeyRotateOrGC(eval); err != nil {
 		return err
 	}
+	if err := c.secureVariablesGC(eval); err != nil {
+		return err
+	}
 	// Node GC must occur after the others to ensure the allocations are
 	// cleared.
 	return c.nodeGC(eval)
@@ -836,6 +841,13 @@ func (c *CoreScheduler) rootKeyRotateOrGC(eval *structs.Evaluation) error {
 		if varIter.Next() != nil {
 			continue // key is still in use
 		}
+		versionIter, err := c.snap.GetSecureVariableVersionsByKeyID(ws, keyMeta.KeyID)
+		if err != nil {
+			return err
+		}
+		if versionIter.Next() != nil {
+			continue // key is still in use by previous versions
+		}
 
 		req := &structs.KeyringDeleteRootKeyRequest{
 			KeyID: keyMeta.KeyID,
@@ -889,10 +901,10 @@ func (c *CoreScheduler) rootKeyRotation(eval *structs.Evaluation) (bool, error)
 }
 
 // secureVariablesReKey is optionally run after rotating the active
-// root key. It iterates over all the variables for the keys in the
-// re-keying state, decrypts them, and re-encrypts them in batches
-// with the currently active key. This job does not GC the keys, which
-// is handled in the normal periodic GC job.
+// root key. It iterates over all the variables and kept previous
+// versions for the keys in the re-keying state, decrypts them, and
+// re-encrypts them in batches with the currently active key. This job
+// does not GC the keys, which is handled in the normal periodic GC job.
 func (c *CoreScheduler) secureVariablesRekey(eval *structs.Evaluation) error {
 
 	ws := memdb.NewWatchSet()
@@ -914,7 +926,11 @@ func (c *CoreScheduler) secureVariablesRekey(eval *structs.Evaluation) error {
 		if err != nil {
 			return err
 		}
-		err = c.batchRotateVariables(varIter, eval)
+		versionIter, err := c.snap.GetSecureVariableVersionsByKeyID(ws, keyMeta.KeyID)
+		if err != nil {
+			return err
+		}
+		err = c.batchRotateVariables(varIter, versionIter, eval)
 		if err != nil {
 			return err
 		}
@@ -947,57 +963,142 @@ func (c *CoreScheduler) secureVariablesRekey(eval *structs.Evaluation) error {
 	return nil
 }
 
-// rootKeyFullRotatePerKey runs over an iterator of secure variables
-// and decrypts them, and then sends them back as batches to be
-// re-encrypted with the currently active key.
-func (c *CoreScheduler) batchRotateVariables(iter memdb.ResultIterator, eval *structs.Evaluation) error {
+// batchRotateVariables runs over iterators of secure variables and kept
+// previous versions, re-encrypts them with the currently active key, and
+// sends them back in batches to replace the encrypted data. Entries modified
+// since the snapshot are left to the write that modified them, which was
+// already encrypted with the active key.
+func (c *CoreScheduler) batchRotateVariables(varIter, versionIter memdb.ResultIterator, eval *structs.Evaluation) error {
 
-	upsertFn := func(variables []*structs.SecureVariableDecrypted) error {
-		if len(variables) == 0 {
-			return nil
-		}
-		args := &structs.SecureVariablesUpsertRequest{
-			Data: variables,
+	newRequest := func() *structs.SecureVariablesRekeyRequest {
+		return &structs.SecureVariablesRekeyRequest{
 			WriteRequest: structs.WriteRequest{
 				Region:    c.srv.config.Region,
 				AuthToken: eval.LeaderACL,
 			},
 		}
-		reply := &structs.SecureVariablesUpsertResponse{}
-		return c.srv.RPC("SecureVariables.Upsert", args, reply)
 	}
 
-	variables := []*structs.SecureVariableDecrypted{}
-	for {
-		raw := iter.Next()
-		if raw == nil {
-			break
+	batches := 0
+	batchSize := c.srv.config.SecureVariablesRekeyBatchSize
+	if batchSize <= 0 {
+		batchSize = 20
+	}
+
+	args := newRequest()
+	flushFn := func() error {
+		if len(args.Data)+len(args.Versions) == 0 {
+			return nil
 		}
-		ev := raw.(*structs.SecureVariableEncrypted)
-		cleartext, err := c.srv.encrypter.Decrypt(ev.Data, ev.KeyID)
+
+		// Pause between batches so the rekey doesn't crowd out other
+		// Raft writes
+		if batches > 0 && c.srv.config.SecureVariablesRekeyBatchWait > 0 {
+			select {
+			case <-time.After(c.srv.config.SecureVariablesRekeyBatchWait):
+			case <-c.srv.shutdownCh:
+				return fmt.Errorf("server shutting down")
+			}
+		}
+
+		err := c.srv.RPC(structs.SecureVariablesRekeyRPCMethod, args, &structs.GenericResponse{})
 		if err != nil {
 			return err
 		}
-		dv := &structs.SecureVariableDecrypted{
+		batches++
+		args = newRequest()
+		return nil
+	}
+
+	rekeyFn := func(ev *structs.SecureVariableEncrypted) (*structs.SecureVariableEncrypted, error) {
+		cleartext, err := c.srv.encrypter.Decrypt(ev.Data, ev.KeyID)
+		if err != nil {
+			return nil, err
+		}
+		nv := &structs.SecureVariableEncrypted{
 			SecureVariableMetadata: ev.SecureVariableMetadata,
 		}
-		dv.Items = make(map[string]string)
-		err = json.Unmarshal(cleartext, &dv.Items)
+		nv.Data, nv.KeyID, err = c.srv.encrypter.Encrypt(cleartext)
+		if err != nil {
+			return nil, err
+		}
+		return nv, nil
+	}
+
+	for raw := varIter.Next(); raw != nil; raw = varIter.Next() {
+		nv, err := rekeyFn(raw.(*structs.SecureVariableEncrypted))
 		if err != nil {
 			return err
 		}
-		variables = append(variables, dv)
-		if len(variables) == 20 {
-			err := upsertFn(variables)
-			if err != nil {
+		args.Data = append(args.Data, nv)
+		if len(args.Data)+len(args.Versions) == batchSize {
+			if err := flushFn(); err != nil {
+				return err
+			}
+		}
+	}
+	for raw := versionIter.Next(); raw != nil; raw = versionIter.Next() {
+		nv, err := rekeyFn(raw.(*structs.SecureVariableEncrypted))
+		if err != nil {
+			return err
+		}
+		args.Versions = append(args.Versions, nv)
+		if len(args.Data)+len(args.Versions) == batchSize {
+			if err := flushFn(); err != nil {
 				return err
 			}
-			variables = []*structs.SecureVariableDecrypted{}
 		}
 	}
 
 	// ensure we submit any partial batch
-	return upsertFn(variables)
+	return flushFn()
+}
+
+// secureVariablesGC is used to delete expired secure variables. Each delete
+// is checked against the expired version, so secure variables written again
+// since the scan are kept. A failure to delete one secure variable doesn't
+// stop the others from being deleted.
+func (c *CoreScheduler) secureVariablesGC(eval *structs.Evaluation) error {
+
+	ws := memdb.NewWatchSet()
+	iter, err := c.snap.SecureVariables(ws)
+	if err != nil {
+		return err
+	}
+
+	var mErr multierror.Error
+	now := time.Now()
+	for raw := iter.Next(); raw != nil; raw = iter.Next() {
+		sv := raw.(*structs.SecureVariableEncrypted)
+		if liveSecureVariable(sv, now) != nil {
+			continue
+		}
+
+		checkIndex := sv.ModifyIndex
+		req := &structs.SecureVariablesDeleteRequest{
+			Path:       sv.Path,
+			CheckIndex: &checkIndex,
+			WriteRequest: structs.WriteRequest{
+				Region:    c.srv.Region(),
+				Namespace: sv.Namespace,
+				AuthToken: eval.LeaderACL,
+			},
+		}
+		var resp structs.SecureVariablesDeleteResponse
+		err := c.srv.RPC("SecureVariables.Delete", req, &resp)
+		if err != nil {
+			c.logger.Error("failed to GC expired secure variable",
+				"namespace", sv.Namespace, "path", sv.Path, "error", err)
+			_ = multierror.Append(&mErr, fmt.Errorf(
+				"failed to GC secure variable %q in namespace %q: %v", sv.Path, sv.Namespace, err))
+			continue
+		}
+		if resp.Conflict != nil {
+			c.logger.Debug("expired secure variable was written since the scan, skipping GC",
+				"namespace", sv.Namespace, "path", sv.Path)
+		}
+	}
+	return mErr.ErrorOrNil()
 }
 
 // getThreshold returns the index threshold for determining whether an
@@ -1,6 +1,7 @@
 package nomad
 
 import (
+	"context"
 	"fmt"
 	"testing"
 	"time"
@@ -11,6 +12,7 @@ import (
 	"github.com/hashicorp/nomad/helper/uuid"
 	"github.com/hashicorp/nomad/nomad/mock"
 	"github.com/hashicorp/nomad/nomad/state"
+	"github.com/hashicorp/nomad/nomad/stream"
 	"github.com/hashicorp/nomad/nomad/structs"
 	"github.com/hashicorp/nomad/testutil"
 	"github.com/stretchr/testify/assert"
@@ -2485,6 +2487,21 @@ func TestCoreScheduler_RootKeyGC(t *testing.T) {
 	require.NoError(t, store.UpsertSecureVariables(
 		structs.MsgTypeTestSetup, 601, []*structs.SecureVariableEncrypted{variable}))
 
+	// insert an "old" and inactive key with a previous version of a
+	// variable that's using it
+	key5 := structs.NewRootKeyMeta()
+	key5.SetInactive()
+	require.NoError(t, store.UpsertRootKeyMeta(650, key5, false))
+
+	versioned := mock.SecureVariableEncrypted()
+	versioned.KeyID = key5.KeyID
+	require.NoError(t, store.UpsertSecureVariables(
+		structs.MsgTypeTestSetup, 651, []*structs.SecureVariableEncrypted{versioned}))
+	versionedU := versioned.Copy()
+	versionedU.KeyID = key0.KeyID
+	require.NoError(t, store.UpsertSecureVariables(
+		structs.MsgTypeTestSetup, 652, []*structs.SecureVariableEncrypted{&versionedU}))
+
 	// insert an allocation
 	alloc := mock.Alloc()
 	alloc.ClientStatus = structs.AllocClientStatusRunning
@@ -2526,6 +2543,10 @@ func TestCoreScheduler_RootKeyGC(t *testing.T) {
 	require.NoError(t, err)
 	require.NotNil(t, key, "old key should not have been GCd if still in use")
 
+	key, err = store.RootKeyMetaByID(ws, key5.KeyID)
+	require.NoError(t, err)
+	require.NotNil(t, key, "old key should not have been GCd if still in use by a previous version")
+
 	key, err = store.RootKeyMetaByID(ws, key3.KeyID)
 	require.NoError(t, err)
 	require.NotNil(t, key, "old key newer than oldest alloc should not have been GCd")
@@ -2581,6 +2602,33 @@ func TestCoreScheduler_SecureVariablesRekey(t *testing.T) {
 	}
 	require.NoError(t, srv.RPC("SecureVariables.Upsert", req2, resp))
 
+	// update a variable so that it has previous versions encrypted with
+	// both keys
+	updated := req.Data[0].Copy()
+	updated.Items["updated"] = "true"
+	req3 := &structs.SecureVariablesUpsertRequest{
+		Data: []*structs.SecureVariableDecrypted{&updated},
+		WriteRequest: structs.WriteRequest{
+			Region: srv.config.Region,
+		},
+	}
+	require.NoError(t, srv.RPC("SecureVariables.Upsert", req3, resp))
+	versions, err := store.GetSecureVariableVersions(nil, updated.Namespace, updated.Path)
+	require.NoError(t, err)
+	require.Len(t, versions, 1)
+	current, err := store.GetSecureVariable(nil, updated.Namespace, updated.Path)
+	require.NoError(t, err)
+
+	// rekeying doesn't publish change events, as the data is unchanged
+	broker, err := store.EventBroker()
+	require.NoError(t, err)
+	sub, err := broker.Subscribe(&stream.SubscribeRequest{
+		Topics:    map[structs.Topic][]string{structs.TopicVariable: {"*"}},
+		Namespace: "*",
+	})
+	require.NoError(t, err)
+	defer sub.Unsubscribe()
+
 	rotateReq.Full = true
 	require.NoError(t, srv.RPC("Keyring.Rotate", rotateReq, &rotateResp))
 	newKeyID := rotateResp.Key.KeyID
@@ -2599,10 +2647,43 @@ func TestCoreScheduler_SecureVariablesRekey(t *testing.T) {
 				return false
 			}
 		}
+		iter, err = store.SecureVariableVersions(ws)
+		require.NoError(t, err)
+		for {
+			raw := iter.Next()
+			if raw == nil {
+				break
+			}
+			variable := raw.(*structs.SecureVariableEncrypted)
+			if variable.KeyID != newKeyID {
+				return false
+			}
+		}
 		return true
 	}, time.Second*5, 100*time.Millisecond,
 		"secure variable rekey should be complete")
 
+	// rekeying doesn't create versions or change the version of variables
+	rekeyedVersions, err := store.GetSecureVariableVersions(nil, updated.Namespace, updated.Path)
+	require.NoError(t, err)
+	require.Len(t, rekeyedVersions, 1)
+	require.Equal(t, versions[0].ModifyIndex, rekeyedVersions[0].ModifyIndex)
+	rekeyed, err := store.GetSecureVariable(nil, updated.Namespace, updated.Path)
+	require.NoError(t, err)
+	require.Equal(t, current.SecureVariableMetadata, rekeyed.SecureVariableMetadata)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	for {
+		events, err := sub.Next(ctx)
+		if err != nil {
+			require.ErrorIs(t, err, context.DeadlineExceeded)
+			break
+		}
+		require.LessOrEqual(t, events.Index, rotateResp.Index,
+			"unexpected events: %#v", events)
+	}
+
 	iter, err := store.RootKeyMetas(memdb.NewWatchSet())
 	require.NoError(t, err)
 	for {
@@ -2617,6 +2698,129 @@ func TestCoreScheduler_SecureVariablesRekey(t *testing.T) {
 	}
 }
 
+// TestCoreScheduler_SecureVariablesGC exercises the deletion of expired
+// secure variables
+func TestCoreScheduler_SecureVariablesGC(t *testing.T) {
+	ci.Parallel(t)
+
+	srv, cleanup := TestServer(t, func(c *Config) {
+		c.NumSchedulers = 0 // Prevent automatic dequeue
+	})
+	defer cleanup()
+	testutil.WaitForLeader(t, srv.RPC)
+
+	expired := mock.SecureVariable()
+	expired.ExpireTime = time.Now().Add(-time.Minute).UnixNano()
+	live := mock.SecureVariable()
+	live.ExpireTime = time.Now().Add(time.Hour).UnixNano()
+
+	req := &structs.SecureVariablesUpsertRequest{
+		Data: []*structs.SecureVariableDecrypted{expired, live},
+		WriteRequest: structs.WriteRequest{
+			Region: srv.config.Region,
+		},
+	}
+	require.NoError(t, srv.RPC("SecureVariables.Upsert", req,
+		&structs.SecureVariablesUpsertResponse{}))
+
+	// Expired secure variables are treated as missing before they are GC'd
+	readReq := &structs.SecureVariablesReadRequest{
+		Path: expired.Path,
+		QueryOptions: structs.QueryOptions{
+			Region:    srv.config.Region,
+			Namespace: expired.Namespace,
+		},
+	}
+	var readResp structs.SecureVariablesReadResponse
+	require.NoError(t, srv.RPC("SecureVariables.Read", readReq, &readResp))
+	require.Nil(t, readResp.Data)
+
+	listReq := &structs.SecureVariablesListRequest{
+		QueryOptions: structs.QueryOptions{
+			Region:    srv.config.Region,
+			Namespace: structs.AllNamespacesSentinel,
+		},
+	}
+	var listResp structs.SecureVariablesListResponse
+	require.NoError(t, srv.RPC("SecureVariables.List", listReq, &listResp))
+	require.Len(t, listResp.Data, 1)
+	require.Equal(t, live.Path, listResp.Data[0].Path)
+
+	store := srv.fsm.State()
+	snap, err := store.Snapshot()
+	require.NoError(t, err)
+	core := NewCoreScheduler(srv, snap)
+
+	index, err := store.LatestIndex()
+	require.NoError(t, err)
+	gc := srv.coreJobEval(structs.CoreJobSecureVariablesGC, index)
+	require.NoError(t, core.Process(gc))
+
+	out, err := store.GetSecureVariable(nil, expired.Namespace, expired.Path)
+	require.NoError(t, err)
+	require.Nil(t, out)
+
+	out, err = store.GetSecureVariable(nil, live.Namespace, live.Path)
+	require.NoError(t, err)
+	require.NotNil(t, out)
+}
+
+// TestCoreScheduler_SecureVariablesGC_Rewritten asserts that a secure
+// variable written again during the GC is kept without failing the GC of
+// the other expired secure variables
+func TestCoreScheduler_SecureVariablesGC_Rewritten(t *testing.T) {
+	ci.Parallel(t)
+
+	srv, cleanup := TestServer(t, func(c *Config) {
+		c.NumSchedulers = 0 // Prevent automatic dequeue
+	})
+	defer cleanup()
+	testutil.WaitForLeader(t, srv.RPC)
+
+	upsert := func(svs ...*structs.SecureVariableDecrypted) {
+		require.NoError(t, srv.RPC("SecureVariables.Upsert", &structs.SecureVariablesUpsertRequest{
+			Data: svs,
+			WriteRequest: structs.WriteRequest{
+				Region: srv.config.Region,
+			},
+		}, &structs.SecureVariablesUpsertResponse{}))
+	}
+
+	// The rewritten secure variable sorts first, so it is GC'd first
+	rewritten := mock.SecureVariable()
+	rewritten.Path = "a/rewritten"
+	rewritten.ExpireTime = time.Now().Add(-time.Minute).UnixNano()
+	expired := mock.SecureVariable()
+	expired.Path = "b/expired"
+	expired.Namespace = rewritten.Namespace
+	expired.ExpireTime = time.Now().Add(-time.Minute).UnixNano()
+	upsert(rewritten, expired)
+
+	store := srv.fsm.State()
+	snap, err := store.Snapshot()
+	require.NoError(t, err)
+	core := NewCoreScheduler(srv, snap)
+
+	// Write the secure variable again after the GC's snapshot
+	update := rewritten.Copy()
+	update.ExpireTime = 0
+	update.Items["rewritten"] = "true"
+	upsert(&update)
+
+	index, err := store.LatestIndex()
+	require.NoError(t, err)
+	gc := srv.coreJobEval(structs.CoreJobSecureVariablesGC, index)
+	require.NoError(t, core.Process(gc))
+
+	out, err := store.GetSecureVariable(nil, rewritten.Namespace, rewritten.Path)
+	require.NoError(t, err)
+	require.NotNil(t, out, "rewritten secure variable should not have been GC'd")
+
+	out, err = store.GetSecureVariable(nil, expired.Namespace, expired.Path)
+	require.NoError(t, err)
+	require.Nil(t, out, "expired secure variable should have been GC'd")
+}
+
 func TestCoreScheduler_FailLoop(t *testing.T) {
 	ci.Parallel(t)
 
@@ -2676,3 +2880,66 @@ func TestCoreScheduler_FailLoop(t *testing.T) {
 			out.TriggeredBy)
 	}
 }
+
+// TestCoreScheduler_SecureVariablesRekey_Failed asserts that a rekey rejected
+// by the FSM fails the core job and leaves the old key in place
+func TestCoreScheduler_SecureVariablesRekey_Failed(t *testing.T) {
+	ci.Parallel(t)
+
+	srv, cleanup := TestServer(t, func(c *Config) {
+		c.NumSchedulers = 0 // Prevent automatic dequeue
+	})
+	defer cleanup()
+	testutil.WaitForLeader(t, srv.RPC)
+
+	store := srv.fsm.State()
+	key0, err := store.GetActiveRootKeyMeta(nil)
+	require.NotNil(t, key0, "expected keyring to be bootstapped")
+	require.NoError(t, err)
+
+	sv := mock.SecureVariable()
+	req := &structs.SecureVariablesUpsertRequest{
+		Data: []*structs.SecureVariableDecrypted{sv},
+		WriteRequest: structs.WriteRequest{
+			Region: srv.config.Region,
+		},
+	}
+	require.NoError(t, srv.RPC("SecureVariables.Upsert", req,
+		&structs.SecureVariablesUpsertResponse{}))
+
+	rotateReq := &structs.KeyringRotateRootKeyRequest{
+		WriteRequest: structs.WriteRequest{
+			Region: srv.config.Region,
+		},
+	}
+	var rotateResp structs.KeyringRotateRootKeyResponse
+	require.NoError(t, srv.RPC("Keyring.Rotate", rotateReq, &rotateResp))
+
+	key0 = key0.Copy()
+	key0.SetRekeying()
+	require.NoError(t, store.UpsertRootKeyMeta(rotateResp.Index+1, key0, false))
+
+	// swap the active key's cipher for one whose key was never written to
+	// raft, so that the FSM rejects the re-encrypted variables
+	bogus, err := structs.NewRootKey(structs.EncryptionAlgorithmAES256GCM)
+	require.NoError(t, err)
+	require.NoError(t, srv.encrypter.AddKey(bogus))
+	srv.encrypter.lock.Lock()
+	srv.encrypter.keyring[rotateResp.Key.KeyID] = srv.encrypter.keyring[bogus.Meta.KeyID]
+	srv.encrypter.lock.Unlock()
+
+	snap, err := store.Snapshot()
+	require.NoError(t, err)
+	core := NewCoreScheduler(srv, snap)
+	eval := srv.coreJobEval(structs.CoreJobSecureVariablesRekey, rotateResp.Index+2)
+	err = core.Process(eval)
+	require.EqualError(t, err, fmt.Sprintf("root key %q not found", bogus.Meta.KeyID))
+
+	out, err := store.GetSecureVariable(nil, sv.Namespace, sv.Path)
+	require.NoError(t, err)
+	require.Equal(t, key0.KeyID, out.KeyID)
+
+	keyMeta, err := store.RootKeyMetaByID(nil, key0.KeyID)
+	require.NoError(t, err)
+	require.True(t, keyMeta.Rekeying(), "key should not be deprecated")
+}
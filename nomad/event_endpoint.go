@@ -50,7 +50,7 @@ func (e *Event) stream(conn io.ReadWriteCloser) {
 	}
 
 	// Get the servers broker and subscribe
-	publisher, err := e.srv.State().EventBroker()
+	publisher, err := e.srv.eventBrokerForTopics(args.Topics)
 	if err != nil {
 		handleJsonResultError(err, helper.Int64ToPtr(500), encoder)
 		return
@@ -1,9 +1,11 @@
 package nomad
 
 import (
+	"bufio"
 	"fmt"
 	"io"
 	"reflect"
+	"sort"
 	"sync"
 	"time"
 
@@ -58,6 +60,9 @@ const (
 	SecureVariablesSnapshot              SnapshotType = 22
 	SecureVariablesQuotaSnapshot         SnapshotType = 23
 	RootKeyMetaSnapshot                  SnapshotType = 24
+	SecureVariablesHistorySnapshot       SnapshotType = 25
+	SecureVariableChangesSnapshot        SnapshotType = 26
+	SecureVariablesSinkSnapshot          SnapshotType = 27
 
 	// Namespace appliers were moved from enterprise and therefore start at 64
 	NamespaceSnapshot SnapshotType = 64
@@ -321,6 +326,12 @@ func (n *nomadFSM) Apply(log *raft.Log) interface{} {
 		return n.applySecureVariableUpsert(msgType, buf[1:], log.Index)
 	case structs.SecureVariableDeleteRequestType:
 		return n.applySecureVariableDelete(msgType, buf[1:], log.Index)
+	case structs.SecureVariableCopyRequestType:
+		return n.applySecureVariableCopy(msgType, buf[1:], log.Index)
+	case structs.SecureVariableRekeyRequestType:
+		return n.applySecureVariableRekey(msgType, buf[1:], log.Index)
+	case structs.SecureVariablesSinkCheckpointRequestType:
+		return n.applySecureVariablesSinkCheckpoint(msgType, buf[1:], log.Index)
 	case structs.RootKeyMetaUpsertRequestType:
 		return n.applyRootKeyMetaUpsert(msgType, buf[1:], log.Index)
 	case structs.RootKeyMetaDeleteRequestType:
@@ -1731,6 +1742,16 @@ func (n *nomadFSM) restoreImpl(old io.ReadCloser, filter *FSMFilter) error {
 				return err
 			}
 
+		case SecureVariablesHistorySnapshot:
+			variable := new(structs.SecureVariableEncrypted)
+			if err := dec.Decode(variable); err != nil {
+				return err
+			}
+
+			if err := restore.SecureVariableVersionsRestore(variable); err != nil {
+				return err
+			}
+
 		case SecureVariablesQuotaSnapshot:
 			quota := new(structs.SecureVariablesQuota)
 			if err := dec.Decode(quota); err != nil {
@@ -1751,6 +1772,26 @@ func (n *nomadFSM) restoreImpl(old io.ReadCloser, filter *FSMFilter) error {
 				return err
 			}
 
+		case SecureVariableChangesSnapshot:
+			changes := new(structs.SecureVariableChanges)
+			if err := dec.Decode(changes); err != nil {
+				return err
+			}
+
+			if err := restore.SecureVariableChangesRestore(changes); err != nil {
+				return err
+			}
+
+		case SecureVariablesSinkSnapshot:
+			checkpoint := new(structs.SecureVariablesSinkCheckpoint)
+			if err := dec.Decode(checkpoint); err != nil {
+				return err
+			}
+
+			if err := restore.SecureVariablesSinkCheckpointRestore(checkpoint); err != nil {
+				return err
+			}
+
 		default:
 			// Check if this is an enterprise only object being restored
 			restorer, ok := n.enterpriseRestorers[snapType]
@@ -2036,6 +2077,164 @@ func (f *FSMFilter) Include(item interface{}) bool {
 	return true
 }
 
+// SnapshotSecureVariablesOptions controls how RewriteSnapshotSecureVariables
+// rewrites the secure variables held in a snapshot.
+type SnapshotSecureVariablesOptions struct {
+	// Exclude drops the secure variables, along with their previous
+	// versions, the namespace quotas they count towards, and their changes
+	// kept for the secure variable sinks.
+	Exclude bool
+
+	// NamespaceMap moves the secure variables in each namespace key to the
+	// namespace it maps to, which must exist in the snapshot.
+	NamespaceMap map[string]string
+}
+
+// RewriteSnapshotSecureVariables copies the state snapshot from in to out,
+// excluding or moving the secure variables as set by opts. All other records
+// are copied as-is without being decoded into their types. The root key
+// metadata is always kept, since the root keys are not part of the snapshot.
+func RewriteSnapshotSecureVariables(in io.Reader, out io.Writer, opts *SnapshotSecureVariablesOptions) error {
+	r := bufio.NewReader(in)
+	dec := codec.NewDecoder(r, structs.MsgpackHandle)
+	enc := codec.NewEncoder(out, structs.MsgpackHandle)
+	remap := len(opts.NamespaceMap) > 0
+
+	writeRaw := func(raw codec.Raw) error {
+		_, err := out.Write(raw)
+		return err
+	}
+	writeRecord := func(snapType SnapshotType, obj interface{}) error {
+		if _, err := out.Write([]byte{byte(snapType)}); err != nil {
+			return err
+		}
+		return enc.Encode(obj)
+	}
+
+	// Copy the header
+	var header codec.Raw
+	if err := dec.Decode(&header); err != nil {
+		return err
+	}
+	if err := writeRaw(header); err != nil {
+		return err
+	}
+
+	type nsPath struct {
+		Namespace string
+		Path      string
+	}
+	paths := make(map[nsPath]struct{})
+	namespaces := make(map[string]struct{})
+	destinations := make(map[string]struct{})
+	quotas := make(map[string]*structs.SecureVariablesQuota)
+
+	for {
+		b, err := r.ReadByte()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+
+		snapType := SnapshotType(b)
+		isVariable := snapType == SecureVariablesSnapshot ||
+			snapType == SecureVariablesHistorySnapshot
+		isQuota := snapType == SecureVariablesQuotaSnapshot
+		isChanges := snapType == SecureVariableChangesSnapshot
+
+		switch {
+		case opts.Exclude && (isVariable || isQuota || isChanges):
+			var raw codec.Raw
+			if err := dec.Decode(&raw); err != nil {
+				return err
+			}
+
+		case remap && isVariable:
+			variable := new(structs.SecureVariableEncrypted)
+			if err := dec.Decode(variable); err != nil {
+				return err
+			}
+			if dest, ok := opts.NamespaceMap[variable.Namespace]; ok {
+				variable.Namespace = dest
+				destinations[dest] = struct{}{}
+			}
+
+			// Only the current versions are unique by path
+			if snapType == SecureVariablesSnapshot {
+				key := nsPath{variable.Namespace, variable.Path}
+				if _, ok := paths[key]; ok {
+					return fmt.Errorf("more than one secure variable would be restored to path %q in namespace %q",
+						variable.Path, variable.Namespace)
+				}
+				paths[key] = struct{}{}
+			}
+			if err := writeRecord(snapType, variable); err != nil {
+				return err
+			}
+
+		case remap && isQuota:
+			// The quotas are merged by their new namespace and written once
+			// all the records have been read.
+			quota := new(structs.SecureVariablesQuota)
+			if err := dec.Decode(quota); err != nil {
+				return err
+			}
+			if dest, ok := opts.NamespaceMap[quota.Namespace]; ok {
+				quota.Namespace = dest
+			}
+			if existing, ok := quotas[quota.Namespace]; ok {
+				existing.Size += quota.Size
+				if quota.CreateIndex < existing.CreateIndex {
+					existing.CreateIndex = quota.CreateIndex
+				}
+				if quota.ModifyIndex > existing.ModifyIndex {
+					existing.ModifyIndex = quota.ModifyIndex
+				}
+			} else {
+				quotas[quota.Namespace] = quota
+			}
+
+		default:
+			var raw codec.Raw
+			if err := dec.Decode(&raw); err != nil {
+				return err
+			}
+			if remap && snapType == NamespaceSnapshot {
+				ns := new(structs.Namespace)
+				if err := structs.Decode(raw, ns); err != nil {
+					return err
+				}
+				namespaces[ns.Name] = struct{}{}
+			}
+			if _, err := out.Write([]byte{b}); err != nil {
+				return err
+			}
+			if err := writeRaw(raw); err != nil {
+				return err
+			}
+		}
+	}
+
+	for dest := range destinations {
+		if _, ok := namespaces[dest]; !ok {
+			return fmt.Errorf("namespace %q does not exist in the snapshot", dest)
+		}
+	}
+
+	names := make([]string, 0, len(quotas))
+	for ns := range quotas {
+		names = append(names, ns)
+	}
+	sort.Strings(names)
+	for _, ns := range names {
+		if err := writeRecord(SecureVariablesQuotaSnapshot, quotas[ns]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (n *nomadFSM) applySecureVariableUpsert(msgType structs.MessageType, buf []byte, index uint64) interface{} {
 	defer metrics.MeasureSince([]string{"nomad", "fsm", "apply_secure_variable_upsert"}, time.Now())
 	var req structs.SecureVariablesEncryptedUpsertRequest
@@ -2066,6 +2265,51 @@ func (n *nomadFSM) applySecureVariableDelete(msgType structs.MessageType, buf []
 	return nil
 }
 
+func (n *nomadFSM) applySecureVariableCopy(msgType structs.MessageType, buf []byte, index uint64) interface{} {
+	defer metrics.MeasureSince([]string{"nomad", "fsm", "apply_secure_variable_copy"}, time.Now())
+	var req structs.SecureVariablesEncryptedCopyRequest
+	if err := structs.Decode(buf, &req); err != nil {
+		panic(fmt.Errorf("failed to decode request: %v", err))
+	}
+
+	if err := n.state.CopySecureVariables(msgType, index, req.Sources, req.Data, req.Expired, req.Move); err != nil {
+		n.logger.Error("CopySecureVariables failed", "error", err)
+		return err
+	}
+
+	return nil
+}
+
+func (n *nomadFSM) applySecureVariableRekey(msgType structs.MessageType, buf []byte, index uint64) interface{} {
+	defer metrics.MeasureSince([]string{"nomad", "fsm", "apply_secure_variable_rekey"}, time.Now())
+	var req structs.SecureVariablesRekeyRequest
+	if err := structs.Decode(buf, &req); err != nil {
+		panic(fmt.Errorf("failed to decode request: %v", err))
+	}
+
+	if err := n.state.RekeySecureVariables(msgType, index, req.Data, req.Versions); err != nil {
+		n.logger.Error("RekeySecureVariables failed", "error", err)
+		return err
+	}
+
+	return nil
+}
+
+func (n *nomadFSM) applySecureVariablesSinkCheckpoint(msgType structs.MessageType, buf []byte, index uint64) interface{} {
+	defer metrics.MeasureSince([]string{"nomad", "fsm", "apply_secure_variables_sink_checkpoint"}, time.Now())
+	var req structs.SecureVariablesSinkCheckpointRequest
+	if err := structs.Decode(buf, &req); err != nil {
+		panic(fmt.Errorf("failed to decode request: %v", err))
+	}
+
+	if err := n.state.UpsertSecureVariablesSinkCheckpoints(index, req.Checkpoints, req.Reset); err != nil {
+		n.logger.Error("UpsertSecureVariablesSinkCheckpoints failed", "error", err)
+		return err
+	}
+
+	return nil
+}
+
 func (n *nomadFSM) applyRootKeyMetaUpsert(msgType structs.MessageType, buf []byte, index uint64) interface{} {
 	defer metrics.MeasureSince([]string{"nomad", "fsm", "apply_root_key_meta_upsert"}, time.Now())
 
@@ -2210,6 +2454,10 @@ func (s *nomadSnapshot) Persist(sink raft.SnapshotSink) error {
 		sink.Cancel()
 		return err
 	}
+	if err := s.persistSecureVariableVersions(sink, encoder); err != nil {
+		sink.Cancel()
+		return err
+	}
 	if err := s.persistSecureVariablesQuotas(sink, encoder); err != nil {
 		sink.Cancel()
 		return err
@@ -2218,6 +2466,14 @@ func (s *nomadSnapshot) Persist(sink raft.SnapshotSink) error {
 		sink.Cancel()
 		return err
 	}
+	if err := s.persistSecureVariableChanges(sink, encoder); err != nil {
+		sink.Cancel()
+		return err
+	}
+	if err := s.persistSecureVariablesSinkCheckpoints(sink, encoder); err != nil {
+		sink.Cancel()
+		return err
+	}
 	return nil
 }
 
@@ -2799,6 +3055,29 @@ func (s *nomadSnapshot) persistSecureVariables(sink raft.SnapshotSink,
 	return nil
 }
 
+func (s *nomadSnapshot) persistSecureVariableVersions(sink raft.SnapshotSink,
+	encoder *codec.Encoder) error {
+
+	ws := memdb.NewWatchSet()
+	versions, err := s.snap.SecureVariableVersions(ws)
+	if err != nil {
+		return err
+	}
+
+	for {
+		raw := versions.Next()
+		if raw == nil {
+			break
+		}
+		variable := raw.(*structs.SecureVariableEncrypted)
+		sink.Write([]byte{byte(SecureVariablesHistorySnapshot)})
+		if err := encoder.Encode(variable); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (s *nomadSnapshot) persistSecureVariablesQuotas(sink raft.SnapshotSink,
 	encoder *codec.Encoder) error {
 
@@ -2845,6 +3124,52 @@ func (s *nomadSnapshot) persistRootKeyMeta(sink raft.SnapshotSink,
 	return nil
 }
 
+func (s *nomadSnapshot) persistSecureVariableChanges(sink raft.SnapshotSink,
+	encoder *codec.Encoder) error {
+
+	ws := memdb.NewWatchSet()
+	iter, err := s.snap.SecureVariableChangesAfter(ws, 0)
+	if err != nil {
+		return err
+	}
+
+	for {
+		raw := iter.Next()
+		if raw == nil {
+			break
+		}
+		changes := raw.(*structs.SecureVariableChanges)
+		sink.Write([]byte{byte(SecureVariableChangesSnapshot)})
+		if err := encoder.Encode(changes); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *nomadSnapshot) persistSecureVariablesSinkCheckpoints(sink raft.SnapshotSink,
+	encoder *codec.Encoder) error {
+
+	ws := memdb.NewWatchSet()
+	iter, err := s.snap.SecureVariablesSinkCheckpoints(ws)
+	if err != nil {
+		return err
+	}
+
+	for {
+		raw := iter.Next()
+		if raw == nil {
+			break
+		}
+		checkpoint := raw.(*structs.SecureVariablesSinkCheckpoint)
+		sink.Write([]byte{byte(SecureVariablesSinkSnapshot)})
+		if err := encoder.Encode(checkpoint); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // Release is a no-op, as we just need to GC the pointer
 // to the state store snapshot. There is nothing to explicitly
 // cleanup.
@@ -3287,6 +3287,96 @@ func TestFSM_SnapshotRestore_Namespaces(t *testing.T) {
 	}
 }
 
+func TestFSM_RewriteSnapshotSecureVariables(t *testing.T) {
+	ci.Parallel(t)
+
+	fsm := testFSM(t)
+	testState := fsm.State()
+	ns1, ns2 := mock.Namespace(), mock.Namespace()
+	require.NoError(t, testState.UpsertNamespaces(5, []*structs.Namespace{ns1, ns2}))
+
+	sv1 := mock.SecureVariableEncrypted()
+	sv1.Path = "a"
+	sv2 := mock.SecureVariableEncrypted()
+	sv2.Namespace = ns1.Name
+	sv2.Path = "b"
+	sv3 := mock.SecureVariableEncrypted()
+	sv3.Namespace = ns1.Name
+	sv3.Path = "a"
+	require.NoError(t, testState.UpsertSecureVariables(structs.MsgTypeTestSetup, 10,
+		[]*structs.SecureVariableEncrypted{sv1, sv2, sv3}))
+
+	snap, err := fsm.Snapshot()
+	require.NoError(t, err)
+	defer snap.Release()
+	buf := bytes.NewBuffer(nil)
+	require.NoError(t, snap.Persist(&MockSink{buf, false}))
+
+	// rewrite persists the snapshot rewritten with opts into a new FSM
+	rewrite := func(opts *SnapshotSecureVariablesOptions) (*state.StateStore, error) {
+		var out bytes.Buffer
+		if err := RewriteSnapshotSecureVariables(bytes.NewReader(buf.Bytes()), &out, opts); err != nil {
+			return nil, err
+		}
+		fsm2 := testFSM(t)
+		require.NoError(t, fsm2.Restore(&MockSink{&out, false}))
+		return fsm2.State(), nil
+	}
+	countVars := func(s *state.StateStore, ns string) int {
+		iter, err := s.GetSecureVariablesByNamespace(nil, ns)
+		require.NoError(t, err)
+		n := 0
+		for raw := iter.Next(); raw != nil; raw = iter.Next() {
+			n++
+		}
+		return n
+	}
+
+	t.Run("exclude", func(t *testing.T) {
+		restored, err := rewrite(&SnapshotSecureVariablesOptions{Exclude: true})
+		require.NoError(t, err)
+		require.Zero(t, countVars(restored, structs.DefaultNamespace))
+		require.Zero(t, countVars(restored, ns1.Name))
+		quota, err := restored.SecureVariablesQuotaByNamespace(nil, ns1.Name)
+		require.NoError(t, err)
+		require.Nil(t, quota)
+
+		// Everything else is restored
+		out, err := restored.NamespaceByName(nil, ns2.Name)
+		require.NoError(t, err)
+		require.NotNil(t, out)
+	})
+
+	t.Run("namespace map", func(t *testing.T) {
+		restored, err := rewrite(&SnapshotSecureVariablesOptions{
+			NamespaceMap: map[string]string{ns1.Name: ns2.Name},
+		})
+		require.NoError(t, err)
+		require.Equal(t, 1, countVars(restored, structs.DefaultNamespace))
+		require.Zero(t, countVars(restored, ns1.Name))
+		require.Equal(t, 2, countVars(restored, ns2.Name))
+
+		quota, err := restored.SecureVariablesQuotaByNamespace(nil, ns2.Name)
+		require.NoError(t, err)
+		require.NotNil(t, quota)
+		require.Equal(t, uint64(len(sv2.Data)+len(sv3.Data)), quota.Size)
+	})
+
+	t.Run("conflicting paths", func(t *testing.T) {
+		_, err := rewrite(&SnapshotSecureVariablesOptions{
+			NamespaceMap: map[string]string{ns1.Name: structs.DefaultNamespace},
+		})
+		require.ErrorContains(t, err, `more than one secure variable would be restored to path "a"`)
+	})
+
+	t.Run("missing namespace", func(t *testing.T) {
+		_, err := rewrite(&SnapshotSecureVariablesOptions{
+			NamespaceMap: map[string]string{ns1.Name: "nope"},
+		})
+		require.EqualError(t, err, `namespace "nope" does not exist in the snapshot`)
+	})
+}
+
 func TestFSM_UpsertServiceRegistrations(t *testing.T) {
 	ci.Parallel(t)
 	fsm := testFSM(t)
@@ -3413,6 +3503,75 @@ func TestFSM_SnapshotRestore_SecureVariables(t *testing.T) {
 	require.ElementsMatch(t, restoredSVs, svs)
 }
 
+func TestFSM_SnapshotRestore_SecureVariablesSink(t *testing.T) {
+	ci.Parallel(t)
+
+	// Create our initial FSM which will be snapshotted.
+	fsm := testFSM(t)
+	testState := fsm.State()
+
+	// Register a sink so the changes to secure variables are kept.
+	req := structs.SecureVariablesSinkCheckpointRequest{
+		Checkpoints: []*structs.SecureVariablesSinkCheckpoint{{Name: "test"}},
+		Reset:       true,
+	}
+	buf, err := structs.Encode(structs.SecureVariablesSinkCheckpointRequestType, req)
+	require.NoError(t, err)
+	require.Nil(t, fsm.Apply(makeLog(buf)))
+
+	sv := mock.SecureVariableEncrypted()
+	require.NoError(t, testState.UpsertSecureVariables(structs.MsgTypeTestSetup, 10,
+		[]*structs.SecureVariableEncrypted{sv}))
+
+	// Perform a snapshot restore.
+	restoredFSM := testSnapshotRestore(t, fsm)
+	restoredState := restoredFSM.State()
+
+	checkpoint, err := restoredState.SecureVariablesSinkCheckpoint(nil, "test")
+	require.NoError(t, err)
+	require.NotNil(t, checkpoint)
+	require.Zero(t, checkpoint.LastIndex)
+
+	iter, err := restoredState.SecureVariableChangesAfter(memdb.NewWatchSet(), 0)
+	require.NoError(t, err)
+	raw := iter.Next()
+	require.NotNil(t, raw)
+	changes := raw.(*structs.SecureVariableChanges)
+	require.Equal(t, uint64(10), changes.Index)
+	require.Len(t, changes.Changes, 1)
+	require.Equal(t, sv.Path, changes.Changes[0].SecureVariable.Path)
+	require.Nil(t, iter.Next())
+}
+
+func TestFSM_SnapshotRestore_SecureVariableVersions(t *testing.T) {
+	ci.Parallel(t)
+
+	// Create our initial FSM which will be snapshotted.
+	fsm := testFSM(t)
+	testState := fsm.State()
+
+	// Upsert a secure variable and update it, so the first version is kept.
+	sv := mock.SecureVariableEncrypted()
+	require.NoError(t, testState.UpsertSecureVariables(
+		structs.MsgTypeTestSetup, 10, []*structs.SecureVariableEncrypted{sv}))
+	svU := sv.Copy()
+	svU.Data = []byte("updated")
+	require.NoError(t, testState.UpsertSecureVariables(
+		structs.MsgTypeTestSetup, 11, []*structs.SecureVariableEncrypted{&svU}))
+
+	versions, err := testState.GetSecureVariableVersions(memdb.NewWatchSet(), sv.Namespace, sv.Path)
+	require.NoError(t, err)
+	require.Len(t, versions, 1)
+
+	// Perform a snapshot restore and ensure the kept version is restored.
+	restoredFSM := testSnapshotRestore(t, fsm)
+	restoredState := restoredFSM.State()
+
+	restored, err := restoredState.GetSecureVariableVersions(memdb.NewWatchSet(), sv.Namespace, sv.Path)
+	require.NoError(t, err)
+	require.Equal(t, versions, restored)
+}
+
 func TestFSM_ACLEvents(t *testing.T) {
 	ci.Parallel(t)
 
@@ -8,6 +8,7 @@ import (
 	"github.com/hashicorp/go-hclog"
 	memdb "github.com/hashicorp/go-memdb"
 
+	"github.com/hashicorp/nomad/helper"
 	"github.com/hashicorp/nomad/helper/uuid"
 	"github.com/hashicorp/nomad/nomad/state"
 	"github.com/hashicorp/nomad/nomad/structs"
@@ -89,6 +90,90 @@ func (k *Keyring) Rotate(args *structs.KeyringRotateRootKeyRequest, reply *struc
 	return nil
 }
 
+// RotateStatus reports the progress of re-encrypting secure variables after
+// a full rotation.
+func (k *Keyring) RotateStatus(args *structs.KeyringRotateStatusRequest, reply *structs.KeyringRotateStatusResponse) error {
+	if done, err := k.srv.forward("Keyring.RotateStatus", args, args, reply); done {
+		return err
+	}
+
+	defer metrics.MeasureSince([]string{"nomad", "keyring", "rotate_status"}, time.Now())
+
+	if aclObj, err := k.srv.ResolveToken(args.AuthToken); err != nil {
+		return err
+	} else if aclObj != nil && !aclObj.IsManagement() {
+		return structs.ErrPermissionDenied
+	}
+
+	// Setup the blocking query
+	opts := blockingOptions{
+		queryOpts: &args.QueryOptions,
+		queryMeta: &reply.QueryMeta,
+		run: func(ws memdb.WatchSet, s *state.StateStore) error {
+			iter, err := s.RootKeyMetas(ws)
+			if err != nil {
+				return err
+			}
+
+			reply.ActiveKeyID = ""
+			reply.Rekeying = []*structs.KeyringRekeyStatus{}
+			for {
+				raw := iter.Next()
+				if raw == nil {
+					break
+				}
+				keyMeta := raw.(*structs.RootKeyMeta)
+				if keyMeta.Active() {
+					reply.ActiveKeyID = keyMeta.KeyID
+				}
+				if !keyMeta.Rekeying() {
+					continue
+				}
+
+				varIter, err := s.GetSecureVariablesByKeyID(ws, keyMeta.KeyID)
+				if err != nil {
+					return err
+				}
+				versionIter, err := s.GetSecureVariableVersionsByKeyID(ws, keyMeta.KeyID)
+				if err != nil {
+					return err
+				}
+				remaining := 0
+				for raw := varIter.Next(); raw != nil; raw = varIter.Next() {
+					remaining++
+				}
+				for raw := versionIter.Next(); raw != nil; raw = versionIter.Next() {
+					remaining++
+				}
+				reply.Rekeying = append(reply.Rekeying, &structs.KeyringRekeyStatus{
+					KeyID:      keyMeta.KeyID,
+					CreateTime: keyMeta.CreateTime,
+					Remaining:  remaining,
+				})
+			}
+
+			// The index changes as keys change state and as secure
+			// variables are re-encrypted
+			keyIndex, err := s.Index(state.TableRootKeyMeta)
+			if err != nil {
+				return err
+			}
+			varIndex, err := s.Index(state.TableSecureVariables)
+			if err != nil {
+				return err
+			}
+			versionIndex, err := s.Index(state.TableSecureVariablesHistory)
+			if err != nil {
+				return err
+			}
+			reply.Index = helper.Uint64Max(keyIndex, helper.Uint64Max(varIndex, versionIndex))
+			k.srv.setQueryMeta(&reply.QueryMeta)
+			return nil
+		},
+	}
+	return k.srv.blockingRPC(&opts)
+}
+
 func (k *Keyring) List(args *structs.KeyringListRootKeyMetaRequest, reply *structs.KeyringListRootKeyMetaResponse) error {
 	if done, err := k.srv.forward("Keyring.List", args, args, reply); done {
 		return err
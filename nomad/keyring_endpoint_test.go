@@ -9,6 +9,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/hashicorp/nomad/ci"
+	"github.com/hashicorp/nomad/nomad/mock"
 	"github.com/hashicorp/nomad/nomad/structs"
 	"github.com/hashicorp/nomad/testutil"
 )
@@ -292,3 +293,59 @@ func TestKeyringEndpoint_Rotate(t *testing.T) {
 	gotKey := getResp.Key
 	require.Len(t, gotKey.Key, 32)
 }
+
+// TestKeyringEndpoint_RotateStatus exercises reporting the progress of
+// re-encrypting secure variables
+func TestKeyringEndpoint_RotateStatus(t *testing.T) {
+
+	ci.Parallel(t)
+	srv, rootToken, shutdown := TestACLServer(t, func(c *Config) {
+		c.NumSchedulers = 0 // Prevent automatic dequeue
+	})
+	defer shutdown()
+	testutil.WaitForLeader(t, srv.RPC)
+	codec := rpcClient(t, srv)
+	store := srv.fsm.State()
+
+	// Setup a key being rekeyed with secure variables still encrypted by it
+	oldKey, err := structs.NewRootKey(structs.EncryptionAlgorithmAES256GCM)
+	require.NoError(t, err)
+	oldKey.Meta.SetRekeying()
+	require.NoError(t, store.UpsertRootKeyMeta(1000, oldKey.Meta, false))
+
+	newKey, err := structs.NewRootKey(structs.EncryptionAlgorithmAES256GCM)
+	require.NoError(t, err)
+	newKey.Meta.SetActive()
+	require.NoError(t, store.UpsertRootKeyMeta(1001, newKey.Meta, false))
+
+	svs := []*structs.SecureVariableEncrypted{}
+	for i := 0; i < 3; i++ {
+		sv := mock.SecureVariableEncrypted()
+		sv.KeyID = oldKey.Meta.KeyID
+		svs = append(svs, sv)
+	}
+	require.NoError(t, store.UpsertSecureVariables(structs.MsgTypeTestSetup, 1002, svs))
+
+	// A previous version encrypted with the key also remains to be rekeyed
+	updated := svs[0].Copy()
+	updated.KeyID = newKey.Meta.KeyID
+	require.NoError(t, store.UpsertSecureVariables(structs.MsgTypeTestSetup, 1003,
+		[]*structs.SecureVariableEncrypted{&updated}))
+
+	statusReq := &structs.KeyringRotateStatusRequest{
+		QueryOptions: structs.QueryOptions{Region: "global"},
+	}
+	var statusResp structs.KeyringRotateStatusResponse
+
+	err = msgpackrpc.CallWithCodec(codec, "Keyring.RotateStatus", statusReq, &statusResp)
+	require.EqualError(t, err, structs.ErrPermissionDenied.Error())
+
+	statusReq.AuthToken = rootToken.SecretID
+	err = msgpackrpc.CallWithCodec(codec, "Keyring.RotateStatus", statusReq, &statusResp)
+	require.NoError(t, err)
+	require.Equal(t, uint64(1003), statusResp.Index)
+	require.Equal(t, newKey.Meta.KeyID, statusResp.ActiveKeyID)
+	require.Len(t, statusResp.Rekeying, 1)
+	require.Equal(t, oldKey.Meta.KeyID, statusResp.Rekeying[0].KeyID)
+	require.Equal(t, 3, statusResp.Rekeying[0].Remaining)
+}
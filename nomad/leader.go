@@ -350,6 +350,9 @@ func (s *Server) establishLeadership(stopCh chan struct{}) error {
 	// Scheduler periodic jobs
 	go s.schedulePeriodic(stopCh)
 
+	// Garbage collect secure variables as they expire
+	go s.scheduleSecureVariablesExpiry(stopCh)
+
 	// Reap any failed evaluations
 	go s.reapFailedEvaluations(stopCh)
 
@@ -365,6 +368,9 @@ func (s *Server) establishLeadership(stopCh chan struct{}) error {
 	// Periodically publish job status metrics
 	go s.publishJobStatusMetrics(stopCh)
 
+	// Forward secure variable changes to the configured sinks
+	go s.forwardSecureVariableChanges(stopCh)
+
 	// Setup the heartbeat timers. This is done both when starting up or when
 	// a leader fail over happens. Since the timers are maintained by the leader
 	// node, effectively this means all the timers are renewed at the time of failover.
@@ -771,6 +777,8 @@ func (s *Server) schedulePeriodic(stopCh chan struct{}) {
 	defer rootKeyGC.Stop()
 	secureVariablesRekey := time.NewTicker(s.config.SecureVariablesRekeyInterval)
 	defer secureVariablesRekey.Stop()
+	secureVariablesGC := time.NewTicker(s.config.SecureVariablesGCInterval)
+	defer secureVariablesGC.Stop()
 
 	// getLatest grabs the latest index from the state store. It returns true if
 	// the index was retrieved successfully.
@@ -827,6 +835,10 @@ func (s *Server) schedulePeriodic(stopCh chan struct{}) {
 			if index, ok := getLatest(); ok {
 				s.evalBroker.Enqueue(s.coreJobEval(structs.CoreJobSecureVariablesRekey, index))
 			}
+		case <-secureVariablesGC.C:
+			if index, ok := getLatest(); ok {
+				s.evalBroker.Enqueue(s.coreJobEval(structs.CoreJobSecureVariablesGC, index))
+			}
 
 		case <-stopCh:
 			return
@@ -834,6 +846,61 @@ func (s *Server) schedulePeriodic(stopCh chan struct{}) {
 	}
 }
 
+// scheduleSecureVariablesExpiry enqueues the garbage collection of secure
+// variables whenever one of them expires, so that blocking queries on them
+// return once they're gone rather than at the next periodic collection.
+func (s *Server) scheduleSecureVariablesExpiry(stopCh chan struct{}) {
+	ctx, cancel := context.WithCancel(s.shutdownCtx)
+	defer cancel()
+	go func() {
+		select {
+		case <-stopCh:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	for {
+		store := s.fsm.State()
+		ws := memdb.NewWatchSet()
+		ws.Add(store.AbandonCh())
+
+		next, err := store.NextSecureVariableExpiry(ws, time.Now())
+		if err != nil {
+			s.logger.Error("failed to find the next secure variable expiry", "error", err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(time.Second):
+			}
+			continue
+		}
+
+		// Wait for the next secure variable to expire, or for a write that
+		// may change which one expires next
+		var waitCtx context.Context = ctx
+		waitCancel := func() {}
+		if !next.IsZero() {
+			waitCtx, waitCancel = context.WithDeadline(ctx, next)
+		}
+		err = ws.WatchCtx(waitCtx)
+		waitCancel()
+		if ctx.Err() != nil {
+			return
+		}
+		if err == nil {
+			continue
+		}
+
+		index, err := store.LatestIndex()
+		if err != nil {
+			s.logger.Error("failed to determine state store's index", "error", err)
+			continue
+		}
+		s.evalBroker.Enqueue(s.coreJobEval(structs.CoreJobSecureVariablesGC, index))
+	}
+}
+
 // coreJobEval returns an evaluation for a core job
 func (s *Server) coreJobEval(job string, modifyIndex uint64) *structs.Evaluation {
 	return &structs.Evaluation{
@@ -0,0 +1,117 @@
+package nomad
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/hashicorp/nomad/helper"
+	"github.com/hashicorp/nomad/nomad/stream"
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+// secureVariableAccessACLDelegate resolves the tokens of subscribers to the
+// secure variable access events against the current state store.
+type secureVariableAccessACLDelegate struct {
+	srv *Server
+}
+
+func (a *secureVariableAccessACLDelegate) TokenProvider() stream.ACLTokenProvider {
+	resolver, _ := a.srv.State().Snapshot()
+	return resolver
+}
+
+// setupSecureVariableAccessBroker creates the event broker serving the
+// VariableAccess topic. Access events aren't written to Raft, so they are kept
+// apart from the state store's events to not interleave indexes that aren't
+// Raft indexes with those that are.
+func (s *Server) setupSecureVariableAccessBroker(ctx context.Context) error {
+	if !s.config.EnableEventBroker {
+		return nil
+	}
+	broker, err := stream.NewEventBroker(ctx, &secureVariableAccessACLDelegate{s}, stream.EventBrokerCfg{
+		EventBufferSize: s.config.EventBufferSize,
+		Logger:          s.logger.Named("secure_variable_access"),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create secure variable access event broker: %v", err)
+	}
+	s.secureVariableAccess = broker
+	return nil
+}
+
+// eventBrokerForTopics returns the event broker that serves the requested
+// topics. The VariableAccess topic has a broker of its own, so it can't be
+// subscribed to along with other topics.
+func (s *Server) eventBrokerForTopics(topics map[structs.Topic][]string) (*stream.EventBroker, error) {
+	if _, ok := topics[structs.TopicVariableAccess]; !ok {
+		return s.State().EventBroker()
+	}
+	if len(topics) > 1 {
+		return nil, fmt.Errorf("topic %s can't be combined with other topics", structs.TopicVariableAccess)
+	}
+	if s.secureVariableAccess == nil {
+		return nil, fmt.Errorf("EventBroker not configured")
+	}
+	return s.secureVariableAccess, nil
+}
+
+// publishAccess publishes an event to the VariableAccess topic for each access
+// to a secure variable, recording the identity that made the request. These
+// events are best effort: they are only published by the server that handled
+// the request, they are lost if it restarts, and failing to publish them
+// doesn't fail the request. Writes and deletes are also published durably to
+// the Variable topic when they are applied by the FSM.
+func (sv *SecureVariables) publishAccess(authToken, eventType string, accesses ...*structs.SecureVariableAccess) {
+	broker := sv.srv.secureVariableAccess
+	if broker == nil {
+		// The event broker is disabled
+		return
+	}
+
+	var accessorID string
+	var claims *structs.IdentityClaims
+	var err error
+	if authToken != "" && !helper.IsUUID(authToken) {
+		claims, err = sv.encrypter.VerifyClaim(authToken)
+	} else {
+		var token *structs.ACLToken
+		token, err = sv.srv.ResolveSecretToken(authToken)
+		if token != nil {
+			accessorID = token.AccessorID
+		}
+	}
+	if err != nil {
+		// The request was already authorized, so this is only the leader
+		// token, which isn't stored in the state
+		sv.logger.Trace("failed to resolve identity of secure variable access", "error", err)
+	}
+
+	// Access events are numbered in the order this server handled them
+	index := atomic.AddUint64(&sv.srv.secureVariableAccessIndex, 1)
+
+	events := make([]structs.Event, len(accesses))
+	for i, access := range accesses {
+		access.AccessorID = accessorID
+		var filterKeys []string
+		if accessorID != "" {
+			filterKeys = []string{accessorID}
+		}
+		if claims != nil {
+			access.AllocationID = claims.AllocationID
+			access.JobID = claims.JobID
+			access.TaskName = claims.TaskName
+			filterKeys = []string{claims.AllocationID, claims.JobID}
+		}
+		events[i] = structs.Event{
+			Topic:      structs.TopicVariableAccess,
+			Type:       eventType,
+			Key:        access.Path,
+			Namespace:  access.Namespace,
+			FilterKeys: filterKeys,
+			Index:      index,
+			Payload:    &structs.SecureVariableAccessEvent{SecureVariableAccess: access},
+		}
+	}
+	broker.Publish(&structs.Events{Index: index, Events: events})
+}
@@ -0,0 +1,37 @@
+package nomad
+
+import (
+	"testing"
+
+	"github.com/hashicorp/nomad/ci"
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_eventBrokerForTopics(t *testing.T) {
+	ci.Parallel(t)
+
+	srv, cleanup := TestServer(t, nil)
+	defer cleanup()
+
+	stateBroker, err := srv.State().EventBroker()
+	require.NoError(t, err)
+
+	broker, err := srv.eventBrokerForTopics(map[structs.Topic][]string{
+		structs.TopicVariable: {"*"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, stateBroker, broker)
+
+	broker, err = srv.eventBrokerForTopics(map[structs.Topic][]string{
+		structs.TopicVariableAccess: {"*"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, srv.secureVariableAccess, broker)
+
+	_, err = srv.eventBrokerForTopics(map[structs.Topic][]string{
+		structs.TopicVariableAccess: {"*"},
+		structs.TopicVariable:       {"*"},
+	})
+	require.EqualError(t, err, "topic VariableAccess can't be combined with other topics")
+}
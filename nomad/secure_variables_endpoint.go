@@ -122,6 +122,17 @@ func (sv *SecureVariables) Upsert(
 		return err
 	}
 
+	accesses := make([]*structs.SecureVariableAccess, len(uArgs.Data))
+	for i, v := range uArgs.Data {
+		accesses[i] = &structs.SecureVariableAccess{
+			Namespace:   v.Namespace,
+			Path:        v.Path,
+			ModifyIndex: index,
+			CheckIndex:  args.CheckIndex,
+		}
+	}
+	sv.publishAccess(args.AuthToken, structs.TypeSecureVariableUpserted, accesses...)
+
 	// Update the index. There is no need to floor this as we are writing to
 	// state and therefore will get a non-zero index response.
 	reply.Index = index
@@ -175,12 +186,229 @@ func (sv *SecureVariables) Delete(
 		return err
 	}
 
+	sv.publishAccess(args.AuthToken, structs.TypeSecureVariableDeleted,
+		&structs.SecureVariableAccess{
+			Namespace:  args.RequestNamespace(),
+			Path:       args.Path,
+			CheckIndex: args.CheckIndex,
+		})
+
 	// Update the index. There is no need to floor this as we are writing to
 	// state and therefore will get a non-zero index response.
 	reply.Index = index
 	return nil
 }
 
+// Copy copies a secure variable, or every secure variable under a path, to a
+// new path and optionally another namespace. With Move the copied variables
+// are deleted. All the variables are written in a single Raft transaction, so
+// either every variable is copied or none are.
+func (sv *SecureVariables) Copy(
+	args *structs.SecureVariablesCopyRequest,
+	reply *structs.SecureVariablesCopyResponse) error {
+
+	if done, err := sv.srv.forward(structs.SecureVariablesCopyRPCMethod, args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"nomad", "secure_variables", "copy"}, time.Now())
+
+	srcNS := args.RequestNamespace()
+	destNS := args.DestNamespace
+	if destNS == "" {
+		destNS = srcNS
+	}
+	srcPath := strings.TrimSuffix(args.Path, "/")
+	destPath := strings.TrimSuffix(args.DestPath, "/")
+	switch {
+	case srcPath == "" || destPath == "":
+		return structs.NewErrRPCCoded(http.StatusBadRequest, "source and destination paths are required")
+	case srcNS == structs.AllNamespacesSentinel || destNS == structs.AllNamespacesSentinel:
+		return structs.NewErrRPCCoded(http.StatusBadRequest, "can not target wildcard (\"*\") namespace")
+	case srcNS == destNS && srcPath == destPath:
+		return structs.NewErrRPCCoded(http.StatusBadRequest, "source and destination are the same")
+	}
+
+	aclObj, err := sv.srv.ResolveToken(args.AuthToken)
+	if err != nil {
+		return err
+	}
+
+	// Check access to the requested source before looking it up, so that a
+	// token without access can't tell whether the secure variables exist
+	srcCap := acl.PolicyRead
+	if args.Move {
+		srcCap = acl.PolicyWrite
+	}
+	if aclObj != nil {
+		allowed := aclObj.AllowSecureVariableOperation(srcNS, srcPath, srcCap)
+		if args.Recurse {
+			allowed = allowed || aclObj.AllowSecureVariableOperation(srcNS, srcPath+"/", srcCap)
+		}
+		if !allowed {
+			return structs.ErrPermissionDenied
+		}
+	}
+
+	snap, err := sv.srv.fsm.State().Snapshot()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	var sources []*structs.SecureVariableEncrypted
+	if args.Recurse {
+		iter, err := snap.GetSecureVariablesByNamespaceAndPrefix(nil, srcNS, srcPath)
+		if err != nil {
+			return err
+		}
+		iter = filterExpiredSecureVariables(iter, now)
+		for raw := iter.Next(); raw != nil; raw = iter.Next() {
+			// Only copy the variables at or below the path, and not those
+			// whose last path segment merely starts with it
+			v := raw.(*structs.SecureVariableEncrypted)
+			if v.Path == srcPath || strings.HasPrefix(v.Path, srcPath+"/") {
+				sources = append(sources, v)
+			}
+		}
+	} else {
+		v, err := snap.GetSecureVariable(nil, srcNS, srcPath)
+		if err != nil {
+			return err
+		}
+		if v = liveSecureVariable(v, now); v != nil {
+			sources = append(sources, v)
+		}
+	}
+	if len(sources) == 0 {
+		return structs.NewErrRPCCoded(http.StatusNotFound, "secure variable not found")
+	}
+
+	uArgs := structs.SecureVariablesEncryptedCopyRequest{
+		Sources:      make([]*structs.SecureVariableMetadata, len(sources)),
+		Data:         make([]*structs.SecureVariableEncrypted, len(sources)),
+		Move:         args.Move,
+		WriteRequest: args.WriteRequest,
+	}
+	copies := make([]*structs.SecureVariableCopy, len(sources))
+	for i, src := range sources {
+		// The encrypted data isn't bound to the path, so it is copied as is
+		dest := src.Copy()
+		dest.Namespace = destNS
+		dest.Path = destPath + strings.TrimPrefix(src.Path, srcPath)
+
+		if aclObj != nil {
+			if !aclObj.AllowSecureVariableOperation(srcNS, src.Path, srcCap) ||
+				!aclObj.AllowSecureVariableOperation(destNS, dest.Path, acl.PolicyWrite) {
+				return structs.ErrPermissionDenied
+			}
+		}
+
+		// The FSM checks this again when applying the copy, but checking
+		// here reports the common case as a conflict. An expired secure
+		// variable is replaced by the copy.
+		existing, err := snap.GetSecureVariable(nil, dest.Namespace, dest.Path)
+		if err != nil {
+			return err
+		}
+		if liveSecureVariable(existing, now) != nil {
+			return structs.NewErrRPCCodedf(http.StatusConflict,
+				"secure variable %q in namespace %q already exists", dest.Path, dest.Namespace)
+		}
+		if existing != nil {
+			expired := existing.SecureVariableMetadata
+			uArgs.Expired = append(uArgs.Expired, &expired)
+		}
+
+		meta := src.SecureVariableMetadata
+		uArgs.Sources[i] = &meta
+		uArgs.Data[i] = &dest
+		copies[i] = &structs.SecureVariableCopy{
+			Namespace:     src.Namespace,
+			Path:          src.Path,
+			DestNamespace: dest.Namespace,
+			DestPath:      dest.Path,
+		}
+	}
+
+	// Moving secure variables within a namespace doesn't change its usage
+	if !args.Move || srcNS != destNS {
+		if err := sv.enforceQuota(structs.SecureVariablesEncryptedUpsertRequest{
+			Data:         uArgs.Data,
+			WriteRequest: args.WriteRequest,
+		}); err != nil {
+			return err
+		}
+	}
+
+	// Update via Raft.
+	out, index, err := sv.srv.raftApply(structs.SecureVariableCopyRequestType, uArgs)
+	if err != nil {
+		return err
+	}
+
+	// Check if the FSM response, which is an interface, contains an error.
+	if err, ok := out.(error); ok && err != nil {
+		return err
+	}
+
+	written := make([]*structs.SecureVariableAccess, len(uArgs.Data))
+	for i, dest := range uArgs.Data {
+		written[i] = &structs.SecureVariableAccess{
+			Namespace:   dest.Namespace,
+			Path:        dest.Path,
+			ModifyIndex: index,
+		}
+	}
+	sv.publishAccess(args.AuthToken, structs.TypeSecureVariableUpserted, written...)
+	if args.Move {
+		deleted := make([]*structs.SecureVariableAccess, len(uArgs.Sources))
+		for i, src := range uArgs.Sources {
+			deleted[i] = &structs.SecureVariableAccess{
+				Namespace:  src.Namespace,
+				Path:       src.Path,
+				CheckIndex: &src.ModifyIndex,
+			}
+		}
+		sv.publishAccess(args.AuthToken, structs.TypeSecureVariableDeleted, deleted...)
+	}
+
+	reply.Copies = copies
+	reply.Index = index
+	return nil
+}
+
+// Rekey replaces the encrypted data of secure variables and kept previous
+// versions that have been re-encrypted with the active root key. It is only
+// used by the core job that rekeys secure variables after a full rotation.
+// The user's data is unchanged, so no versions are kept and no change events
+// are published.
+func (sv *SecureVariables) Rekey(
+	args *structs.SecureVariablesRekeyRequest,
+	reply *structs.GenericResponse) error {
+
+	if done, err := sv.srv.forward(structs.SecureVariablesRekeyRPCMethod, args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"nomad", "secure_variables", "rekey"}, time.Now())
+
+	if aclObj, err := sv.srv.ResolveToken(args.AuthToken); err != nil {
+		return err
+	} else if aclObj != nil && !aclObj.IsManagement() {
+		return structs.ErrPermissionDenied
+	}
+
+	out, index, err := sv.srv.raftApply(structs.SecureVariableRekeyRequestType, args)
+	if err != nil {
+		return err
+	}
+	if err, ok := out.(error); ok && err != nil {
+		return err
+	}
+
+	reply.Index = index
+	return nil
+}
+
 // Read is used to get a specific secure variable
 func (sv *SecureVariables) Read(args *structs.SecureVariablesReadRequest, reply *structs.SecureVariablesReadResponse) error {
 	if done, err := sv.srv.forward(structs.SecureVariablesReadRPCMethod, args, args, reply); done {
@@ -205,6 +433,19 @@ func (sv *SecureVariables) Read(args *structs.SecureVariablesReadRequest, reply
 				return err
 			}
 
+			// The previous versions of an expired secure variable are
+			// treated as missing along with it
+			out = liveSecureVariable(out, time.Now())
+
+			// A previous version is read from the history unless it is
+			// the current one
+			if args.Version != 0 && out != nil && out.ModifyIndex != args.Version {
+				out, err = s.GetSecureVariableVersion(ws, args.RequestNamespace(), args.Path, args.Version)
+				if err != nil {
+					return err
+				}
+			}
+
 			// Setup the output
 			reply.Data = nil
 			if out != nil {
@@ -220,7 +461,81 @@ func (sv *SecureVariables) Read(args *structs.SecureVariablesReadRequest, reply
 			}
 			return nil
 		}}
-	return sv.srv.blockingRPC(&opts)
+	if err := sv.srv.blockingRPC(&opts); err != nil {
+		return err
+	}
+
+	if reply.Data != nil {
+		sv.publishAccess(args.AuthToken, structs.TypeSecureVariableRead,
+			&structs.SecureVariableAccess{
+				Namespace:   reply.Data.Namespace,
+				Path:        reply.Data.Path,
+				ModifyIndex: reply.Data.ModifyIndex,
+			})
+	}
+	return nil
+}
+
+// History is used to list the versions of a specific secure variable, newest
+// first. The current version is followed by the kept previous versions.
+func (sv *SecureVariables) History(
+	args *structs.SecureVariablesHistoryRequest,
+	reply *structs.SecureVariablesHistoryResponse) error {
+
+	if done, err := sv.srv.forward(structs.SecureVariablesHistoryRPCMethod, args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"nomad", "secure_variables", "history"}, time.Now())
+
+	err := sv.handleMixedAuthEndpoint(args.QueryOptions,
+		acl.PolicyRead, args.Path)
+	if err != nil {
+		return err
+	}
+
+	return sv.srv.blockingRPC(&blockingOptions{
+		queryOpts: &args.QueryOptions,
+		queryMeta: &reply.QueryMeta,
+		run: func(ws memdb.WatchSet, stateStore *state.StateStore) error {
+			out, err := stateStore.GetSecureVariable(ws, args.RequestNamespace(), args.Path)
+			if err != nil {
+				return err
+			}
+			versions, err := stateStore.GetSecureVariableVersions(ws, args.RequestNamespace(), args.Path)
+			if err != nil {
+				return err
+			}
+
+			// The previous versions of an expired secure variable are
+			// treated as missing along with it
+			if out = liveSecureVariable(out, time.Now()); out != nil {
+				versions = append([]*structs.SecureVariableEncrypted{out}, versions...)
+			} else {
+				versions = nil
+			}
+
+			svs := make([]*structs.SecureVariableMetadata, len(versions))
+			for i, version := range versions {
+				svStub := version.SecureVariableMetadata
+				svs[i] = &svStub
+			}
+			reply.Data = svs
+
+			// Versions are dropped when their root key is deleted, which
+			// only updates the index of the history table.
+			if err := sv.srv.setReplyQueryMeta(stateStore, state.TableSecureVariables, &reply.QueryMeta); err != nil {
+				return err
+			}
+			historyIndex, err := stateStore.Index(state.TableSecureVariablesHistory)
+			if err != nil {
+				return err
+			}
+			if historyIndex > reply.Index {
+				reply.Index = historyIndex
+			}
+			return nil
+		},
+	})
 }
 
 // List is used to list secure variables held within state. It supports single
@@ -271,13 +586,15 @@ func (sv *SecureVariables) List(
 				},
 			)
 
+			fltrIter := filterExpiredSecureVariables(iter, time.Now())
+
 			// Set up our output after we have checked the error.
 			var svs []*structs.SecureVariableMetadata
 
 			// Build the paginator. This includes the function that is
 			// responsible for appending a variable to the secure variables
 			// stubs slice.
-			paginatorImpl, err := paginator.NewPaginator(iter, tokenizer, nil, args.QueryOptions,
+			paginatorImpl, err := paginator.NewPaginator(fltrIter, tokenizer, nil, args.QueryOptions,
 				func(raw interface{}) error {
 					sv := raw.(*structs.SecureVariableEncrypted)
 					svStub := sv.SecureVariableMetadata
@@ -308,6 +625,84 @@ func (sv *SecureVariables) List(
 	})
 }
 
+// Tree is used to list the secure variables under a prefix as a tree of path
+// segments, along with the number of items each holds and its size. The
+// listing is paginated by secure variable, so each page is a tree of its
+// own.
+func (sv *SecureVariables) Tree(
+	args *structs.SecureVariablesTreeRequest,
+	reply *structs.SecureVariablesTreeResponse) error {
+
+	if done, err := sv.srv.forward(structs.SecureVariablesTreeRPCMethod, args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"nomad", "secure_variables", "tree"}, time.Now())
+
+	if args.RequestNamespace() == structs.AllNamespacesSentinel {
+		return structs.NewErrRPCCoded(http.StatusBadRequest, "can not target wildcard (\"*\") namespace")
+	}
+
+	// The item counts are only known by decrypting the secure variables, so
+	// the tree requires read rather than list access.
+	err := sv.handleMixedAuthEndpoint(args.QueryOptions,
+		acl.PolicyRead, args.Prefix)
+	if err != nil {
+		return err
+	}
+
+	return sv.srv.blockingRPC(&blockingOptions{
+		queryOpts: &args.QueryOptions,
+		queryMeta: &reply.QueryMeta,
+		run: func(ws memdb.WatchSet, stateStore *state.StateStore) error {
+
+			iter, err := stateStore.GetSecureVariablesByNamespaceAndPrefix(ws, args.RequestNamespace(), args.Prefix)
+			if err != nil {
+				return err
+			}
+
+			tokenizer := paginator.NewStructsTokenizer(iter,
+				paginator.StructsTokenizerOptions{
+					WithNamespace: true,
+					WithID:        true,
+				},
+			)
+
+			fltrIter := filterExpiredSecureVariables(iter, time.Now())
+
+			var stubs []*structs.SecureVariableTreeStub
+			paginatorImpl, err := paginator.NewPaginator(fltrIter, tokenizer, nil, args.QueryOptions,
+				func(raw interface{}) error {
+					ev := raw.(*structs.SecureVariableEncrypted)
+					dv, err := sv.decrypt(ev)
+					if err != nil {
+						return err
+					}
+					stubs = append(stubs, &structs.SecureVariableTreeStub{
+						SecureVariableMetadata: ev.SecureVariableMetadata,
+						ItemCount:              len(dv.Items),
+						Size:                   len(ev.Data),
+					})
+					return nil
+				})
+			if err != nil {
+				return structs.NewErrRPCCodedf(
+					http.StatusBadRequest, "failed to create result paginator: %v", err)
+			}
+
+			nextToken, err := paginatorImpl.Page()
+			if err != nil {
+				return structs.NewErrRPCCodedf(
+					http.StatusBadRequest, "failed to read result page: %v", err)
+			}
+
+			reply.Data = structs.NewSecureVariableTree(stubs)
+			reply.NextToken = nextToken
+
+			return sv.srv.setReplyQueryMeta(stateStore, state.TableSecureVariables, &reply.QueryMeta)
+		},
+	})
+}
+
 // listAllSecureVariables is used to list secure variables held within
 // state where the caller has used the namespace wildcard identifier.
 func (s *SecureVariables) listAllSecureVariables(
@@ -366,6 +761,7 @@ func (s *SecureVariables) listAllSecureVariables(
 
 			// Wrap the SecureVariables iterator with a FilterIterator to
 			// eliminate invalid values before sending them to the paginator.
+			now := time.Now()
 			fltrIter := memdb.NewFilterIterator(iter, func(raw interface{}) bool {
 
 				// Values are filtered when the func returns true.
@@ -376,7 +772,7 @@ func (s *SecureVariables) listAllSecureVariables(
 				if !strings.HasPrefix(sv.Path, args.Prefix) {
 					return true
 				}
-				return false
+				return liveSecureVariable(sv, now) == nil
 			})
 
 			// Build the paginator. This includes the function that is
@@ -548,6 +944,11 @@ func (s *SecureVariables) validateCAS(cidx uint64, namespace, path string, confl
 	if err != nil {
 		return fmt.Errorf("cas error: %w", err)
 	}
+	// Expired secure variables are treated as missing, unless the check is
+	// against the expired version itself, as the garbage collection does.
+	if exist != nil && exist.ModifyIndex != cidx {
+		exist = liveSecureVariable(exist, time.Now())
+	}
 	if exist == nil && cidx != 0 {
 		// return a zero value with the namespace and path applied
 		zeroVal := &structs.SecureVariableDecrypted{
@@ -577,3 +978,21 @@ func (s *SecureVariables) validateCAS(cidx uint64, namespace, path string, confl
 
 	return nil
 }
+
+// liveSecureVariable returns the secure variable unless it is nil or has
+// expired. Expired secure variables are treated as missing until the core job
+// deletes them, which the leader schedules for when they expire.
+func liveSecureVariable(sv *structs.SecureVariableEncrypted, now time.Time) *structs.SecureVariableEncrypted {
+	if sv == nil || sv.Expired(now) {
+		return nil
+	}
+	return sv
+}
+
+// filterExpiredSecureVariables wraps an iterator of secure variables to skip
+// the expired ones.
+func filterExpiredSecureVariables(iter memdb.ResultIterator, now time.Time) *memdb.FilterIterator {
+	return memdb.NewFilterIterator(iter, func(raw interface{}) bool {
+		return liveSecureVariable(raw.(*structs.SecureVariableEncrypted), now) == nil
+	})
+}
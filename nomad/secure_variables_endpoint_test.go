@@ -1,14 +1,17 @@
 package nomad
 
 import (
+	"context"
 	"fmt"
 	"math/rand"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/nomad/acl"
 	"github.com/hashicorp/nomad/ci"
 	"github.com/hashicorp/nomad/nomad/mock"
+	"github.com/hashicorp/nomad/nomad/stream"
 	"github.com/hashicorp/nomad/nomad/structs"
 	"github.com/hashicorp/nomad/testutil"
 	"github.com/stretchr/testify/require"
@@ -231,3 +234,341 @@ func TestSecureVariablesEndpoint_auth(t *testing.T) {
 	}
 
 }
+
+func TestSecureVariablesEndpoint_PublishAccess(t *testing.T) {
+
+	ci.Parallel(t)
+	srv, rootToken, shutdown := TestACLServer(t, func(c *Config) {
+		c.NumSchedulers = 0 // Prevent automatic dequeue
+	})
+	defer shutdown()
+	testutil.WaitForLeader(t, srv.RPC)
+
+	alloc := mock.Alloc()
+	alloc.ClientStatus = structs.AllocClientStatusRunning
+	store := srv.fsm.State()
+	require.NoError(t, store.UpsertAllocs(
+		structs.MsgTypeTestSetup, 1000, []*structs.Allocation{alloc}))
+	idToken, err := srv.encrypter.SignClaims(alloc.ToTaskIdentityClaims("web"))
+	require.NoError(t, err)
+
+	sub, err := srv.secureVariableAccess.Subscribe(&stream.SubscribeRequest{
+		Topics:    map[structs.Topic][]string{structs.TopicVariableAccess: {"*"}},
+		Namespace: structs.DefaultNamespace,
+	})
+	require.NoError(t, err)
+	defer sub.Unsubscribe()
+
+	path := "jobs/" + alloc.JobID
+	sv := mock.SecureVariable()
+	sv.Namespace = structs.DefaultNamespace
+	sv.Path = path
+
+	upsertResp := new(structs.SecureVariablesUpsertResponse)
+	require.NoError(t, srv.RPC(structs.SecureVariablesUpsertRPCMethod,
+		&structs.SecureVariablesUpsertRequest{
+			Data: []*structs.SecureVariableDecrypted{sv},
+			WriteRequest: structs.WriteRequest{
+				Region:    "global",
+				AuthToken: rootToken.SecretID,
+			},
+		}, upsertResp))
+
+	readResp := new(structs.SecureVariablesReadResponse)
+	require.NoError(t, srv.RPC(structs.SecureVariablesReadRPCMethod,
+		&structs.SecureVariablesReadRequest{
+			Path: path,
+			QueryOptions: structs.QueryOptions{
+				Region:    "global",
+				Namespace: structs.DefaultNamespace,
+				AuthToken: idToken,
+			},
+		}, readResp))
+
+	checkIndex := upsertResp.Index
+	require.NoError(t, srv.RPC(structs.SecureVariablesDeleteRPCMethod,
+		&structs.SecureVariablesDeleteRequest{
+			Path:       path,
+			CheckIndex: &checkIndex,
+			WriteRequest: structs.WriteRequest{
+				Region:    "global",
+				Namespace: structs.DefaultNamespace,
+				AuthToken: rootToken.SecretID,
+			},
+		}, new(structs.SecureVariablesDeleteResponse)))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var events []structs.Event
+	for len(events) < 3 {
+		out, err := sub.Next(ctx)
+		require.NoError(t, err)
+		events = append(events, out.Events...)
+	}
+	require.Len(t, events, 3)
+
+	accesses := make([]*structs.SecureVariableAccess, len(events))
+	for i, event := range events {
+		require.Equal(t, structs.TopicVariableAccess, event.Topic)
+		require.Equal(t, path, event.Key)
+		require.Equal(t, uint64(i+1), event.Index)
+		accesses[i] = event.Payload.(*structs.SecureVariableAccessEvent).SecureVariableAccess
+	}
+
+	require.Equal(t, structs.TypeSecureVariableUpserted, events[0].Type)
+	require.Equal(t, rootToken.AccessorID, accesses[0].AccessorID)
+	require.Equal(t, upsertResp.Index, accesses[0].ModifyIndex)
+	require.Nil(t, accesses[0].CheckIndex)
+
+	require.Equal(t, structs.TypeSecureVariableRead, events[1].Type)
+	require.Empty(t, accesses[1].AccessorID)
+	require.Equal(t, alloc.ID, accesses[1].AllocationID)
+	require.Equal(t, alloc.JobID, accesses[1].JobID)
+	require.Equal(t, "web", accesses[1].TaskName)
+	require.Equal(t, upsertResp.Index, accesses[1].ModifyIndex)
+
+	require.Equal(t, structs.TypeSecureVariableDeleted, events[2].Type)
+	require.Equal(t, rootToken.AccessorID, accesses[2].AccessorID)
+	require.Equal(t, &checkIndex, accesses[2].CheckIndex)
+}
+
+func TestSecureVariablesEndpoint_Rekey(t *testing.T) {
+
+	ci.Parallel(t)
+	srv, rootToken, shutdown := TestACLServer(t, func(c *Config) {
+		c.NumSchedulers = 0 // Prevent automatic dequeue
+	})
+	defer shutdown()
+	testutil.WaitForLeader(t, srv.RPC)
+
+	store := srv.fsm.State()
+	sv := mock.SecureVariableEncrypted()
+	require.NoError(t, store.UpsertSecureVariables(
+		structs.MsgTypeTestSetup, 1000, []*structs.SecureVariableEncrypted{sv}))
+
+	rekeyed := sv.Copy()
+	rekeyed.KeyID = "new"
+	rekeyed.Data = []byte("rekeyed")
+	req := &structs.SecureVariablesRekeyRequest{
+		Data: []*structs.SecureVariableEncrypted{&rekeyed},
+		WriteRequest: structs.WriteRequest{
+			Region: "global",
+		},
+	}
+
+	// Only a management token may rekey secure variables
+	token := mock.CreatePolicyAndToken(t, store, 1001, "rekey",
+		mock.NamespacePolicyWithSecureVariables(structs.DefaultNamespace, "",
+			[]string{"list-jobs"}, map[string][]string{"*": {"write"}}))
+	req.AuthToken = token.SecretID
+	err := srv.RPC(structs.SecureVariablesRekeyRPCMethod, req, &structs.GenericResponse{})
+	require.EqualError(t, err, structs.ErrPermissionDenied.Error())
+
+	// Errors from the FSM are returned to the caller
+	req.AuthToken = rootToken.SecretID
+	err = srv.RPC(structs.SecureVariablesRekeyRPCMethod, req, &structs.GenericResponse{})
+	require.EqualError(t, err, `root key "new" not found`)
+
+	keyMeta, err := store.GetActiveRootKeyMeta(nil)
+	require.NoError(t, err)
+	rekeyed.KeyID = keyMeta.KeyID
+	require.NoError(t, srv.RPC(structs.SecureVariablesRekeyRPCMethod, req, &structs.GenericResponse{}))
+
+	out, err := store.GetSecureVariable(nil, sv.Namespace, sv.Path)
+	require.NoError(t, err)
+	require.Equal(t, keyMeta.KeyID, out.KeyID)
+	require.Equal(t, sv.ModifyIndex, out.ModifyIndex)
+
+	versions, err := store.GetSecureVariableVersions(nil, sv.Namespace, sv.Path)
+	require.NoError(t, err)
+	require.Empty(t, versions)
+}
+
+func TestSecureVariablesEndpoint_Copy_ACL(t *testing.T) {
+
+	ci.Parallel(t)
+	srv, rootToken, shutdown := TestACLServer(t, func(c *Config) {
+		c.NumSchedulers = 0 // Prevent automatic dequeue
+	})
+	defer shutdown()
+	testutil.WaitForLeader(t, srv.RPC)
+
+	for _, path := range []string{"app/db", "other/db"} {
+		sv := mock.SecureVariable()
+		sv.Namespace = structs.DefaultNamespace
+		sv.Path = path
+		require.NoError(t, srv.RPC(structs.SecureVariablesUpsertRPCMethod,
+			&structs.SecureVariablesUpsertRequest{
+				Data: []*structs.SecureVariableDecrypted{sv},
+				WriteRequest: structs.WriteRequest{
+					Region:    "global",
+					AuthToken: rootToken.SecretID,
+				},
+			}, new(structs.SecureVariablesUpsertResponse)))
+	}
+
+	token := mock.CreatePolicyAndToken(t, srv.fsm.State(), 1000, "copy",
+		mock.NamespacePolicyWithSecureVariables(structs.DefaultNamespace, "", nil,
+			map[string][]string{
+				"app/*":  {"read"},
+				"copy/*": {"write"},
+			}))
+
+	copyFn := func(path string, recurse bool) error {
+		dest := "copy/" + path
+		if recurse {
+			dest = "copy/recursive/" + path
+		}
+		return srv.RPC(structs.SecureVariablesCopyRPCMethod,
+			&structs.SecureVariablesCopyRequest{
+				Path:     path,
+				DestPath: dest,
+				Recurse:  recurse,
+				WriteRequest: structs.WriteRequest{
+					Region:    "global",
+					Namespace: structs.DefaultNamespace,
+					AuthToken: token.SecretID,
+				},
+			}, new(structs.SecureVariablesCopyResponse))
+	}
+
+	// Without access to the source, whether it exists isn't disclosed
+	require.EqualError(t, copyFn("other/db", false), structs.ErrPermissionDenied.Error())
+	require.EqualError(t, copyFn("other/missing", false), structs.ErrPermissionDenied.Error())
+	require.EqualError(t, copyFn("other", true), structs.ErrPermissionDenied.Error())
+
+	// With access, a missing source is reported as not found
+	require.EqualError(t, copyFn("app/missing", false), "RPC Error:: 404,secure variable not found")
+	require.NoError(t, copyFn("app/db", false))
+	require.NoError(t, copyFn("app", true))
+}
+
+func TestSecureVariablesEndpoint_Expired(t *testing.T) {
+	ci.Parallel(t)
+
+	srv, shutdown := TestServer(t, func(c *Config) {
+		// Expired secure variables are only collected when they expire
+		c.SecureVariablesGCInterval = time.Hour
+	})
+	defer shutdown()
+	testutil.WaitForLeader(t, srv.RPC)
+
+	upsert := func(sv *structs.SecureVariableDecrypted) uint64 {
+		var resp structs.SecureVariablesUpsertResponse
+		require.NoError(t, srv.RPC(structs.SecureVariablesUpsertRPCMethod,
+			&structs.SecureVariablesUpsertRequest{
+				Data:         []*structs.SecureVariableDecrypted{sv},
+				WriteRequest: structs.WriteRequest{Region: "global"},
+			}, &resp))
+		return resp.Index
+	}
+
+	// Write a secure variable twice so that it has a previous version, and
+	// have it expire in the past
+	expired := mock.SecureVariable()
+	expired.Namespace = structs.DefaultNamespace
+	expired.Path = "app/expired"
+	upsert(expired)
+	expired.ExpireTime = time.Now().Add(-time.Minute).UnixNano()
+	upsert(expired)
+
+	live := mock.SecureVariable()
+	live.Namespace = structs.DefaultNamespace
+	live.Path = "app/live"
+	upsert(live)
+
+	// The history of an expired secure variable is empty
+	var historyResp structs.SecureVariablesHistoryResponse
+	require.NoError(t, srv.RPC(structs.SecureVariablesHistoryRPCMethod,
+		&structs.SecureVariablesHistoryRequest{
+			Path: expired.Path,
+			QueryOptions: structs.QueryOptions{
+				Region:    "global",
+				Namespace: structs.DefaultNamespace,
+			},
+		}, &historyResp))
+	require.Empty(t, historyResp.Data)
+
+	// A copy replaces an expired secure variable at the destination
+	var copyResp structs.SecureVariablesCopyResponse
+	require.NoError(t, srv.RPC(structs.SecureVariablesCopyRPCMethod,
+		&structs.SecureVariablesCopyRequest{
+			Path:     live.Path,
+			DestPath: expired.Path,
+			WriteRequest: structs.WriteRequest{
+				Region:    "global",
+				Namespace: structs.DefaultNamespace,
+			},
+		}, &copyResp))
+
+	out, err := srv.fsm.State().GetSecureVariable(nil, structs.DefaultNamespace, expired.Path)
+	require.NoError(t, err)
+	require.NotNil(t, out)
+	require.Zero(t, out.ExpireTime)
+	require.Equal(t, copyResp.Index, out.CreateIndex)
+
+	// A blocking read returns once the secure variable expires
+	expiring := mock.SecureVariable()
+	expiring.Namespace = structs.DefaultNamespace
+	expiring.Path = "app/expiring"
+	expiring.ExpireTime = time.Now().Add(time.Second).UnixNano()
+	index := upsert(expiring)
+
+	start := time.Now()
+	var readResp structs.SecureVariablesReadResponse
+	require.NoError(t, srv.RPC(structs.SecureVariablesReadRPCMethod,
+		&structs.SecureVariablesReadRequest{
+			Path: expiring.Path,
+			QueryOptions: structs.QueryOptions{
+				Region:        "global",
+				Namespace:     structs.DefaultNamespace,
+				MinQueryIndex: index,
+				MaxQueryTime:  10 * time.Second,
+			},
+		}, &readResp))
+	require.Nil(t, readResp.Data)
+	require.Greater(t, readResp.Index, index)
+	require.Less(t, time.Since(start), 5*time.Second)
+}
+
+// TestSecureVariablesEndpoint_Copy_MoveQuota asserts that moving a secure
+// variable within a namespace leaves its quota usage unchanged, which is why
+// such moves skip the quota check
+func TestSecureVariablesEndpoint_Copy_MoveQuota(t *testing.T) {
+	ci.Parallel(t)
+
+	srv, shutdown := TestServer(t, func(c *Config) {
+		c.NumSchedulers = 0 // Prevent automatic dequeue
+	})
+	defer shutdown()
+	testutil.WaitForLeader(t, srv.RPC)
+
+	sv := mock.SecureVariable()
+	sv.Namespace = structs.DefaultNamespace
+	sv.Path = "app/db"
+	require.NoError(t, srv.RPC(structs.SecureVariablesUpsertRPCMethod,
+		&structs.SecureVariablesUpsertRequest{
+			Data:         []*structs.SecureVariableDecrypted{sv},
+			WriteRequest: structs.WriteRequest{Region: "global"},
+		}, new(structs.SecureVariablesUpsertResponse)))
+
+	before, err := srv.fsm.State().SecureVariablesQuotaByNamespace(nil, structs.DefaultNamespace)
+	require.NoError(t, err)
+	require.NotZero(t, before.Size)
+
+	require.NoError(t, srv.RPC(structs.SecureVariablesCopyRPCMethod,
+		&structs.SecureVariablesCopyRequest{
+			Path:     sv.Path,
+			DestPath: "app/moved",
+			Move:     true,
+			WriteRequest: structs.WriteRequest{
+				Region:    "global",
+				Namespace: structs.DefaultNamespace,
+			},
+		}, new(structs.SecureVariablesCopyResponse)))
+
+	after, err := srv.fsm.State().SecureVariablesQuotaByNamespace(nil, structs.DefaultNamespace)
+	require.NoError(t, err)
+	require.Equal(t, before.Size, after.Size)
+}
@@ -0,0 +1,333 @@
+package nomad
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	log "github.com/hashicorp/go-hclog"
+	memdb "github.com/hashicorp/go-memdb"
+
+	"github.com/hashicorp/nomad/nomad/state"
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/hashicorp/nomad/nomad/structs/config"
+)
+
+const (
+	// secureVariablesSinkDefaultTimeout is the time to wait for a sink to
+	// accept a request when no timeout is configured
+	secureVariablesSinkDefaultTimeout = 10 * time.Second
+
+	// secureVariablesSinkBatchSize is the most Raft indexes of changes that
+	// are delivered before the sink's checkpoint is updated
+	secureVariablesSinkBatchSize = 64
+
+	// secureVariablesSinkRetryInterval is the base time to wait between
+	// delivery attempts, and secureVariablesSinkMaxRetryInterval the most
+	secureVariablesSinkRetryInterval    = time.Second
+	secureVariablesSinkMaxRetryInterval = time.Minute
+
+	// secureVariablesSinkSignatureHeader is the header holding the HMAC-SHA256
+	// signature of the request body
+	secureVariablesSinkSignatureHeader = "X-Nomad-Signature"
+)
+
+// secureVariablesSink forwards the changes to secure variables to an external
+// system. The FSM keeps the changes in the state store until every sink has
+// received them, and only the leader forwards them, recording the index of
+// the last changes it delivered to each sink in Raft. A new leader resumes
+// from there, so each change is delivered at least once. Failed deliveries
+// are retried until they succeed or leadership is lost.
+type secureVariablesSink struct {
+	srv    *Server
+	config *config.SecureVariablesSink
+	client *http.Client
+	logger log.Logger
+}
+
+// forwardSecureVariableChanges registers the configured sinks and forwards
+// the changes to secure variables to them until leadership is lost.
+func (s *Server) forwardSecureVariableChanges(stopCh chan struct{}) {
+	ctx, cancel := context.WithCancel(s.shutdownCtx)
+	defer cancel()
+	go func() {
+		select {
+		case <-stopCh:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	for {
+		err := s.registerSecureVariablesSinks()
+		if err == nil {
+			break
+		}
+		s.logger.Error("failed to register secure variable sinks", "error", err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(secureVariablesSinkRetryInterval):
+		}
+	}
+
+	for _, cfg := range s.config.SecureVariablesSinks {
+		timeout := cfg.Timeout
+		if timeout == 0 {
+			timeout = secureVariablesSinkDefaultTimeout
+		}
+		sink := &secureVariablesSink{
+			srv:    s,
+			config: cfg,
+			client: &http.Client{Timeout: timeout},
+			logger: s.logger.Named("secure_variables_sink").With("sink", cfg.Name),
+		}
+		go sink.run(ctx)
+	}
+	<-ctx.Done()
+}
+
+// registerSecureVariablesSinks writes a checkpoint for each configured sink
+// that doesn't have one yet, starting at the latest index, and removes the
+// checkpoints of sinks that are no longer configured. Changes are only kept
+// for the sinks to forward while at least one sink is registered.
+func (s *Server) registerSecureVariablesSinks() error {
+	store := s.fsm.State()
+	latest, err := store.LatestIndex()
+	if err != nil {
+		return err
+	}
+
+	update := false
+	checkpoints := make([]*structs.SecureVariablesSinkCheckpoint, 0, len(s.config.SecureVariablesSinks))
+	for _, cfg := range s.config.SecureVariablesSinks {
+		checkpoint, err := store.SecureVariablesSinkCheckpoint(nil, cfg.Name)
+		if err != nil {
+			return err
+		}
+		if checkpoint == nil {
+			checkpoint = &structs.SecureVariablesSinkCheckpoint{
+				Name:      cfg.Name,
+				LastIndex: latest,
+			}
+			update = true
+		}
+		checkpoints = append(checkpoints, checkpoint)
+	}
+
+	iter, err := store.SecureVariablesSinkCheckpoints(nil)
+	if err != nil {
+		return err
+	}
+	registered := 0
+	for raw := iter.Next(); raw != nil; raw = iter.Next() {
+		registered++
+	}
+	if !update && registered == len(checkpoints) {
+		return nil
+	}
+
+	req := &structs.SecureVariablesSinkCheckpointRequest{
+		Checkpoints: checkpoints,
+		Reset:       true,
+	}
+	out, _, err := s.raftApply(structs.SecureVariablesSinkCheckpointRequestType, req)
+	if err != nil {
+		return err
+	}
+	if err, ok := out.(error); ok && err != nil {
+		return err
+	}
+	return nil
+}
+
+// run forwards the changes made after the sink's checkpoint, waiting for
+// more changes once they have all been delivered.
+func (v *secureVariablesSink) run(ctx context.Context) {
+	for {
+		ws := memdb.NewWatchSet()
+		store := v.srv.fsm.State()
+		ws.Add(store.AbandonCh())
+
+		lastIndex, err := v.forward(ctx, ws, store)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			v.logger.Error("failed to forward secure variable changes", "error", err)
+			if !v.wait(ctx, secureVariablesSinkRetryInterval) {
+				return
+			}
+			continue
+		}
+		if lastIndex != 0 {
+			continue
+		}
+
+		// Everything was delivered, so wait for more changes
+		if err := ws.WatchCtx(ctx); err != nil {
+			return
+		}
+	}
+}
+
+// forward delivers a batch of the changes made after the sink's checkpoint
+// and then moves the checkpoint past them. It returns the index of the last
+// changes delivered, or zero if there were none.
+func (v *secureVariablesSink) forward(ctx context.Context, ws memdb.WatchSet, store *state.StateStore) (uint64, error) {
+	checkpoint, err := store.SecureVariablesSinkCheckpoint(ws, v.config.Name)
+	if err != nil {
+		return 0, err
+	}
+	if checkpoint == nil {
+		return 0, fmt.Errorf("sink is not registered")
+	}
+	iter, err := store.SecureVariableChangesAfter(ws, checkpoint.LastIndex)
+	if err != nil {
+		return 0, err
+	}
+
+	var lastIndex uint64
+	for i := 0; i < secureVariablesSinkBatchSize; i++ {
+		raw := iter.Next()
+		if raw == nil {
+			break
+		}
+		changes := raw.(*structs.SecureVariableChanges)
+		if events := v.events(changes); len(events.Events) > 0 {
+			if err := v.deliver(ctx, events); err != nil {
+				return 0, err
+			}
+		}
+		lastIndex = changes.Index
+	}
+	if lastIndex == 0 {
+		return 0, nil
+	}
+
+	checkpoint = &structs.SecureVariablesSinkCheckpoint{
+		Name:      v.config.Name,
+		LastIndex: lastIndex,
+	}
+	req := &structs.SecureVariablesSinkCheckpointRequest{
+		Checkpoints: []*structs.SecureVariablesSinkCheckpoint{checkpoint},
+	}
+	out, _, err := v.srv.raftApply(structs.SecureVariablesSinkCheckpointRequestType, req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to update checkpoint: %v", err)
+	}
+	if err, ok := out.(error); ok && err != nil {
+		return 0, fmt.Errorf("failed to update checkpoint: %v", err)
+	}
+	return lastIndex, nil
+}
+
+// events returns the events for the changes under the paths the sink is
+// configured for, as they are published to the Variable topic.
+func (v *secureVariablesSink) events(changes *structs.SecureVariableChanges) *structs.Events {
+	events := &structs.Events{Index: changes.Index}
+	for _, change := range changes.Changes {
+		if !v.matchesPath(change.SecureVariable.Path) {
+			continue
+		}
+		events.Events = append(events.Events, structs.Event{
+			Topic:     structs.TopicVariable,
+			Type:      change.Type,
+			Key:       change.SecureVariable.Path,
+			Namespace: change.SecureVariable.Namespace,
+			Index:     changes.Index,
+			Payload: &structs.SecureVariableEvent{
+				SecureVariable: change.SecureVariable,
+			},
+		})
+	}
+	return events
+}
+
+func (v *secureVariablesSink) matchesPath(path string) bool {
+	if len(v.config.PathPrefixes) == 0 {
+		return true
+	}
+	for _, prefix := range v.config.PathPrefixes {
+		if strings.HasPrefix(path, strings.TrimPrefix(prefix, "/")) {
+			return true
+		}
+	}
+	return false
+}
+
+// deliver posts the events to the sink, retrying failed attempts with an
+// increasing backoff until one succeeds or the context is done.
+func (v *secureVariablesSink) deliver(ctx context.Context, events *structs.Events) error {
+	body, err := json.Marshal(events)
+	if err != nil {
+		return fmt.Errorf("failed to encode events: %v", err)
+	}
+
+	for attempt := 1; ; attempt++ {
+		err = v.post(ctx, body)
+		if err == nil {
+			return nil
+		}
+
+		v.logger.Warn("failed to forward secure variable changes, retrying",
+			"index", events.Index, "attempt", attempt, "error", err)
+		backoff := time.Duration(attempt) * secureVariablesSinkRetryInterval
+		if backoff > secureVariablesSinkMaxRetryInterval {
+			backoff = secureVariablesSinkMaxRetryInterval
+		}
+		if !v.wait(ctx, backoff) {
+			return ctx.Err()
+		}
+	}
+}
+
+// wait returns false if the context is done before the duration has passed.
+func (v *secureVariablesSink) wait(ctx context.Context, d time.Duration) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	case <-time.After(d):
+		return true
+	}
+}
+
+func (v *secureVariablesSink) post(ctx context.Context, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, v.config.Address, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for k, val := range v.config.Headers {
+		req.Header.Set(k, val)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if v.config.HMACKey != "" {
+		req.Header.Set(secureVariablesSinkSignatureHeader, signSecureVariablesSinkBody(v.config.HMACKey, body))
+	}
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected response code %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// signSecureVariablesSinkBody returns the value of the signature header for
+// a request body.
+func signSecureVariablesSinkBody(key string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
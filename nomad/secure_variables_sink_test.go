@@ -0,0 +1,184 @@
+package nomad
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	memdb "github.com/hashicorp/go-memdb"
+	"github.com/hashicorp/nomad/ci"
+	"github.com/hashicorp/nomad/nomad/mock"
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/hashicorp/nomad/nomad/structs/config"
+	"github.com/hashicorp/nomad/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSecureVariablesSink_Webhook(t *testing.T) {
+	ci.Parallel(t)
+
+	type request struct {
+		header http.Header
+		body   []byte
+	}
+	requestCh := make(chan request, 10)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		requestCh <- request{header: r.Header, body: body}
+	}))
+	defer ts.Close()
+
+	srv, shutdown := TestServer(t, func(c *Config) {
+		c.NumSchedulers = 0 // Prevent automatic dequeue
+		c.SecureVariablesSinks = []*config.SecureVariablesSink{{
+			Name:         "test",
+			Type:         config.SecureVariablesSinkTypeWebhook,
+			Address:      ts.URL,
+			PathPrefixes: []string{"app/"},
+			HMACKey:      "secret",
+			Headers:      map[string]string{"Authorization": "Bearer token"},
+		}}
+	})
+	defer shutdown()
+	testutil.WaitForLeader(t, srv.RPC)
+	waitForSecureVariablesSink(t, srv, "test")
+
+	// Only the write under the configured prefix is forwarded
+	upsertSecureVariableForSink(t, srv, "other/db")
+	upsertSecureVariableForSink(t, srv, "app/db")
+
+	var req request
+	select {
+	case req = <-requestCh:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the sink request")
+	}
+
+	require.Equal(t, "application/json", req.header.Get("Content-Type"))
+	require.Equal(t, "Bearer token", req.header.Get("Authorization"))
+	require.Equal(t, signSecureVariablesSinkBody("secret", req.body),
+		req.header.Get(secureVariablesSinkSignatureHeader))
+
+	var events struct {
+		Index  uint64
+		Events []struct {
+			Topic     structs.Topic
+			Type      string
+			Key       string
+			Namespace string
+		}
+	}
+	require.NoError(t, json.Unmarshal(req.body, &events))
+	require.Len(t, events.Events, 1)
+	require.Equal(t, structs.TopicVariable, events.Events[0].Topic)
+	require.Equal(t, structs.TypeSecureVariableUpserted, events.Events[0].Type)
+	require.Equal(t, "app/db", events.Events[0].Key)
+	require.Equal(t, structs.DefaultNamespace, events.Events[0].Namespace)
+
+	select {
+	case req = <-requestCh:
+		t.Fatalf("unexpected sink request: %s", req.body)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestSecureVariablesSink_Redelivery(t *testing.T) {
+	ci.Parallel(t)
+
+	var failed int32
+	bodyCh := make(chan []byte, 10)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		bodyCh <- body
+		if atomic.CompareAndSwapInt32(&failed, 0, 1) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer ts.Close()
+
+	srv, shutdown := TestServer(t, func(c *Config) {
+		c.NumSchedulers = 0 // Prevent automatic dequeue
+		c.SecureVariablesSinks = []*config.SecureVariablesSink{{
+			Name:    "test",
+			Type:    config.SecureVariablesSinkTypeWebhook,
+			Address: ts.URL,
+		}}
+	})
+	defer shutdown()
+	testutil.WaitForLeader(t, srv.RPC)
+	registered := waitForSecureVariablesSink(t, srv, "test")
+
+	index := upsertSecureVariableForSink(t, srv, "app/db")
+
+	receive := func() []byte {
+		select {
+		case body := <-bodyCh:
+			return body
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for the sink request")
+			return nil
+		}
+	}
+
+	// The checkpoint doesn't move past the changes until they're accepted
+	first := receive()
+	checkpoint, err := srv.fsm.State().SecureVariablesSinkCheckpoint(nil, "test")
+	require.NoError(t, err)
+	require.Equal(t, registered.LastIndex, checkpoint.LastIndex)
+
+	require.Equal(t, first, receive())
+	testutil.WaitForResult(func() (bool, error) {
+		checkpoint, err := srv.fsm.State().SecureVariablesSinkCheckpoint(nil, "test")
+		if err != nil {
+			return false, err
+		}
+		if checkpoint.LastIndex != index {
+			return false, fmt.Errorf("expected checkpoint at %d, got %d", index, checkpoint.LastIndex)
+		}
+		return true, nil
+	}, func(err error) {
+		require.NoError(t, err)
+	})
+
+	// The delivered changes are no longer kept
+	iter, err := srv.fsm.State().SecureVariableChangesAfter(memdb.NewWatchSet(), 0)
+	require.NoError(t, err)
+	require.Nil(t, iter.Next())
+}
+
+// waitForSecureVariablesSink waits for the leader to register a sink, as only
+// the changes made after that are forwarded to it.
+func waitForSecureVariablesSink(t *testing.T, srv *Server, name string) *structs.SecureVariablesSinkCheckpoint {
+	t.Helper()
+	var checkpoint *structs.SecureVariablesSinkCheckpoint
+	testutil.WaitForResult(func() (bool, error) {
+		var err error
+		checkpoint, err = srv.fsm.State().SecureVariablesSinkCheckpoint(nil, name)
+		if err != nil {
+			return false, err
+		}
+		return checkpoint != nil, fmt.Errorf("sink %q not registered", name)
+	}, func(err error) {
+		require.NoError(t, err)
+	})
+	return checkpoint
+}
+
+func upsertSecureVariableForSink(t *testing.T, srv *Server, path string) uint64 {
+	t.Helper()
+	sv := mock.SecureVariable()
+	sv.Namespace = structs.DefaultNamespace
+	sv.Path = path
+	var resp structs.SecureVariablesUpsertResponse
+	require.NoError(t, srv.RPC(structs.SecureVariablesUpsertRPCMethod,
+		&structs.SecureVariablesUpsertRequest{
+			Data:         []*structs.SecureVariableDecrypted{sv},
+			WriteRequest: structs.WriteRequest{Region: "global"},
+		}, &resp))
+	return resp.Index
+}
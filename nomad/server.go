@@ -34,6 +34,7 @@ import (
 	"github.com/hashicorp/nomad/nomad/deploymentwatcher"
 	"github.com/hashicorp/nomad/nomad/drainer"
 	"github.com/hashicorp/nomad/nomad/state"
+	"github.com/hashicorp/nomad/nomad/stream"
 	"github.com/hashicorp/nomad/nomad/structs"
 	"github.com/hashicorp/nomad/nomad/structs/config"
 	"github.com/hashicorp/nomad/nomad/volumewatcher"
@@ -274,6 +275,12 @@ type Server struct {
 	shutdownCtx    context.Context
 	shutdownCancel context.CancelFunc
 	shutdownCh     <-chan struct{}
+
+	// secureVariableAccess is the event broker for secure variable access
+	// events, which aren't written to Raft. secureVariableAccessIndex is
+	// the index of the last access event it was sent.
+	secureVariableAccess      *stream.EventBroker
+	secureVariableAccessIndex uint64
 }
 
 // Holds the RPC endpoints
@@ -364,6 +371,11 @@ func NewServer(config *Config, consulCatalog consul.CatalogAPI, consulConfigEntr
 	s.shutdownCtx, s.shutdownCancel = context.WithCancel(context.Background())
 	s.shutdownCh = s.shutdownCtx.Done()
 
+	// Create the event broker for secure variable access events
+	if err := s.setupSecureVariableAccessBroker(s.shutdownCtx); err != nil {
+		return nil, err
+	}
+
 	// Create the RPC handler
 	s.rpcHandler = newRpcHandler(s)
 
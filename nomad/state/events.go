@@ -31,6 +31,9 @@ var MsgTypeEvents = map[structs.MessageType]string{
 	structs.ServiceRegistrationUpsertRequestType:         structs.TypeServiceRegistration,
 	structs.ServiceRegistrationDeleteByIDRequestType:     structs.TypeServiceDeregistration,
 	structs.ServiceRegistrationDeleteByNodeIDRequestType: structs.TypeServiceDeregistration,
+	structs.SecureVariableUpsertRequestType:              structs.TypeSecureVariableUpserted,
+	structs.SecureVariableDeleteRequestType:              structs.TypeSecureVariableDeleted,
+	structs.SecureVariableCopyRequestType:                structs.TypeSecureVariableUpserted,
 }
 
 func eventsFromChanges(tx ReadTxn, changes Changes) *structs.Events {
@@ -42,7 +45,11 @@ func eventsFromChanges(tx ReadTxn, changes Changes) *structs.Events {
 	var events []structs.Event
 	for _, change := range changes.Changes {
 		if event, ok := eventFromChange(change); ok {
-			event.Type = eventType
+			// Some messages, like moving secure variables, cause changes
+			// of more than one type
+			if event.Type == "" {
+				event.Type = eventType
+			}
 			event.Index = changes.Index
 			events = append(events, event)
 		}
@@ -108,6 +115,20 @@ func eventFromChange(change memdb.Change) (structs.Event, bool) {
 					Service: before,
 				},
 			}, true
+		case TableSecureVariables:
+			before, ok := change.Before.(*structs.SecureVariableEncrypted)
+			if !ok {
+				return structs.Event{}, false
+			}
+			return structs.Event{
+				Topic:     structs.TopicVariable,
+				Type:      structs.TypeSecureVariableDeleted,
+				Key:       before.Path,
+				Namespace: before.Namespace,
+				Payload: &structs.SecureVariableEvent{
+					SecureVariable: before.SecureVariableMetadata.Copy(),
+				},
+			}, true
 		}
 		return structs.Event{}, false
 	}
@@ -235,6 +256,19 @@ func eventFromChange(change memdb.Change) (structs.Event, bool) {
 				Service: after,
 			},
 		}, true
+	case TableSecureVariables:
+		after, ok := change.After.(*structs.SecureVariableEncrypted)
+		if !ok {
+			return structs.Event{}, false
+		}
+		return structs.Event{
+			Topic:     structs.TopicVariable,
+			Key:       after.Path,
+			Namespace: after.Namespace,
+			Payload: &structs.SecureVariableEvent{
+				SecureVariable: after.SecureVariableMetadata.Copy(),
+			},
+		}, true
 	}
 
 	return structs.Event{}, false
@@ -1059,3 +1059,65 @@ func testNodeID() string {
 func testNodeIDTwo() string {
 	return "694ff31d-8c59-4030-ac83-e15692560c8d"
 }
+
+func Test_eventsFromChanges_SecureVariables(t *testing.T) {
+	ci.Parallel(t)
+	testState := TestStateStoreCfg(t, TestStateStorePublisher(t))
+	defer testState.StopEventBroker()
+
+	sv := mock.SecureVariableEncrypted()
+	require.NoError(t, testState.UpsertSecureVariables(
+		structs.SecureVariableUpsertRequestType, 10, []*structs.SecureVariableEncrypted{sv}))
+
+	events := WaitForEvents(t, testState, 10, 1, 1*time.Second)
+	require.Len(t, events, 1)
+	require.Equal(t, structs.TopicVariable, events[0].Topic)
+	require.Equal(t, structs.TypeSecureVariableUpserted, events[0].Type)
+	require.Equal(t, sv.Path, events[0].Key)
+	require.Equal(t, sv.Namespace, events[0].Namespace)
+	require.Equal(t, uint64(10), events[0].Index)
+	eventPayload := events[0].Payload.(*structs.SecureVariableEvent)
+	require.Equal(t, uint64(10), eventPayload.SecureVariable.ModifyIndex)
+
+	// Moving a secure variable publishes both the copy and the deletion.
+	src, err := testState.GetSecureVariable(nil, sv.Namespace, sv.Path)
+	require.NoError(t, err)
+	dest := src.Copy()
+	dest.Path = sv.Path + "/moved"
+	require.NoError(t, testState.CopySecureVariables(structs.SecureVariableCopyRequestType, 20,
+		[]*structs.SecureVariableMetadata{&src.SecureVariableMetadata},
+		[]*structs.SecureVariableEncrypted{&dest}, nil, true))
+
+	events = WaitForEvents(t, testState, 20, 2, 1*time.Second)
+	require.Len(t, events, 2)
+	types := map[string]string{}
+	for _, event := range events {
+		require.Equal(t, structs.TopicVariable, event.Topic)
+		types[event.Key] = event.Type
+	}
+	require.Equal(t, map[string]string{
+		sv.Path:   structs.TypeSecureVariableDeleted,
+		dest.Path: structs.TypeSecureVariableUpserted,
+	}, types)
+
+	// Rekeying doesn't change the secure variable, so it publishes nothing.
+	keyMeta := structs.NewRootKeyMeta()
+	require.NoError(t, testState.UpsertRootKeyMeta(25, keyMeta, false))
+	moved, err := testState.GetSecureVariable(nil, dest.Namespace, dest.Path)
+	require.NoError(t, err)
+	rekeyed := moved.Copy()
+	rekeyed.KeyID = keyMeta.KeyID
+	require.NoError(t, testState.RekeySecureVariables(structs.SecureVariableRekeyRequestType, 30,
+		[]*structs.SecureVariableEncrypted{&rekeyed}, nil))
+
+	require.NoError(t, testState.DeleteSecureVariables(
+		structs.SecureVariableDeleteRequestType, 40, dest.Namespace, []string{dest.Path}))
+	WaitForEvents(t, testState, 40, 1, 1*time.Second)
+
+	// The only event after the move is for the deletion.
+	events = EventsForIndex(t, testState, 20)
+	require.Len(t, events, 3)
+	require.Equal(t, structs.TypeSecureVariableDeleted, events[2].Type)
+	require.Equal(t, dest.Path, events[2].Key)
+	require.Equal(t, uint64(40), events[2].Index)
+}
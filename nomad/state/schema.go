@@ -1,6 +1,7 @@
 package state
 
 import (
+	"encoding/binary"
 	"fmt"
 	"sync"
 
@@ -12,11 +13,15 @@ import (
 const (
 	tableIndex = "index"
 
-	TableNamespaces            = "namespaces"
-	TableServiceRegistrations  = "service_registrations"
-	TableSecureVariables       = "secure_variables"
-	TableSecureVariablesQuotas = "secure_variables_quota"
-	TableRootKeyMeta           = "secure_variables_root_key_meta"
+	TableNamespaces             = "namespaces"
+	TableServiceRegistrations   = "service_registrations"
+	TableSecureVariables        = "secure_variables"
+	TableSecureVariablesQuotas  = "secure_variables_quota"
+	TableSecureVariablesHistory = "secure_variables_history"
+	TableRootKeyMeta            = "secure_variables_root_key_meta"
+
+	TableSecureVariablesChanges         = "secure_variables_changes"
+	TableSecureVariablesSinkCheckpoints = "secure_variables_sink_checkpoints"
 )
 
 const (
@@ -27,6 +32,7 @@ const (
 	indexServiceName = "service_name"
 	indexKeyID       = "key_id"
 	indexPath        = "path"
+	indexExpireTime  = "expire_time"
 )
 
 var (
@@ -77,7 +83,10 @@ func init() {
 		serviceRegistrationsTableSchema,
 		secureVariablesTableSchema,
 		secureVariablesQuotasTableSchema,
+		secureVariablesHistoryTableSchema,
 		secureVariablesRootKeyMetaSchema,
+		secureVariablesChangesSchema,
+		secureVariablesSinkCheckpointsSchema,
 	}...)
 }
 
@@ -1245,6 +1254,48 @@ func secureVariablesTableSchema() *memdb.TableSchema {
 					Field: "Path",
 				},
 			},
+			indexExpireTime: {
+				Name:         indexExpireTime,
+				AllowMissing: true,
+				Unique:       false,
+				Indexer:      &secureVariableExpireTimeFieldIndexer{},
+			},
+		},
+	}
+}
+
+// secureVariablesHistoryTableSchema returns the MemDB schema for the
+// previous versions of Nomad secure variables.
+func secureVariablesHistoryTableSchema() *memdb.TableSchema {
+	return &memdb.TableSchema{
+		Name: TableSecureVariablesHistory,
+		Indexes: map[string]*memdb.IndexSchema{
+			indexID: {
+				Name:         indexID,
+				AllowMissing: false,
+				Unique:       true,
+
+				// Use a compound index so the tuple of (Namespace, Path,
+				// ModifyIndex) is uniquely identifying
+				Indexer: &memdb.CompoundIndex{
+					Indexes: []memdb.Indexer{
+						&memdb.StringFieldIndex{
+							Field: "Namespace",
+						},
+						&memdb.StringFieldIndex{
+							Field: "Path",
+						},
+						&memdb.UintFieldIndex{
+							Field: "ModifyIndex",
+						},
+					},
+				},
+			},
+			indexKeyID: {
+				Name:         indexKeyID,
+				AllowMissing: false,
+				Indexer:      &secureVariableKeyIDFieldIndexer{},
+			},
 		},
 	}
 }
@@ -1301,6 +1352,43 @@ func (s *secureVariableKeyIDFieldIndexer) FromObject(obj interface{}) (bool, []b
 	return true, []byte(keyID), nil
 }
 
+// secureVariableExpireTimeFieldIndexer indexes the secure variables that
+// expire by their ExpireTime, encoded so that they sort by time.
+type secureVariableExpireTimeFieldIndexer struct{}
+
+// FromArgs implements go-memdb/Indexer and is used to build an exact
+// index lookup based on arguments
+func (s *secureVariableExpireTimeFieldIndexer) FromArgs(args ...interface{}) ([]byte, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("must provide only a single argument")
+	}
+	arg, ok := args[0].(int64)
+	if !ok {
+		return nil, fmt.Errorf("argument must be an int64: %#v", args[0])
+	}
+	buf := make([]byte, 8)
+	binary.BigEndian.PutUint64(buf, uint64(arg))
+	return buf, nil
+}
+
+// FromObject implements go-memdb/SingleIndexer and is used to extract
+// an index value from an object or to indicate that the index value
+// is missing.
+func (s *secureVariableExpireTimeFieldIndexer) FromObject(obj interface{}) (bool, []byte, error) {
+	variable, ok := obj.(*structs.SecureVariableEncrypted)
+	if !ok {
+		return false, nil, fmt.Errorf("object %#v is not a SecureVariable", obj)
+	}
+
+	// Secure variables that never expire aren't indexed
+	if variable.ExpireTime <= 0 {
+		return false, nil, nil
+	}
+	buf := make([]byte, 8)
+	binary.BigEndian.PutUint64(buf, uint64(variable.ExpireTime))
+	return true, buf, nil
+}
+
 // secureVariablesQuotasTableSchema returns the MemDB schema for Nomad
 // secure variables quotas tracking
 func secureVariablesQuotasTableSchema() *memdb.TableSchema {
@@ -1338,3 +1426,39 @@ func secureVariablesRootKeyMetaSchema() *memdb.TableSchema {
 		},
 	}
 }
+
+// secureVariablesChangesSchema returns the MemDB schema for the changes to
+// secure variables that are kept for the sinks to forward
+func secureVariablesChangesSchema() *memdb.TableSchema {
+	return &memdb.TableSchema{
+		Name: TableSecureVariablesChanges,
+		Indexes: map[string]*memdb.IndexSchema{
+			indexID: {
+				Name:         indexID,
+				AllowMissing: false,
+				Unique:       true,
+				Indexer: &memdb.UintFieldIndex{
+					Field: "Index",
+				},
+			},
+		},
+	}
+}
+
+// secureVariablesSinkCheckpointsSchema returns the MemDB schema for the
+// progress of secure variable sinks
+func secureVariablesSinkCheckpointsSchema() *memdb.TableSchema {
+	return &memdb.TableSchema{
+		Name: TableSecureVariablesSinkCheckpoints,
+		Indexes: map[string]*memdb.IndexSchema{
+			indexID: {
+				Name:         indexID,
+				AllowMissing: false,
+				Unique:       true,
+				Indexer: &memdb.StringFieldIndex{
+					Field: "Name",
+				},
+			},
+		},
+	}
+}
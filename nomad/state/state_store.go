@@ -6763,6 +6763,18 @@ func (s *StateStore) DeleteRootKeyMeta(index uint64, keyID string) error {
 		return fmt.Errorf("index update failed: %v", err)
 	}
 
+	// previous versions of secure variables encrypted with the key can no
+	// longer be decrypted, so they are dropped along with it
+	deleted, err := txn.DeleteAll(TableSecureVariablesHistory, indexKeyID, keyID)
+	if err != nil {
+		return fmt.Errorf("secure variable version delete failed: %v", err)
+	}
+	if deleted > 0 {
+		if err := txn.Insert("index", &IndexEntry{TableSecureVariablesHistory, index}); err != nil {
+			return fmt.Errorf("index update failed: %v", err)
+		}
+	}
+
 	return txn.Commit()
 }
 
@@ -207,6 +207,15 @@ func (r *StateRestore) SecureVariablesRestore(variable *structs.SecureVariableEn
 	return nil
 }
 
+// SecureVariableVersionsRestore is used to restore a single previous version
+// of a secure variable into the secure_variables_history table.
+func (r *StateRestore) SecureVariableVersionsRestore(variable *structs.SecureVariableEncrypted) error {
+	if err := r.txn.Insert(TableSecureVariablesHistory, variable); err != nil {
+		return fmt.Errorf("secure variable version insert failed: %v", err)
+	}
+	return nil
+}
+
 // SecureVariablesQuotaRestore is used to restore a single secure variable quota
 // into the secure_variables_quota table.
 func (r *StateRestore) SecureVariablesQuotaRestore(quota *structs.SecureVariablesQuota) error {
@@ -224,3 +233,21 @@ func (r *StateRestore) RootKeyMetaRestore(quota *structs.RootKeyMeta) error {
 	}
 	return nil
 }
+
+// SecureVariableChangesRestore is used to restore the changes to secure
+// variables kept for the sinks into the secure_variables_changes table.
+func (r *StateRestore) SecureVariableChangesRestore(changes *structs.SecureVariableChanges) error {
+	if err := r.txn.Insert(TableSecureVariablesChanges, changes); err != nil {
+		return fmt.Errorf("secure variable changes insert failed: %v", err)
+	}
+	return nil
+}
+
+// SecureVariablesSinkCheckpointRestore is used to restore the checkpoint of a
+// secure variable sink into the secure_variables_sink_checkpoints table.
+func (r *StateRestore) SecureVariablesSinkCheckpointRestore(checkpoint *structs.SecureVariablesSinkCheckpoint) error {
+	if err := r.txn.Insert(TableSecureVariablesSinkCheckpoints, checkpoint); err != nil {
+		return fmt.Errorf("secure variable sink checkpoint insert failed: %v", err)
+	}
+	return nil
+}
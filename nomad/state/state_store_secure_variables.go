@@ -2,6 +2,7 @@ package state
 
 import (
 	"fmt"
+	"sort"
 	"time"
 
 	"github.com/hashicorp/go-memdb"
@@ -95,10 +96,12 @@ func (s *StateStore) GetSecureVariable(
 	txn := s.db.ReadTxn()
 
 	// Try to fetch the secure variable.
-	raw, err := txn.First(TableSecureVariables, indexID, namespace, path)
+	watchCh, raw, err := txn.FirstWatch(TableSecureVariables, indexID, namespace, path)
 	if err != nil { // error during fetch
 		return nil, fmt.Errorf("secure variable lookup failed: %v", err)
 	}
+	ws.Add(watchCh)
+
 	if raw == nil { // not found
 		return nil, nil
 	}
@@ -108,7 +111,7 @@ func (s *StateStore) GetSecureVariable(
 }
 
 func (s *StateStore) UpsertSecureVariables(msgType structs.MessageType, index uint64, svs []*structs.SecureVariableEncrypted) error {
-	txn := s.db.WriteTxn(index)
+	txn := s.db.WriteTxnMsgT(msgType, index)
 	defer txn.Abort()
 
 	var updated bool = false
@@ -125,6 +128,9 @@ func (s *StateStore) UpsertSecureVariables(msgType structs.MessageType, index ui
 	if err := txn.Insert(tableIndex, &IndexEntry{TableSecureVariables, index}); err != nil {
 		return fmt.Errorf("index update failed: %v", err)
 	}
+	if err := s.recordSecureVariableChanges(index, txn); err != nil {
+		return err
+	}
 
 	return txn.Commit()
 }
@@ -157,6 +163,10 @@ func (s *StateStore) upsertSecureVariableImpl(index uint64, txn *txn, sv *struct
 		sv.ModifyIndex = index
 		sv.ModifyTime = nowNano
 		quotaChange = len(sv.Data) - len(exist.Data)
+
+		if err := s.upsertSecureVariableVersion(index, txn, exist); err != nil {
+			return err
+		}
 	} else {
 		sv.CreateIndex = index
 		sv.CreateTime = nowNano
@@ -197,6 +207,115 @@ func (s *StateStore) upsertSecureVariableImpl(index uint64, txn *txn, sv *struct
 	return nil
 }
 
+// upsertSecureVariableVersion keeps the version of a secure variable that is
+// being replaced, dropping the oldest kept versions beyond the tracked limit.
+func (s *StateStore) upsertSecureVariableVersion(index uint64, txn *txn, sv *structs.SecureVariableEncrypted) error {
+	if err := txn.Insert(TableSecureVariablesHistory, sv); err != nil {
+		return fmt.Errorf("secure variable version insert failed: %v", err)
+	}
+	if err := txn.Insert(tableIndex, &IndexEntry{TableSecureVariablesHistory, index}); err != nil {
+		return fmt.Errorf("index update failed: %v", err)
+	}
+
+	all, err := s.secureVariableVersions(txn, nil, sv.Namespace, sv.Path)
+	if err != nil {
+		return err
+	}
+	for i := structs.SecureVariableTrackedVersions; i < len(all); i++ {
+		if err := txn.Delete(TableSecureVariablesHistory, all[i]); err != nil {
+			return fmt.Errorf("secure variable version delete failed: %v", err)
+		}
+	}
+	return nil
+}
+
+// secureVariableVersions returns the kept previous versions of a secure
+// variable, newest first.
+func (s *StateStore) secureVariableVersions(txn Txn, ws memdb.WatchSet, namespace, path string) ([]*structs.SecureVariableEncrypted, error) {
+	iter, err := txn.Get(TableSecureVariablesHistory, indexID+"_prefix", namespace, path)
+	if err != nil {
+		return nil, fmt.Errorf("secure variable version lookup failed: %v", err)
+	}
+	ws.Add(iter.WatchCh())
+
+	var all []*structs.SecureVariableEncrypted
+	for {
+		raw := iter.Next()
+		if raw == nil {
+			break
+		}
+
+		// Ensure the path is an exact match
+		sv := raw.(*structs.SecureVariableEncrypted)
+		if sv.Path != path {
+			continue
+		}
+		all = append(all, sv)
+	}
+
+	sort.Slice(all, func(i, j int) bool {
+		return all[i].ModifyIndex > all[j].ModifyIndex
+	})
+	return all, nil
+}
+
+// GetSecureVariableVersions returns the kept previous versions of a secure
+// variable at a given namespace and path, newest first. The current version
+// is not included.
+func (s *StateStore) GetSecureVariableVersions(
+	ws memdb.WatchSet, namespace, path string) ([]*structs.SecureVariableEncrypted, error) {
+	txn := s.db.ReadTxn()
+	return s.secureVariableVersions(txn, ws, namespace, path)
+}
+
+// GetSecureVariableVersion returns the kept previous version of a secure
+// variable at a given namespace and path that was last modified at the
+// modify index.
+func (s *StateStore) GetSecureVariableVersion(
+	ws memdb.WatchSet, namespace, path string, modifyIndex uint64) (*structs.SecureVariableEncrypted, error) {
+	txn := s.db.ReadTxn()
+
+	watchCh, raw, err := txn.FirstWatch(TableSecureVariablesHistory, indexID, namespace, path, modifyIndex)
+	if err != nil {
+		return nil, fmt.Errorf("secure variable version lookup failed: %v", err)
+	}
+	ws.Add(watchCh)
+
+	if raw == nil {
+		return nil, nil
+	}
+	return raw.(*structs.SecureVariableEncrypted), nil
+}
+
+// GetSecureVariableVersionsByKeyID returns an iterator of the kept previous
+// versions of secure variables that are encrypted with the root key.
+func (s *StateStore) GetSecureVariableVersionsByKeyID(
+	ws memdb.WatchSet, keyID string) (memdb.ResultIterator, error) {
+	txn := s.db.ReadTxn()
+
+	iter, err := txn.Get(TableSecureVariablesHistory, indexKeyID, keyID)
+	if err != nil {
+		return nil, fmt.Errorf("secure variable version lookup failed: %v", err)
+	}
+	ws.Add(iter.WatchCh())
+
+	return iter, nil
+}
+
+// SecureVariableVersions queries all the kept previous versions of secure
+// variables and is used only for snapshot/restore
+func (s *StateStore) SecureVariableVersions(ws memdb.WatchSet) (memdb.ResultIterator, error) {
+	txn := s.db.ReadTxn()
+
+	iter, err := txn.Get(TableSecureVariablesHistory, indexID)
+	if err != nil {
+		return nil, err
+	}
+
+	ws.Add(iter.WatchCh())
+	return iter, nil
+}
+
 // shouldWrite can be used to determine if a write needs to happen.
 func shouldWrite(sv, existing *structs.SecureVariableEncrypted) bool {
 	// FIXME: Move this to the RPC layer eventually.
@@ -209,15 +328,40 @@ func shouldWrite(sv, existing *structs.SecureVariableEncrypted) bool {
 	return true
 }
 
+// NextSecureVariableExpiry returns the earliest time after now at which a
+// secure variable expires, or the zero time if none of them expire.
+func (s *StateStore) NextSecureVariableExpiry(ws memdb.WatchSet, now time.Time) (time.Time, error) {
+	txn := s.db.ReadTxn()
+
+	// Iterators from LowerBound can't be watched, so watch the whole table
+	all, err := txn.Get(TableSecureVariables, indexID)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("secure variables lookup failed: %v", err)
+	}
+	ws.Add(all.WatchCh())
+
+	iter, err := txn.LowerBound(TableSecureVariables, indexExpireTime, now.UnixNano()+1)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("secure variables lookup failed: %v", err)
+	}
+	raw := iter.Next()
+	if raw == nil {
+		return time.Time{}, nil
+	}
+	return time.Unix(0, raw.(*structs.SecureVariableEncrypted).ExpireTime), nil
+}
+
 func (s *StateStore) DeleteSecureVariables(msgType structs.MessageType, index uint64, namespace string, paths []string) error {
-	txn := s.db.WriteTxn(index)
+	txn := s.db.WriteTxnMsgT(msgType, index)
 	defer txn.Abort()
 
-	err := s.DeleteSecureVariablesTxn(index, namespace, paths, txn)
-	if err == nil {
-		return txn.Commit()
+	if err := s.DeleteSecureVariablesTxn(index, namespace, paths, txn); err != nil {
+		return err
 	}
-	return err
+	if err := s.recordSecureVariableChanges(index, txn); err != nil {
+		return err
+	}
+	return txn.Commit()
 }
 
 func (s *StateStore) DeleteSecureVariablesTxn(index uint64, namespace string, paths []string, txn Txn) error {
@@ -230,6 +374,149 @@ func (s *StateStore) DeleteSecureVariablesTxn(index uint64, namespace string, pa
 	return nil
 }
 
+// CopySecureVariables writes copies of secure variables to new paths, and
+// with move deletes the variables they were copied from. Nothing is written
+// if any of the copies already exist, or if any of the sources was modified
+// since it was read. Expired secure variables at the destination paths are
+// replaced by the copies, unless they were modified since they were read.
+func (s *StateStore) CopySecureVariables(msgType structs.MessageType, index uint64,
+	sources []*structs.SecureVariableMetadata, svs []*structs.SecureVariableEncrypted,
+	expired []*structs.SecureVariableMetadata, move bool) error {
+	txn := s.db.WriteTxnMsgT(msgType, index)
+	defer txn.Abort()
+
+	for _, src := range sources {
+		existing, err := txn.First(TableSecureVariables, indexID, src.Namespace, src.Path)
+		if err != nil {
+			return fmt.Errorf("secure variable lookup failed: %v", err)
+		}
+		if existing == nil || existing.(*structs.SecureVariableEncrypted).ModifyIndex != src.ModifyIndex {
+			return fmt.Errorf("secure variable %q in namespace %q was modified", src.Path, src.Namespace)
+		}
+	}
+
+	// The expiry time isn't checked here, as applying the copy must not
+	// depend on when it's applied
+	replaced := make(map[structs.NamespacedID]uint64, len(expired))
+	for _, sv := range expired {
+		replaced[structs.NamespacedID{Namespace: sv.Namespace, ID: sv.Path}] = sv.ModifyIndex
+	}
+	for _, sv := range svs {
+		existing, err := txn.First(TableSecureVariables, indexID, sv.Namespace, sv.Path)
+		if err != nil {
+			return fmt.Errorf("secure variable lookup failed: %v", err)
+		}
+		if existing == nil {
+			continue
+		}
+		checkIndex, ok := replaced[structs.NamespacedID{Namespace: sv.Namespace, ID: sv.Path}]
+		if !ok || existing.(*structs.SecureVariableEncrypted).ModifyIndex != checkIndex {
+			return fmt.Errorf("secure variable %q in namespace %q already exists", sv.Path, sv.Namespace)
+		}
+		if err := s.DeleteSecureVariableTxn(index, sv.Namespace, sv.Path, txn); err != nil {
+			return err
+		}
+	}
+
+	var updated bool
+	for _, sv := range svs {
+		if err := s.upsertSecureVariableImpl(index, txn, sv, &updated); err != nil {
+			return err
+		}
+	}
+	if move {
+		for _, src := range sources {
+			if err := s.DeleteSecureVariableTxn(index, src.Namespace, src.Path, txn); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := txn.Insert(tableIndex, &IndexEntry{TableSecureVariables, index}); err != nil {
+		return fmt.Errorf("index update failed: %v", err)
+	}
+	if err := s.recordSecureVariableChanges(index, txn); err != nil {
+		return err
+	}
+
+	return txn.Commit()
+}
+
+// RekeySecureVariables replaces the encrypted data of secure variables and
+// of kept previous versions of secure variables that have been re-encrypted
+// with another root key. The user's data is unchanged, so the metadata is
+// left as is: no previous version is kept and check-and-set indexes remain
+// valid. Entries modified or dropped since they were read are skipped, as
+// writes are always encrypted with the active key. All root keys use the
+// same algorithm, so the size tracked for quotas doesn't change.
+func (s *StateStore) RekeySecureVariables(msgType structs.MessageType, index uint64,
+	svs, versions []*structs.SecureVariableEncrypted) error {
+	txn := s.db.WriteTxn(index)
+	defer txn.Abort()
+
+	rekey := func(table string, args []interface{}, sv *structs.SecureVariableEncrypted) (bool, error) {
+		raw, err := txn.First(table, indexID, args...)
+		if err != nil {
+			return false, fmt.Errorf("secure variable lookup failed: %v", err)
+		}
+		if raw == nil {
+			return false, nil
+		}
+		existing := raw.(*structs.SecureVariableEncrypted)
+		if existing.ModifyIndex != sv.ModifyIndex {
+			return false, nil
+		}
+
+		// refuse to leave data encrypted with a key that no other server
+		// would be able to find in the keyring
+		rawKey, err := txn.First(TableRootKeyMeta, indexID, sv.KeyID)
+		if err != nil {
+			return false, fmt.Errorf("root key lookup failed: %v", err)
+		}
+		if rawKey == nil {
+			return false, fmt.Errorf("root key %q not found", sv.KeyID)
+		}
+
+		updated := *existing
+		updated.SecureVariableData = sv.SecureVariableData
+		if err := txn.Insert(table, &updated); err != nil {
+			return false, fmt.Errorf("secure variable insert failed: %v", err)
+		}
+		return true, nil
+	}
+
+	var updatedVars, updatedVersions bool
+	for _, sv := range svs {
+		updated, err := rekey(TableSecureVariables,
+			[]interface{}{sv.Namespace, sv.Path}, sv)
+		if err != nil {
+			return err
+		}
+		updatedVars = updatedVars || updated
+	}
+	for _, sv := range versions {
+		updated, err := rekey(TableSecureVariablesHistory,
+			[]interface{}{sv.Namespace, sv.Path, sv.ModifyIndex}, sv)
+		if err != nil {
+			return err
+		}
+		updatedVersions = updatedVersions || updated
+	}
+
+	if updatedVars {
+		if err := txn.Insert(tableIndex, &IndexEntry{TableSecureVariables, index}); err != nil {
+			return fmt.Errorf("index update failed: %v", err)
+		}
+	}
+	if updatedVersions {
+		if err := txn.Insert(tableIndex, &IndexEntry{TableSecureVariablesHistory, index}); err != nil {
+			return fmt.Errorf("index update failed: %v", err)
+		}
+	}
+
+	return txn.Commit()
+}
+
 // DeleteSecureVariable is used to delete a single secure variable
 func (s *StateStore) DeleteSecureVariable(index uint64, namespace, path string) error {
 	txn := s.db.WriteTxn(index)
@@ -266,6 +553,22 @@ func (s *StateStore) DeleteSecureVariableTxn(index uint64, namespace, path strin
 		return fmt.Errorf("index update failed: %v", err)
 	}
 
+	// Delete the previous versions of the variable along with it
+	versions, err := s.secureVariableVersions(txn, nil, namespace, path)
+	if err != nil {
+		return err
+	}
+	for _, version := range versions {
+		if err := txn.Delete(TableSecureVariablesHistory, version); err != nil {
+			return fmt.Errorf("secure variable version delete failed: %v", err)
+		}
+	}
+	if len(versions) > 0 {
+		if err := txn.Insert("index", &IndexEntry{TableSecureVariablesHistory, index}); err != nil {
+			return fmt.Errorf("index update failed: %v", err)
+		}
+	}
+
 	// Track quota usage
 	if existingQuota != nil {
 		quotaUsed := existingQuota.(*structs.SecureVariablesQuota)
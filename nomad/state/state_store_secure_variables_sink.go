@@ -0,0 +1,187 @@
+package state
+
+import (
+	"fmt"
+
+	memdb "github.com/hashicorp/go-memdb"
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+// recordSecureVariableChanges keeps the writes and deletions of secure
+// variables made in the transaction so that the leader can forward them to
+// the secure variable sinks. Nothing is kept if no sink is registered.
+func (s *StateStore) recordSecureVariableChanges(index uint64, txn *txn) error {
+	existing, err := txn.First(TableSecureVariablesSinkCheckpoints, indexID)
+	if err != nil {
+		return fmt.Errorf("secure variable sink checkpoint lookup failed: %v", err)
+	}
+	if existing == nil {
+		return nil
+	}
+
+	var changes []*structs.SecureVariableChange
+	for _, change := range txn.Changes() {
+		if change.Table != TableSecureVariables {
+			continue
+		}
+		if change.Deleted() {
+			before := change.Before.(*structs.SecureVariableEncrypted)
+			changes = append(changes, &structs.SecureVariableChange{
+				Type:           structs.TypeSecureVariableDeleted,
+				SecureVariable: before.SecureVariableMetadata.Copy(),
+			})
+			continue
+		}
+		after := change.After.(*structs.SecureVariableEncrypted)
+		changes = append(changes, &structs.SecureVariableChange{
+			Type:           structs.TypeSecureVariableUpserted,
+			SecureVariable: after.SecureVariableMetadata.Copy(),
+		})
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+
+	record := &structs.SecureVariableChanges{
+		Index:   index,
+		Changes: changes,
+	}
+	if err := txn.Insert(TableSecureVariablesChanges, record); err != nil {
+		return fmt.Errorf("secure variable changes insert failed: %v", err)
+	}
+	if err := txn.Insert(tableIndex, &IndexEntry{TableSecureVariablesChanges, index}); err != nil {
+		return fmt.Errorf("index update failed: %v", err)
+	}
+	return nil
+}
+
+// SecureVariableChangesAfter returns an iterator over the kept changes to
+// secure variables made after an index, in index order.
+func (s *StateStore) SecureVariableChangesAfter(ws memdb.WatchSet, index uint64) (memdb.ResultIterator, error) {
+	txn := s.db.ReadTxn()
+
+	// Iterators from LowerBound can't be watched, so watch the whole table
+	all, err := txn.Get(TableSecureVariablesChanges, indexID)
+	if err != nil {
+		return nil, fmt.Errorf("secure variable changes lookup failed: %v", err)
+	}
+	ws.Add(all.WatchCh())
+
+	iter, err := txn.LowerBound(TableSecureVariablesChanges, indexID, index+1)
+	if err != nil {
+		return nil, fmt.Errorf("secure variable changes lookup failed: %v", err)
+	}
+	return iter, nil
+}
+
+// SecureVariablesSinkCheckpoint returns the checkpoint of a secure variable
+// sink, or nil if the sink isn't registered.
+func (s *StateStore) SecureVariablesSinkCheckpoint(ws memdb.WatchSet, name string) (*structs.SecureVariablesSinkCheckpoint, error) {
+	txn := s.db.ReadTxn()
+
+	watchCh, raw, err := txn.FirstWatch(TableSecureVariablesSinkCheckpoints, indexID, name)
+	if err != nil {
+		return nil, fmt.Errorf("secure variable sink checkpoint lookup failed: %v", err)
+	}
+	ws.Add(watchCh)
+
+	if raw == nil {
+		return nil, nil
+	}
+	return raw.(*structs.SecureVariablesSinkCheckpoint), nil
+}
+
+// SecureVariablesSinkCheckpoints returns an iterator over the checkpoints of
+// all the registered secure variable sinks.
+func (s *StateStore) SecureVariablesSinkCheckpoints(ws memdb.WatchSet) (memdb.ResultIterator, error) {
+	txn := s.db.ReadTxn()
+
+	iter, err := txn.Get(TableSecureVariablesSinkCheckpoints, indexID)
+	if err != nil {
+		return nil, fmt.Errorf("secure variable sink checkpoints lookup failed: %v", err)
+	}
+	ws.Add(iter.WatchCh())
+
+	return iter, nil
+}
+
+// UpsertSecureVariablesSinkCheckpoints updates the checkpoints of secure
+// variable sinks, removing the checkpoints of other sinks with reset. Kept
+// changes that every registered sink has received are then dropped, and all
+// of them if no sink is left.
+func (s *StateStore) UpsertSecureVariablesSinkCheckpoints(index uint64,
+	checkpoints []*structs.SecureVariablesSinkCheckpoint, reset bool) error {
+	txn := s.db.WriteTxn(index)
+	defer txn.Abort()
+
+	names := make(map[string]struct{}, len(checkpoints))
+	for _, checkpoint := range checkpoints {
+		names[checkpoint.Name] = struct{}{}
+
+		existing, err := txn.First(TableSecureVariablesSinkCheckpoints, indexID, checkpoint.Name)
+		if err != nil {
+			return fmt.Errorf("secure variable sink checkpoint lookup failed: %v", err)
+		}
+		updated := *checkpoint
+		updated.CreateIndex = index
+		if existing != nil {
+			updated.CreateIndex = existing.(*structs.SecureVariablesSinkCheckpoint).CreateIndex
+		}
+		updated.ModifyIndex = index
+		if err := txn.Insert(TableSecureVariablesSinkCheckpoints, &updated); err != nil {
+			return fmt.Errorf("secure variable sink checkpoint insert failed: %v", err)
+		}
+	}
+
+	iter, err := txn.Get(TableSecureVariablesSinkCheckpoints, indexID)
+	if err != nil {
+		return fmt.Errorf("secure variable sink checkpoints lookup failed: %v", err)
+	}
+	var registered bool
+	var minIndex uint64
+	var removed []interface{}
+	for raw := iter.Next(); raw != nil; raw = iter.Next() {
+		checkpoint := raw.(*structs.SecureVariablesSinkCheckpoint)
+		if _, ok := names[checkpoint.Name]; reset && !ok {
+			removed = append(removed, raw)
+			continue
+		}
+		if !registered || checkpoint.LastIndex < minIndex {
+			minIndex = checkpoint.LastIndex
+		}
+		registered = true
+	}
+	for _, raw := range removed {
+		if err := txn.Delete(TableSecureVariablesSinkCheckpoints, raw); err != nil {
+			return fmt.Errorf("secure variable sink checkpoint delete failed: %v", err)
+		}
+	}
+	if err := txn.Insert(tableIndex, &IndexEntry{TableSecureVariablesSinkCheckpoints, index}); err != nil {
+		return fmt.Errorf("index update failed: %v", err)
+	}
+
+	// Drop the changes every sink has received
+	iter, err = txn.Get(TableSecureVariablesChanges, indexID)
+	if err != nil {
+		return fmt.Errorf("secure variable changes lookup failed: %v", err)
+	}
+	var dropped []interface{}
+	for raw := iter.Next(); raw != nil; raw = iter.Next() {
+		if registered && raw.(*structs.SecureVariableChanges).Index > minIndex {
+			break
+		}
+		dropped = append(dropped, raw)
+	}
+	for _, raw := range dropped {
+		if err := txn.Delete(TableSecureVariablesChanges, raw); err != nil {
+			return fmt.Errorf("secure variable changes delete failed: %v", err)
+		}
+	}
+	if len(dropped) > 0 {
+		if err := txn.Insert(tableIndex, &IndexEntry{TableSecureVariablesChanges, index}); err != nil {
+			return fmt.Errorf("index update failed: %v", err)
+		}
+	}
+
+	return txn.Commit()
+}
@@ -0,0 +1,87 @@
+package state
+
+import (
+	"testing"
+
+	memdb "github.com/hashicorp/go-memdb"
+	"github.com/hashicorp/nomad/ci"
+	"github.com/hashicorp/nomad/nomad/mock"
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStateStore_SecureVariablesSinkCheckpoints(t *testing.T) {
+	ci.Parallel(t)
+	testState := testStateStore(t)
+
+	changesAfter := func(index uint64) []*structs.SecureVariableChanges {
+		t.Helper()
+		iter, err := testState.SecureVariableChangesAfter(memdb.NewWatchSet(), index)
+		require.NoError(t, err)
+		var out []*structs.SecureVariableChanges
+		for raw := iter.Next(); raw != nil; raw = iter.Next() {
+			out = append(out, raw.(*structs.SecureVariableChanges))
+		}
+		return out
+	}
+
+	// Changes aren't kept while no sink is registered
+	sv1 := mock.SecureVariableEncrypted()
+	require.NoError(t, testState.UpsertSecureVariables(
+		structs.SecureVariableUpsertRequestType, 10, []*structs.SecureVariableEncrypted{sv1}))
+	require.Empty(t, changesAfter(0))
+
+	require.NoError(t, testState.UpsertSecureVariablesSinkCheckpoints(20,
+		[]*structs.SecureVariablesSinkCheckpoint{
+			{Name: "a", LastIndex: 20},
+			{Name: "b", LastIndex: 20},
+		}, true))
+
+	sv2 := mock.SecureVariableEncrypted()
+	require.NoError(t, testState.UpsertSecureVariables(
+		structs.SecureVariableUpsertRequestType, 30, []*structs.SecureVariableEncrypted{sv2}))
+	require.NoError(t, testState.DeleteSecureVariables(
+		structs.SecureVariableDeleteRequestType, 40, sv1.Namespace, []string{sv1.Path}))
+
+	changes := changesAfter(0)
+	require.Len(t, changes, 2)
+	require.Equal(t, uint64(30), changes[0].Index)
+	require.Len(t, changes[0].Changes, 1)
+	require.Equal(t, structs.TypeSecureVariableUpserted, changes[0].Changes[0].Type)
+	require.Equal(t, sv2.Path, changes[0].Changes[0].SecureVariable.Path)
+	require.Equal(t, uint64(40), changes[1].Index)
+	require.Equal(t, structs.TypeSecureVariableDeleted, changes[1].Changes[0].Type)
+	require.Equal(t, sv1.Path, changes[1].Changes[0].SecureVariable.Path)
+	require.Len(t, changesAfter(30), 1)
+
+	// Changes are kept until every sink has received them
+	require.NoError(t, testState.UpsertSecureVariablesSinkCheckpoints(50,
+		[]*structs.SecureVariablesSinkCheckpoint{{Name: "a", LastIndex: 40}}, false))
+	require.Len(t, changesAfter(0), 2)
+
+	require.NoError(t, testState.UpsertSecureVariablesSinkCheckpoints(60,
+		[]*structs.SecureVariablesSinkCheckpoint{{Name: "b", LastIndex: 30}}, false))
+	changes = changesAfter(0)
+	require.Len(t, changes, 1)
+	require.Equal(t, uint64(40), changes[0].Index)
+
+	checkpoint, err := testState.SecureVariablesSinkCheckpoint(nil, "b")
+	require.NoError(t, err)
+	require.Equal(t, uint64(30), checkpoint.LastIndex)
+	require.Equal(t, uint64(20), checkpoint.CreateIndex)
+	require.Equal(t, uint64(60), checkpoint.ModifyIndex)
+
+	// Resetting drops the sinks that aren't included
+	require.NoError(t, testState.UpsertSecureVariablesSinkCheckpoints(70,
+		[]*structs.SecureVariablesSinkCheckpoint{{Name: "a", LastIndex: 40}}, true))
+	checkpoint, err = testState.SecureVariablesSinkCheckpoint(nil, "b")
+	require.NoError(t, err)
+	require.Nil(t, checkpoint)
+	require.Empty(t, changesAfter(0))
+
+	// Once no sink is left no more changes are kept
+	require.NoError(t, testState.UpsertSecureVariablesSinkCheckpoints(80, nil, true))
+	require.NoError(t, testState.UpsertSecureVariables(
+		structs.SecureVariableUpsertRequestType, 90, []*structs.SecureVariableEncrypted{sv1}))
+	require.Empty(t, changesAfter(0))
+}
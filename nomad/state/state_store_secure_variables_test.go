@@ -570,6 +570,307 @@ func TestStateStore_ListSecureVariablesByKeyID(t *testing.T) {
 	require.Equal(t, 5, count)
 }
 
+func TestStateStore_SecureVariableVersions(t *testing.T) {
+	ci.Parallel(t)
+	testState := testStateStore(t)
+	ws := memdb.NewWatchSet()
+
+	keyMeta := structs.NewRootKeyMeta()
+	require.NoError(t, testState.UpsertRootKeyMeta(5, keyMeta, false))
+
+	sv := mock.SecureVariableEncrypted()
+	sv.KeyID = keyMeta.KeyID
+	require.NoError(t, testState.UpsertSecureVariables(
+		structs.MsgTypeTestSetup, 10, []*structs.SecureVariableEncrypted{sv}))
+
+	// A newly created variable has no previous versions.
+	versions, err := testState.GetSecureVariableVersions(ws, sv.Namespace, sv.Path)
+	require.NoError(t, err)
+	require.Empty(t, versions)
+
+	// Update the variable more times than there are versions kept, so the
+	// oldest versions are dropped.
+	updates := structs.SecureVariableTrackedVersions + 2
+	for i := 1; i <= updates; i++ {
+		nv := sv.Copy()
+		nv.Data = []byte(fmt.Sprintf("data-%d", i))
+		require.NoError(t, testState.UpsertSecureVariables(
+			structs.MsgTypeTestSetup, uint64(10+i), []*structs.SecureVariableEncrypted{&nv}))
+	}
+
+	historyIndex, err := testState.Index(TableSecureVariablesHistory)
+	require.NoError(t, err)
+	require.Equal(t, uint64(10+updates), historyIndex)
+
+	versions, err = testState.GetSecureVariableVersions(ws, sv.Namespace, sv.Path)
+	require.NoError(t, err)
+	require.Len(t, versions, structs.SecureVariableTrackedVersions)
+	for i, version := range versions {
+		// The newest kept version is the one replaced by the last update.
+		modifyIndex := uint64(10 + updates - 1 - i)
+		require.Equal(t, modifyIndex, version.ModifyIndex)
+		require.Equal(t, uint64(10), version.CreateIndex)
+	}
+
+	// Look up a single kept version, and one that was dropped.
+	version, err := testState.GetSecureVariableVersion(ws, sv.Namespace, sv.Path, uint64(10+updates-1))
+	require.NoError(t, err)
+	require.NotNil(t, version)
+	require.Equal(t, []byte(fmt.Sprintf("data-%d", updates-1)), version.Data)
+
+	version, err = testState.GetSecureVariableVersion(ws, sv.Namespace, sv.Path, 10)
+	require.NoError(t, err)
+	require.Nil(t, version)
+
+	// Versions of another variable whose path shares the prefix are kept
+	// separately.
+	other := mock.SecureVariableEncrypted()
+	other.Path = sv.Path + "2"
+	require.NoError(t, testState.UpsertSecureVariables(
+		structs.MsgTypeTestSetup, 30, []*structs.SecureVariableEncrypted{other}))
+	otherU := other.Copy()
+	otherU.Data = []byte("other")
+	require.NoError(t, testState.UpsertSecureVariables(
+		structs.MsgTypeTestSetup, 31, []*structs.SecureVariableEncrypted{&otherU}))
+
+	versions, err = testState.GetSecureVariableVersions(ws, sv.Namespace, sv.Path)
+	require.NoError(t, err)
+	require.Len(t, versions, structs.SecureVariableTrackedVersions)
+
+	// Deleting the root key drops the versions encrypted with it.
+	require.NoError(t, testState.DeleteRootKeyMeta(40, keyMeta.KeyID))
+	versions, err = testState.GetSecureVariableVersions(ws, sv.Namespace, sv.Path)
+	require.NoError(t, err)
+	require.Empty(t, versions)
+
+	// Deleting a variable drops its versions.
+	require.NoError(t, testState.DeleteSecureVariable(50, other.Namespace, other.Path))
+	versions, err = testState.GetSecureVariableVersions(ws, other.Namespace, other.Path)
+	require.NoError(t, err)
+	require.Empty(t, versions)
+
+	historyIndex, err = testState.Index(TableSecureVariablesHistory)
+	require.NoError(t, err)
+	require.Equal(t, uint64(50), historyIndex)
+}
+
+func TestStateStore_RekeySecureVariables(t *testing.T) {
+	ci.Parallel(t)
+	testState := testStateStore(t)
+	ws := memdb.NewWatchSet()
+
+	sv := mock.SecureVariableEncrypted()
+	sv.KeyID = "old"
+	require.NoError(t, testState.UpsertSecureVariables(
+		structs.MsgTypeTestSetup, 10, []*structs.SecureVariableEncrypted{sv}))
+	nv := sv.Copy()
+	nv.Data = []byte("current")
+	require.NoError(t, testState.UpsertSecureVariables(
+		structs.MsgTypeTestSetup, 11, []*structs.SecureVariableEncrypted{&nv}))
+
+	// Another variable is modified after it was read for rekeying.
+	stale := mock.SecureVariableEncrypted()
+	stale.KeyID = "old"
+	require.NoError(t, testState.UpsertSecureVariables(
+		structs.MsgTypeTestSetup, 12, []*structs.SecureVariableEncrypted{stale}))
+	modified := stale.Copy()
+	modified.Data = []byte("modified")
+	require.NoError(t, testState.UpsertSecureVariables(
+		structs.MsgTypeTestSetup, 13, []*structs.SecureVariableEncrypted{&modified}))
+
+	current, err := testState.GetSecureVariable(ws, sv.Namespace, sv.Path)
+	require.NoError(t, err)
+	versions, err := testState.GetSecureVariableVersions(ws, sv.Namespace, sv.Path)
+	require.NoError(t, err)
+	require.Len(t, versions, 1)
+
+	rekeyed := func(in *structs.SecureVariableEncrypted) *structs.SecureVariableEncrypted {
+		out := in.Copy()
+		out.KeyID = "new"
+		out.Data = append([]byte("new-"), in.Data...)
+		return &out
+	}
+	staleRead := *stale
+	staleRead.ModifyIndex = 12
+
+	// Rekeying to a key that isn't in the keyring fails.
+	err = testState.RekeySecureVariables(structs.MsgTypeTestSetup, 14,
+		[]*structs.SecureVariableEncrypted{rekeyed(current)}, nil)
+	require.EqualError(t, err, `root key "new" not found`)
+
+	keyMeta := structs.NewRootKeyMeta()
+	keyMeta.KeyID = "new"
+	require.NoError(t, testState.UpsertRootKeyMeta(15, keyMeta, false))
+
+	require.NoError(t, testState.RekeySecureVariables(structs.MsgTypeTestSetup, 20,
+		[]*structs.SecureVariableEncrypted{rekeyed(current), rekeyed(&staleRead)},
+		[]*structs.SecureVariableEncrypted{rekeyed(versions[0])}))
+
+	// The data is replaced but the metadata and versions are unchanged.
+	out, err := testState.GetSecureVariable(ws, sv.Namespace, sv.Path)
+	require.NoError(t, err)
+	require.Equal(t, "new", out.KeyID)
+	require.Equal(t, []byte("new-current"), out.Data)
+	require.Equal(t, current.SecureVariableMetadata, out.SecureVariableMetadata)
+
+	versions, err = testState.GetSecureVariableVersions(ws, sv.Namespace, sv.Path)
+	require.NoError(t, err)
+	require.Len(t, versions, 1)
+	require.Equal(t, "new", versions[0].KeyID)
+	require.Equal(t, uint64(10), versions[0].ModifyIndex)
+
+	// The variable modified since it was read is skipped.
+	out, err = testState.GetSecureVariable(ws, stale.Namespace, stale.Path)
+	require.NoError(t, err)
+	require.Equal(t, "old", out.KeyID)
+	require.Equal(t, []byte("modified"), out.Data)
+
+	iter, err := testState.GetSecureVariableVersionsByKeyID(ws, "old")
+	require.NoError(t, err)
+	raw := iter.Next()
+	require.NotNil(t, raw)
+	require.Equal(t, stale.Path, raw.(*structs.SecureVariableEncrypted).Path)
+	require.Nil(t, iter.Next())
+
+	for _, table := range []string{TableSecureVariables, TableSecureVariablesHistory} {
+		index, err := testState.Index(table)
+		require.NoError(t, err)
+		require.Equal(t, uint64(20), index)
+	}
+}
+
+func TestStateStore_CopySecureVariables(t *testing.T) {
+	ci.Parallel(t)
+	testState := testStateStore(t)
+	ws := memdb.NewWatchSet()
+
+	src := mock.SecureVariableEncrypted()
+	require.NoError(t, testState.UpsertSecureVariables(
+		structs.MsgTypeTestSetup, 10, []*structs.SecureVariableEncrypted{src}))
+
+	copyOf := func(sv *structs.SecureVariableEncrypted, path string) *structs.SecureVariableEncrypted {
+		out := sv.Copy()
+		out.Path = path
+		return &out
+	}
+
+	// A stale source is rejected
+	stale := src.SecureVariableMetadata
+	stale.ModifyIndex = 9
+	err := testState.CopySecureVariables(structs.MsgTypeTestSetup, 20,
+		[]*structs.SecureVariableMetadata{&stale},
+		[]*structs.SecureVariableEncrypted{copyOf(src, "copy")}, nil, false)
+	require.EqualError(t, err, fmt.Sprintf("secure variable %q in namespace %q was modified", src.Path, src.Namespace))
+
+	// Copy the variable
+	meta := src.SecureVariableMetadata
+	require.NoError(t, testState.CopySecureVariables(structs.MsgTypeTestSetup, 21,
+		[]*structs.SecureVariableMetadata{&meta},
+		[]*structs.SecureVariableEncrypted{copyOf(src, "copy")}, nil, false))
+
+	out, err := testState.GetSecureVariable(ws, src.Namespace, "copy")
+	require.NoError(t, err)
+	require.NotNil(t, out)
+	require.Equal(t, src.Data, out.Data)
+	require.Equal(t, uint64(21), out.CreateIndex)
+
+	// An existing destination is rejected
+	err = testState.CopySecureVariables(structs.MsgTypeTestSetup, 22,
+		[]*structs.SecureVariableMetadata{&meta},
+		[]*structs.SecureVariableEncrypted{copyOf(src, "copy")}, nil, false)
+	require.EqualError(t, err, fmt.Sprintf("secure variable %q in namespace %q already exists", "copy", src.Namespace))
+
+	// Move the variable, which deletes the source
+	require.NoError(t, testState.CopySecureVariables(structs.MsgTypeTestSetup, 23,
+		[]*structs.SecureVariableMetadata{&meta},
+		[]*structs.SecureVariableEncrypted{copyOf(src, "moved")}, nil, true))
+
+	out, err = testState.GetSecureVariable(ws, src.Namespace, src.Path)
+	require.NoError(t, err)
+	require.Nil(t, out)
+	out, err = testState.GetSecureVariable(ws, src.Namespace, "moved")
+	require.NoError(t, err)
+	require.NotNil(t, out)
+
+	// Quota usage counts the two copies
+	quotaUsed, err := testState.SecureVariablesQuotaByNamespace(ws, src.Namespace)
+	require.NoError(t, err)
+	require.Equal(t, uint64(2*len(src.Data)), quotaUsed.Size)
+
+	index, err := testState.Index(TableSecureVariables)
+	require.NoError(t, err)
+	require.Equal(t, uint64(23), index)
+
+	// An expired destination is only replaced if it wasn't modified since
+	// it was read
+	expired := copyOf(src, "expired")
+	expired.ExpireTime = time.Now().Add(-time.Minute).UnixNano()
+	require.NoError(t, testState.UpsertSecureVariables(
+		structs.MsgTypeTestSetup, 24, []*structs.SecureVariableEncrypted{expired}))
+	moved, err := testState.GetSecureVariable(ws, src.Namespace, "moved")
+	require.NoError(t, err)
+
+	staleExpired := expired.SecureVariableMetadata
+	staleExpired.ModifyIndex = 20
+	err = testState.CopySecureVariables(structs.MsgTypeTestSetup, 25,
+		[]*structs.SecureVariableMetadata{&moved.SecureVariableMetadata},
+		[]*structs.SecureVariableEncrypted{copyOf(moved, "expired")},
+		[]*structs.SecureVariableMetadata{&staleExpired}, false)
+	require.EqualError(t, err, fmt.Sprintf("secure variable %q in namespace %q already exists", "expired", src.Namespace))
+
+	current, err := testState.GetSecureVariable(ws, src.Namespace, "expired")
+	require.NoError(t, err)
+	require.NoError(t, testState.CopySecureVariables(structs.MsgTypeTestSetup, 26,
+		[]*structs.SecureVariableMetadata{&moved.SecureVariableMetadata},
+		[]*structs.SecureVariableEncrypted{copyOf(moved, "expired")},
+		[]*structs.SecureVariableMetadata{&current.SecureVariableMetadata}, false))
+
+	out, err = testState.GetSecureVariable(ws, src.Namespace, "expired")
+	require.NoError(t, err)
+	require.Equal(t, uint64(26), out.CreateIndex)
+	require.Zero(t, out.ExpireTime)
+	versions, err := testState.GetSecureVariableVersions(ws, src.Namespace, "expired")
+	require.NoError(t, err)
+	require.Empty(t, versions)
+}
+
+func TestStateStore_NextSecureVariableExpiry(t *testing.T) {
+	ci.Parallel(t)
+	testState := testStateStore(t)
+
+	now := time.Now()
+	next, err := testState.NextSecureVariableExpiry(nil, now)
+	require.NoError(t, err)
+	require.True(t, next.IsZero())
+
+	expired := mock.SecureVariableEncrypted()
+	expired.ExpireTime = now.Add(-time.Minute).UnixNano()
+	later := mock.SecureVariableEncrypted()
+	later.ExpireTime = now.Add(time.Hour).UnixNano()
+	never := mock.SecureVariableEncrypted()
+	require.NoError(t, testState.UpsertSecureVariables(structs.MsgTypeTestSetup, 10,
+		[]*structs.SecureVariableEncrypted{expired, later, never}))
+
+	// Secure variables that have already expired are skipped
+	next, err = testState.NextSecureVariableExpiry(nil, now)
+	require.NoError(t, err)
+	require.Equal(t, later.ExpireTime, next.UnixNano())
+
+	// Writes to the table fire the watch
+	ws := memdb.NewWatchSet()
+	_, err = testState.NextSecureVariableExpiry(ws, now)
+	require.NoError(t, err)
+	sooner := mock.SecureVariableEncrypted()
+	sooner.ExpireTime = now.Add(time.Minute).UnixNano()
+	require.NoError(t, testState.UpsertSecureVariables(structs.MsgTypeTestSetup, 20,
+		[]*structs.SecureVariableEncrypted{sooner}))
+	require.True(t, watchFired(ws))
+
+	next, err = testState.NextSecureVariableExpiry(nil, now)
+	require.NoError(t, err)
+	require.Equal(t, sooner.ExpireTime, next.UnixNano())
+}
+
 // mockSecureVariables returns a random number of secure variables between min
 // and max inclusive.
 func mockSecureVariables(count int) (
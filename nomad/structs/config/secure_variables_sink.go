@@ -0,0 +1,119 @@
+package config
+
+import (
+	"fmt"
+	"time"
+
+	multierror "github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/nomad/helper"
+)
+
+const (
+	// SecureVariablesSinkTypeWebhook posts secure variable change events to
+	// an HTTP endpoint.
+	SecureVariablesSinkTypeWebhook = "webhook"
+)
+
+// SecureVariablesSink is the configuration of an external system that
+// secure variable change events are forwarded to.
+type SecureVariablesSink struct {
+	// Name is a unique name given to the sink
+	Name string `hcl:",key"`
+
+	// Type is the sink type to configure. (webhook)
+	Type string `hcl:"type"`
+
+	// Address is the URL events are posted to.
+	Address string `hcl:"address"`
+
+	// PathPrefixes limits the events forwarded to those for secure variables
+	// with a path starting with one of the prefixes. All events are
+	// forwarded if empty.
+	PathPrefixes []string `hcl:"path_prefixes"`
+
+	// HMACKey is used to sign the body of each request. The signature is
+	// sent in the X-Nomad-Signature header when set.
+	HMACKey string `hcl:"hmac_key" json:"-"`
+
+	// Headers are added to each request. They often hold credentials, so
+	// like the HMAC key they aren't included in the agent's config output.
+	Headers map[string]string `hcl:"headers" json:"-"`
+
+	// Timeout is the time to wait for the sink to accept a request.
+	Timeout    time.Duration `hcl:"-"`
+	TimeoutHCL string        `hcl:"timeout" json:"-"`
+}
+
+// Copy returns a new copy of a SecureVariablesSink
+func (s *SecureVariablesSink) Copy() *SecureVariablesSink {
+	if s == nil {
+		return nil
+	}
+
+	nc := new(SecureVariablesSink)
+	*nc = *s
+
+	// Copy slices and maps
+	nc.PathPrefixes = helper.CopySliceString(s.PathPrefixes)
+	nc.Headers = helper.CopyMapStringString(s.Headers)
+
+	return nc
+}
+
+// Validate returns an error if the sink can not be used.
+func (s *SecureVariablesSink) Validate() error {
+	var mErr multierror.Error
+	switch s.Type {
+	case SecureVariablesSinkTypeWebhook:
+	case "":
+		_ = multierror.Append(&mErr, fmt.Errorf("sink type is required"))
+	default:
+		_ = multierror.Append(&mErr, fmt.Errorf("unsupported sink type %q, only %q is supported",
+			s.Type, SecureVariablesSinkTypeWebhook))
+	}
+	if s.Address == "" {
+		_ = multierror.Append(&mErr, fmt.Errorf("sink address is required"))
+	}
+	if s.Timeout < 0 {
+		_ = multierror.Append(&mErr, fmt.Errorf("sink timeout must not be negative"))
+	}
+	return mErr.ErrorOrNil()
+}
+
+// CopySliceSecureVariablesSink returns a copy of a slice of sinks.
+func CopySliceSecureVariablesSink(a []*SecureVariablesSink) []*SecureVariablesSink {
+	l := len(a)
+	if l == 0 {
+		return nil
+	}
+
+	ns := make([]*SecureVariablesSink, l)
+	for idx, cfg := range a {
+		ns[idx] = cfg.Copy()
+	}
+
+	return ns
+}
+
+// SecureVariablesSinkSliceMerge merges two slices of sinks by name. Sinks
+// from b take precedence.
+func SecureVariablesSinkSliceMerge(a, b []*SecureVariablesSink) []*SecureVariablesSink {
+	n := make([]*SecureVariablesSink, len(a))
+	seenKeys := make(map[string]int, len(a))
+
+	for i, config := range a {
+		n[i] = config.Copy()
+		seenKeys[config.Name] = i
+	}
+
+	for _, config := range b {
+		if fIndex, ok := seenKeys[config.Name]; ok {
+			n[fIndex] = config.Copy()
+			continue
+		}
+
+		n = append(n, config.Copy())
+	}
+
+	return n
+}
@@ -0,0 +1,49 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/hashicorp/nomad/ci"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSecureVariablesSink_Validate(t *testing.T) {
+	ci.Parallel(t)
+
+	sink := &SecureVariablesSink{
+		Name:    "sync",
+		Type:    SecureVariablesSinkTypeWebhook,
+		Address: "https://sync.example.com",
+	}
+	require.NoError(t, sink.Validate())
+
+	sink = &SecureVariablesSink{Name: "sync"}
+	err := sink.Validate()
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "sink type is required")
+	require.Contains(t, err.Error(), "sink address is required")
+
+	sink = &SecureVariablesSink{Name: "sync", Type: "kafka", Address: "broker:9092"}
+	require.EqualError(t, sink.Validate(),
+		"1 error occurred:\n\t* unsupported sink type \"kafka\", only \"webhook\" is supported\n\n")
+}
+
+func TestSecureVariablesSinkSliceMerge(t *testing.T) {
+	ci.Parallel(t)
+
+	a := []*SecureVariablesSink{
+		{Name: "one", Type: "webhook", Address: "https://one.example.com"},
+		{Name: "two", Type: "webhook", Address: "https://two.example.com"},
+	}
+	b := []*SecureVariablesSink{
+		{Name: "two", Type: "webhook", Address: "https://two.example.org"},
+		{Name: "three", Type: "webhook", Address: "https://three.example.com"},
+	}
+
+	result := SecureVariablesSinkSliceMerge(a, b)
+	require.Equal(t, []*SecureVariablesSink{
+		{Name: "one", Type: "webhook", Address: "https://one.example.com"},
+		{Name: "two", Type: "webhook", Address: "https://two.example.org"},
+		{Name: "three", Type: "webhook", Address: "https://three.example.com"},
+	}, result)
+}
@@ -544,6 +544,12 @@ func (t *Task) Diff(other *Task, contextual bool) (*TaskDiff, error) {
 		diff.Objects = append(diff.Objects, tmplDiffs...)
 	}
 
+	// Secure variables diff
+	svDiffs := taskSecureVariableDiffs(t.SecureVariables, other.SecureVariables, contextual)
+	if svDiffs != nil {
+		diff.Objects = append(diff.Objects, svDiffs...)
+	}
+
 	return diff, nil
 }
 
@@ -1623,6 +1629,74 @@ func vaultDiff(old, new *Vault, contextual bool) *ObjectDiff {
 	return diff
 }
 
+// taskSecureVariableDiff returns the diff of two TaskSecureVariable
+// objects. If contextual diff is enabled, all fields will be returned, even
+// if no diff occurred.
+func taskSecureVariableDiff(old, new *TaskSecureVariable, contextual bool) *ObjectDiff {
+	diff := &ObjectDiff{Type: DiffTypeNone, Name: "SecureVariable"}
+	var oldPrimitiveFlat, newPrimitiveFlat map[string]string
+
+	if reflect.DeepEqual(old, new) {
+		return nil
+	} else if old == nil {
+		old = &TaskSecureVariable{}
+		diff.Type = DiffTypeAdded
+		newPrimitiveFlat = flatmap.Flatten(new, nil, true)
+	} else if new == nil {
+		new = &TaskSecureVariable{}
+		diff.Type = DiffTypeDeleted
+		oldPrimitiveFlat = flatmap.Flatten(old, nil, true)
+	} else {
+		diff.Type = DiffTypeEdited
+		oldPrimitiveFlat = flatmap.Flatten(old, nil, true)
+		newPrimitiveFlat = flatmap.Flatten(new, nil, true)
+	}
+
+	// Diff the primitive fields.
+	diff.Fields = fieldDiffs(oldPrimitiveFlat, newPrimitiveFlat, contextual)
+
+	// Items diffs
+	if setDiff := stringSetDiff(old.Items, new.Items, "Items", contextual); setDiff != nil {
+		diff.Objects = append(diff.Objects, setDiff)
+	}
+
+	return diff
+}
+
+// taskSecureVariableDiffs returns the diff of two TaskSecureVariable slices,
+// matched by path. If contextual diff is enabled, all fields will be
+// returned, even if no diff occurred.
+func taskSecureVariableDiffs(old, new []*TaskSecureVariable, contextual bool) []*ObjectDiff {
+	oldMap := make(map[string]*TaskSecureVariable, len(old))
+	newMap := make(map[string]*TaskSecureVariable, len(new))
+	for _, o := range old {
+		oldMap[o.Path] = o
+	}
+	for _, n := range new {
+		newMap[n.Path] = n
+	}
+
+	var diffs []*ObjectDiff
+	for path, oldSV := range oldMap {
+		// Diff the same, deleted and edited
+		if diff := taskSecureVariableDiff(oldSV, newMap[path], contextual); diff != nil {
+			diffs = append(diffs, diff)
+		}
+	}
+
+	for path, newSV := range newMap {
+		// Diff the added
+		if _, ok := oldMap[path]; !ok {
+			if diff := taskSecureVariableDiff(nil, newSV, contextual); diff != nil {
+				diffs = append(diffs, diff)
+			}
+		}
+	}
+
+	sort.Sort(ObjectDiffs(diffs))
+	return diffs
+}
+
 // waitConfigDiff returns the diff of two WaitConfig objects. If contextual diff is
 // enabled, all fields will be returned, even if no diff occurred.
 func waitConfigDiff(old, new *WaitConfig, contextual bool) *ObjectDiff {
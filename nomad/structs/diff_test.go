@@ -6949,6 +6949,80 @@ func TestTaskDiff(t *testing.T) {
 				},
 			},
 		},
+		{
+			Name: "SecureVariables edited",
+			Old: &Task{
+				SecureVariables: []*TaskSecureVariable{
+					{
+						Path:       "app/db",
+						Items:      []string{"user", "pass"},
+						ChangeMode: "restart",
+					},
+					{
+						Path:       "app/api",
+						ChangeMode: "restart",
+					},
+				},
+			},
+			New: &Task{
+				SecureVariables: []*TaskSecureVariable{
+					{
+						Path:       "app/db",
+						Items:      []string{"pass"},
+						ChangeMode: "noop",
+					},
+				},
+			},
+			Expected: &TaskDiff{
+				Type: DiffTypeEdited,
+				Objects: []*ObjectDiff{
+					{
+						Type: DiffTypeEdited,
+						Name: "SecureVariable",
+						Fields: []*FieldDiff{
+							{
+								Type: DiffTypeEdited,
+								Name: "ChangeMode",
+								Old:  "restart",
+								New:  "noop",
+							},
+						},
+						Objects: []*ObjectDiff{
+							{
+								Type: DiffTypeDeleted,
+								Name: "Items",
+								Fields: []*FieldDiff{
+									{
+										Type: DiffTypeDeleted,
+										Name: "Items",
+										Old:  "user",
+										New:  "",
+									},
+								},
+							},
+						},
+					},
+					{
+						Type: DiffTypeDeleted,
+						Name: "SecureVariable",
+						Fields: []*FieldDiff{
+							{
+								Type: DiffTypeDeleted,
+								Name: "ChangeMode",
+								Old:  "restart",
+								New:  "",
+							},
+							{
+								Type: DiffTypeDeleted,
+								Name: "Path",
+								Old:  "app/api",
+								New:  "",
+							},
+						},
+					},
+				},
+			},
+		},
 		{
 			Name:       "Vault edited with context",
 			Contextual: true,
@@ -24,7 +24,14 @@ const (
 	TopicACLPolicy  Topic = "ACLPolicy"
 	TopicACLToken   Topic = "ACLToken"
 	TopicService    Topic = "Service"
-	TopicAll        Topic = "*"
+	TopicVariable   Topic = "Variable"
+
+	// TopicVariableAccess events are published by the server handling a
+	// request rather than from Raft, so they are served by a separate event
+	// broker. Their indexes are a sequence local to that server.
+	TopicVariableAccess Topic = "VariableAccess"
+
+	TopicAll Topic = "*"
 
 	TypeNodeRegistration              = "NodeRegistration"
 	TypeNodeDeregistration            = "NodeDeregistration"
@@ -48,6 +55,9 @@ const (
 	TypeACLPolicyUpserted             = "ACLPolicyUpserted"
 	TypeServiceRegistration           = "ServiceRegistration"
 	TypeServiceDeregistration         = "ServiceDeregistration"
+	TypeSecureVariableRead            = "SecureVariableRead"
+	TypeSecureVariableUpserted        = "SecureVariableUpserted"
+	TypeSecureVariableDeleted         = "SecureVariableDeleted"
 )
 
 // Event represents a change in Nomads state.
@@ -151,3 +161,39 @@ func (a *ACLTokenEvent) SecretID() string {
 type ACLPolicyEvent struct {
 	ACLPolicy *ACLPolicy
 }
+
+// SecureVariableEvent holds the metadata of a secure variable that was
+// written or deleted. It never holds the items of the variable.
+type SecureVariableEvent struct {
+	SecureVariable *SecureVariableMetadata
+}
+
+// SecureVariableAccessEvent holds an access to a secure variable, including
+// reads. These events are best effort: they are only published by the server
+// that handled the request, and are lost if it restarts.
+type SecureVariableAccessEvent struct {
+	SecureVariableAccess *SecureVariableAccess
+}
+
+// SecureVariableAccess records who read, wrote, or deleted a secure variable.
+// It never holds the items of the variable.
+type SecureVariableAccess struct {
+	Namespace string
+	Path      string
+
+	// AccessorID is the accessor of the ACL token used for the request. It
+	// is empty if ACLs are disabled or a workload identity was used.
+	AccessorID string
+
+	// AllocationID, JobID, and TaskName identify the workload whose
+	// identity was used for the request.
+	AllocationID string
+	JobID        string
+	TaskName     string
+
+	// ModifyIndex is the version of the variable that was read or written.
+	ModifyIndex uint64
+
+	// CheckIndex is the index a check-and-set write was checked against.
+	CheckIndex *uint64
+}
@@ -5,6 +5,8 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"sort"
+	"strings"
 	"time"
 
 	// note: this is aliased so that it's more noticeable if someone
@@ -43,6 +45,39 @@ const (
 	// Args: SecureVariablesByNameRequest
 	// Reply: SecureVariablesByNameResponse
 	SecureVariablesReadRPCMethod = "SecureVariables.Read"
+
+	// SecureVariablesHistoryRPCMethod is the RPC method for listing the
+	// kept previous versions of a secure variable.
+	//
+	// Args: SecureVariablesHistoryRequest
+	// Reply: SecureVariablesHistoryResponse
+	SecureVariablesHistoryRPCMethod = "SecureVariables.History"
+
+	// SecureVariablesCopyRPCMethod is the RPC method for copying or moving
+	// secure variables to new paths in a single transaction.
+	//
+	// Args: SecureVariablesCopyRequest
+	// Reply: SecureVariablesCopyResponse
+	SecureVariablesCopyRPCMethod = "SecureVariables.Copy"
+
+	// SecureVariablesTreeRPCMethod is the RPC method for listing secure
+	// variables as a tree of path segments.
+	//
+	// Args: SecureVariablesTreeRequest
+	// Reply: SecureVariablesTreeResponse
+	SecureVariablesTreeRPCMethod = "SecureVariables.Tree"
+
+	// SecureVariablesRekeyRPCMethod is the RPC method used by the core job
+	// that re-encrypts secure variables and their kept previous versions
+	// with the active root key after a full rotation.
+	//
+	// Args: SecureVariablesRekeyRequest
+	// Reply: GenericResponse
+	SecureVariablesRekeyRPCMethod = "SecureVariables.Rekey"
+
+	// SecureVariableTrackedVersions is the number of previous versions of a
+	// secure variable that are kept.
+	SecureVariableTrackedVersions = 5
 )
 
 // SecureVariableMetadata is the metadata envelope for a Secure Variable, it
@@ -55,6 +90,16 @@ type SecureVariableMetadata struct {
 	CreateTime  int64
 	ModifyIndex uint64
 	ModifyTime  int64
+
+	// ExpireTime is when the secure variable expires, in UnixNanos. Expired
+	// secure variables are treated as missing and are deleted by a periodic
+	// core job. Zero means the secure variable never expires.
+	ExpireTime int64
+}
+
+// Expired returns whether the secure variable has expired at the given time.
+func (sv SecureVariableMetadata) Expired(now time.Time) bool {
+	return sv.ExpireTime != 0 && sv.ExpireTime <= now.UnixNano()
 }
 
 // SecureVariableEncrypted structs are returned from the Encrypter's encrypt
@@ -154,6 +199,9 @@ func (sv SecureVariableDecrypted) Validate() error {
 	if sv.Namespace == AllNamespacesSentinel {
 		return errors.New("can not target wildcard (\"*\")namespace")
 	}
+	if sv.ExpireTime < 0 {
+		return errors.New("expire time can not be negative")
+	}
 	return nil
 }
 
@@ -233,8 +281,76 @@ type SecureVariablesListResponse struct {
 	QueryMeta
 }
 
+type SecureVariablesTreeRequest struct {
+	QueryOptions
+}
+
+type SecureVariablesTreeResponse struct {
+	Data *SecureVariableTreeNode
+	QueryMeta
+}
+
+// SecureVariableTreeNode is a path segment in a tree listing of secure
+// variables. The node holds a secure variable if one is stored at its path,
+// and may have children whether or not it does.
+type SecureVariableTreeNode struct {
+	Name     string
+	Path     string
+	Variable *SecureVariableTreeStub
+	Children []*SecureVariableTreeNode
+}
+
+// SecureVariableTreeStub is the metadata of a secure variable in a tree
+// listing, along with the number of items it holds and the size of its
+// encrypted data in bytes.
+type SecureVariableTreeStub struct {
+	SecureVariableMetadata
+	ItemCount int
+	Size      int
+}
+
+// NewSecureVariableTree builds the tree of path segments holding the secure
+// variables. The root node has an empty name and path, and children are
+// sorted by name.
+func NewSecureVariableTree(stubs []*SecureVariableTreeStub) *SecureVariableTreeNode {
+	root := &SecureVariableTreeNode{}
+	nodes := map[string]*SecureVariableTreeNode{"": root}
+	for _, stub := range stubs {
+		node := root
+		for _, name := range strings.Split(stub.Path, "/") {
+			path := name
+			if node.Path != "" {
+				path = node.Path + "/" + name
+			}
+			child, ok := nodes[path]
+			if !ok {
+				child = &SecureVariableTreeNode{Name: name, Path: path}
+				nodes[path] = child
+				node.Children = append(node.Children, child)
+			}
+			node = child
+		}
+		node.Variable = stub
+	}
+	root.sort()
+	return root
+}
+
+func (n *SecureVariableTreeNode) sort() {
+	sort.Slice(n.Children, func(i, j int) bool {
+		return n.Children[i].Name < n.Children[j].Name
+	})
+	for _, c := range n.Children {
+		c.sort()
+	}
+}
+
 type SecureVariablesReadRequest struct {
 	Path string
+
+	// Version is the ModifyIndex of a previous version of the variable to
+	// read. The current version is read when it is zero.
+	Version uint64
 	QueryOptions
 }
 
@@ -243,6 +359,102 @@ type SecureVariablesReadResponse struct {
 	QueryMeta
 }
 
+type SecureVariablesHistoryRequest struct {
+	Path string
+	QueryOptions
+}
+
+type SecureVariablesHistoryResponse struct {
+	Data []*SecureVariableMetadata
+	QueryMeta
+}
+
+// SecureVariablesCopyRequest copies the secure variable at Path, or with
+// Recurse every secure variable under it, to DestPath in DestNamespace. With
+// Move the copied variables are deleted.
+type SecureVariablesCopyRequest struct {
+	Path          string
+	DestNamespace string
+	DestPath      string
+	Recurse       bool
+	Move          bool
+	WriteRequest
+}
+
+// SecureVariableCopy describes a single secure variable copied by a
+// SecureVariablesCopyRequest.
+type SecureVariableCopy struct {
+	Namespace     string
+	Path          string
+	DestNamespace string
+	DestPath      string
+}
+
+type SecureVariablesCopyResponse struct {
+	Copies []*SecureVariableCopy
+	WriteMeta
+}
+
+// SecureVariablesEncryptedCopyRequest is the Raft request applied for a
+// SecureVariablesCopyRequest. The copies in Data are written only if none of
+// them exist and the Sources haven't been modified since they were read. The
+// Expired secure variables at the destination paths are replaced by the
+// copies, unless they have been modified since they were read.
+type SecureVariablesEncryptedCopyRequest struct {
+	Sources []*SecureVariableMetadata
+	Data    []*SecureVariableEncrypted
+	Expired []*SecureVariableMetadata
+	Move    bool
+	WriteRequest
+}
+
+// SecureVariablesRekeyRequest holds secure variables and kept previous
+// versions of secure variables that have been re-encrypted with another root
+// key. Only the encrypted data of each entry is replaced, and entries that
+// have been modified or dropped since they were read are skipped.
+type SecureVariablesRekeyRequest struct {
+	Data     []*SecureVariableEncrypted
+	Versions []*SecureVariableEncrypted
+	WriteRequest
+}
+
+// SecureVariableChanges holds the writes and deletions of secure variables
+// made at a Raft index. They are kept in the state store while any sink has
+// yet to receive them, so that a new leader can resume forwarding them.
+type SecureVariableChanges struct {
+	Index   uint64
+	Changes []*SecureVariableChange
+}
+
+// SecureVariableChange is a write or deletion of a secure variable. It never
+// holds the items of the variable.
+type SecureVariableChange struct {
+	// Type is TypeSecureVariableUpserted or TypeSecureVariableDeleted
+	Type           string
+	SecureVariable *SecureVariableMetadata
+}
+
+// SecureVariablesSinkCheckpoint is the index of the last secure variable
+// changes the leader has delivered to a sink.
+type SecureVariablesSinkCheckpoint struct {
+	Name        string
+	LastIndex   uint64
+	CreateIndex uint64
+	ModifyIndex uint64
+}
+
+// SecureVariablesSinkCheckpointRequest updates the checkpoints of secure
+// variable sinks. Changes that all the sinks have received are dropped.
+type SecureVariablesSinkCheckpointRequest struct {
+	Checkpoints []*SecureVariablesSinkCheckpoint
+
+	// Reset removes the checkpoints of sinks that aren't in Checkpoints.
+	// It's set when a leader registers the sinks it's configured with.
+	Reset bool
+
+	WriteRequest
+}
+
 type SecureVariablesDeleteRequest struct {
 	Path       string
 	CheckIndex *uint64
@@ -436,6 +648,30 @@ type KeyringListRootKeyMetaResponse struct {
 	QueryMeta
 }
 
+type KeyringRotateStatusRequest struct {
+	QueryOptions
+}
+
+// KeyringRotateStatusResponse reports the progress of full key rotations.
+// Rekeying is empty once every secure variable is encrypted with the active
+// key.
+type KeyringRotateStatusResponse struct {
+	ActiveKeyID string
+	Rekeying    []*KeyringRekeyStatus
+	QueryMeta
+}
+
+// KeyringRekeyStatus is the progress of re-encrypting the secure variables
+// of a root key in the rekeying state with the active key.
+type KeyringRekeyStatus struct {
+	KeyID      string
+	CreateTime time.Time
+
+	// Remaining is the number of secure variables and kept previous versions
+	// of secure variables still encrypted with the key.
+	Remaining int
+}
+
 // KeyringUpdateRootKeyRequest is used internally for key replication
 // only and for keyring restores. The RootKeyMeta will be extracted
 // for applying to the FSM with the KeyringUpdateRootKeyMetaRequest
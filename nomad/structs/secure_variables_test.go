@@ -31,3 +31,37 @@ func TestStructs_SecureVariableDecrypted_Copy(t *testing.T) {
 	sv2.Items["new"] = "new"
 	require.False(t, sv.Equals(sv2), "sv and sv2 should not be equal")
 }
+
+func TestStructs_NewSecureVariableTree(t *testing.T) {
+	ci.Parallel(t)
+
+	stub := func(p string) *SecureVariableTreeStub {
+		return &SecureVariableTreeStub{
+			SecureVariableMetadata: SecureVariableMetadata{Namespace: "default", Path: p},
+		}
+	}
+	root := NewSecureVariableTree([]*SecureVariableTreeStub{
+		stub("b"), stub("a/y/z"), stub("a/x"), stub("a"),
+	})
+
+	require.Empty(t, root.Path)
+	require.Nil(t, root.Variable)
+	require.Len(t, root.Children, 2)
+
+	a := root.Children[0]
+	require.Equal(t, "a", a.Path)
+	require.NotNil(t, a.Variable)
+	require.Len(t, a.Children, 2)
+	require.Equal(t, "a/x", a.Children[0].Path)
+	require.Equal(t, "x", a.Children[0].Name)
+
+	y := a.Children[1]
+	require.Equal(t, "a/y", y.Path)
+	require.Nil(t, y.Variable)
+	require.Len(t, y.Children, 1)
+	require.Equal(t, "a/y/z", y.Children[0].Path)
+	require.NotNil(t, y.Children[0].Variable)
+
+	require.Equal(t, "b", root.Children[1].Path)
+	require.Empty(t, root.Children[1].Children)
+}
@@ -113,6 +113,9 @@ const (
 	SecureVariableDeleteRequestType              MessageType = 51
 	RootKeyMetaUpsertRequestType                 MessageType = 52
 	RootKeyMetaDeleteRequestType                 MessageType = 53
+	SecureVariableCopyRequestType                MessageType = 54
+	SecureVariableRekeyRequestType               MessageType = 55
+	SecureVariablesSinkCheckpointRequestType     MessageType = 56
 
 	// Namespace types were moved from enterprise and therefore start at 64
 	NamespaceUpsertRequestType MessageType = 64
@@ -6965,6 +6968,10 @@ type Task struct {
 	// Templates are the set of templates to be rendered for the task.
 	Templates []*Template
 
+	// SecureVariables are the secure variables whose items are injected
+	// into the task's environment.
+	SecureVariables []*TaskSecureVariable
+
 	// Constraints can be specified at a task level and apply only to
 	// the particular task.
 	Constraints []*Constraint
@@ -7088,6 +7095,14 @@ func (t *Task) Copy() *Task {
 		nt.Templates = templates
 	}
 
+	if t.SecureVariables != nil {
+		vars := make([]*TaskSecureVariable, len(t.SecureVariables))
+		for i, v := range nt.SecureVariables {
+			vars[i] = v.Copy()
+		}
+		nt.SecureVariables = vars
+	}
+
 	return nt
 }
 
@@ -7132,6 +7147,10 @@ func (t *Task) Canonicalize(job *Job, tg *TaskGroup) {
 	for _, template := range t.Templates {
 		template.Canonicalize()
 	}
+
+	for _, sv := range t.SecureVariables {
+		sv.Canonicalize()
+	}
 }
 
 func (t *Task) GoString() string {
@@ -7243,6 +7262,21 @@ func (t *Task) Validate(ephemeralDisk *EphemeralDisk, jobType string, tgServices
 		}
 	}
 
+	paths := make(map[string]int, len(t.SecureVariables))
+	for idx, sv := range t.SecureVariables {
+		if err := sv.Validate(); err != nil {
+			outer := fmt.Errorf("Secure variable %d validation failed: %s", idx+1, err)
+			mErr.Errors = append(mErr.Errors, outer)
+		}
+
+		if other, ok := paths[sv.Path]; ok {
+			outer := fmt.Errorf("Secure variable %d has same path as %d", idx+1, other)
+			mErr.Errors = append(mErr.Errors, outer)
+		} else {
+			paths[sv.Path] = idx + 1
+		}
+	}
+
 	// Validate the dispatch payload block if there
 	if t.DispatchPayload != nil {
 		if err := t.DispatchPayload.Validate(); err != nil {
@@ -9072,6 +9106,97 @@ func (v *Vault) Validate() error {
 	return mErr.ErrorOrNil()
 }
 
+const (
+	// SecureVariableChangeModeNoop takes no action when an injected secure
+	// variable item changes.
+	SecureVariableChangeModeNoop = "noop"
+
+	// SecureVariableChangeModeRestart restarts the task when an injected
+	// secure variable item changes.
+	SecureVariableChangeModeRestart = "restart"
+)
+
+var (
+	// validSecureVariableItemName is used to validate the names of the items
+	// injected as environment variables
+	validSecureVariableItemName = regexp.MustCompile("^[A-Za-z_][A-Za-z0-9_]*$")
+)
+
+// ValidSecureVariableItemName returns whether a secure variable item can be
+// injected as an environment variable of the same name.
+func ValidSecureVariableItemName(name string) bool {
+	return validSecureVariableItemName.MatchString(name)
+}
+
+// TaskSecureVariable is a secure variable whose items are injected into the
+// task's environment when it starts. The secure variable is read from the
+// job's namespace with the task's workload identity.
+type TaskSecureVariable struct {
+	// Path is the path of the secure variable.
+	Path string
+
+	// Items are the keys of the items to inject, each as an environment
+	// variable of the same name. All the items are injected if empty.
+	Items []string
+
+	// ChangeMode is used to configure the task's behavior when an injected
+	// item changes while the task is running.
+	ChangeMode string
+}
+
+// Copy returns a copy of this TaskSecureVariable block.
+func (sv *TaskSecureVariable) Copy() *TaskSecureVariable {
+	if sv == nil {
+		return nil
+	}
+
+	nsv := new(TaskSecureVariable)
+	*nsv = *sv
+	nsv.Items = helper.CopySliceString(sv.Items)
+	return nsv
+}
+
+func (sv *TaskSecureVariable) Canonicalize() {
+	if sv.ChangeMode == "" {
+		sv.ChangeMode = SecureVariableChangeModeRestart
+	}
+}
+
+// Validate returns if the TaskSecureVariable block is valid.
+func (sv *TaskSecureVariable) Validate() error {
+	if sv == nil {
+		return nil
+	}
+
+	var mErr multierror.Error
+	if strings.Trim(sv.Path, "/") == "" {
+		_ = multierror.Append(&mErr, fmt.Errorf("Must specify a path"))
+	}
+
+	seen := make(map[string]struct{}, len(sv.Items))
+	for _, item := range sv.Items {
+		if item == "" {
+			_ = multierror.Append(&mErr, fmt.Errorf("Item names cannot be empty"))
+			continue
+		}
+		if !ValidSecureVariableItemName(item) {
+			_ = multierror.Append(&mErr, fmt.Errorf("Item %q is not a valid environment variable name", item))
+		}
+		if _, ok := seen[item]; ok {
+			_ = multierror.Append(&mErr, fmt.Errorf("Item %q is listed more than once", item))
+		}
+		seen[item] = struct{}{}
+	}
+
+	switch sv.ChangeMode {
+	case SecureVariableChangeModeNoop, SecureVariableChangeModeRestart:
+	default:
+		_ = multierror.Append(&mErr, fmt.Errorf("Unknown change mode %q", sv.ChangeMode))
+	}
+
+	return mErr.ErrorOrNil()
+}
+
 const (
 	// DeploymentStatuses are the various states a deployment can be be in
 	DeploymentStatusRunning    = "running"
@@ -10815,6 +10940,10 @@ const (
 	// variables and re-encrypting them with the active key
 	CoreJobSecureVariablesRekey = "secure-variables-rekey"
 
+	// CoreJobSecureVariablesGC is used for the garbage collection of
+	// expired secure variables.
+	CoreJobSecureVariablesGC = "secure-variables-gc"
+
 	// CoreJobForceGC is used to force garbage collection of all GCable objects.
 	CoreJobForceGC = "force-gc"
 )
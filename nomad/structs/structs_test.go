@@ -1605,6 +1605,39 @@ func TestTask_Validate(t *testing.T) {
 	)
 }
 
+func TestTask_Validate_SecureVariables(t *testing.T) {
+	ci.Parallel(t)
+
+	task := &Task{
+		Name:      "web",
+		Driver:    "docker",
+		Resources: DefaultResources(),
+		LogConfig: DefaultLogConfig(),
+		SecureVariables: []*TaskSecureVariable{
+			{Path: "app/db", Items: []string{"user", "pass"}, ChangeMode: SecureVariableChangeModeRestart},
+			{Path: "app/api", ChangeMode: SecureVariableChangeModeNoop},
+		},
+	}
+	ephemeralDisk := DefaultEphemeralDisk()
+	require.NoError(t, task.Validate(ephemeralDisk, JobTypeService, nil, nil))
+
+	task.SecureVariables = []*TaskSecureVariable{
+		{Path: "/", ChangeMode: SecureVariableChangeModeRestart},
+		{Path: "app/db", Items: []string{"user", "user", "", "db-pass", "1pass"}, ChangeMode: "signal"},
+		{Path: "app/db", ChangeMode: SecureVariableChangeModeRestart},
+	}
+	err := task.Validate(ephemeralDisk, JobTypeService, nil, nil)
+	requireErrors(t, err,
+		"Must specify a path",
+		`Item "user" is listed more than once`,
+		"Item names cannot be empty",
+		`Item "db-pass" is not a valid environment variable name`,
+		`Item "1pass" is not a valid environment variable name`,
+		`Unknown change mode "signal"`,
+		"Secure variable 3 has same path as 2",
+	)
+}
+
 func TestTask_Validate_Resources(t *testing.T) {
 	ci.Parallel(t)
 